@@ -0,0 +1,91 @@
+package boltstore
+
+import (
+	"context"
+	"log"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// touch persists the last access time of the loaded record of session id,
+// if the stored one drifted more than Options.AccessResolution. Concurrent
+// loads sharing the record write it once.
+func (s *BoltStore) touch(ctx context.Context, id string, rec *record) {
+	if s.options.AccessResolution <= 0 {
+		return
+	}
+	now := time.Now()
+	last := rec.accessed.Load()
+	if time.Unix(last, 0).Add(s.options.AccessResolution).After(now) || !rec.accessed.CompareAndSwap(last, now.Unix()) {
+		return
+	}
+	ok, err := s.refresh(func(tx *bolt.Tx) error {
+		b, _ := s.findTx(tx, []byte(id), s.buckets[0])
+		if b == nil || b.Get(keyDeletedUntil) != nil {
+			return nil
+		}
+		return b.Put(keyLastAccess, encodeExpiry(now))
+	})
+	if !ok {
+		// shed under load, a later load writes it
+		rec.accessed.CompareAndSwap(now.Unix(), last)
+	}
+	if err != nil {
+		log.Printf("boltstore: update session last access error: %v", s.traced(ctx, err))
+	}
+}
+
+// SessionInfo is metadata of a stored session.
+type SessionInfo struct {
+	ID         string
+	Bucket     string    // bucket the session is stored in
+	Version    uint64    // record version, see Version
+	Size       int       // serialized values length
+	ExpiresAt  time.Time // expiration time
+	LastAccess time.Time // last load or save, zero if not tracked, see Options.AccessResolution
+	Accesses   uint64    // approximate count of loads, see Options.CountAccesses
+	Pinned     bool      // exempt from eviction, see Pin
+	CreatedAt  time.Time // creation time, zero if saved by older versions, see Options.MaxLifetime
+}
+
+// Info returns metadata of the active session with given id, without
+// loading its values. Returns ErrNotFound if there is no active session.
+func (s *BoltStore) Info(id string) (*SessionInfo, error) {
+	if err := s.enter(); err != nil {
+		return nil, err
+	}
+	defer s.leave()
+	var info SessionInfo
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if s.activeBucket(tx, []byte(id), time.Now()) == nil {
+			return ErrNotFound
+		}
+		b, spec := s.findTx(tx, []byte(id), s.buckets[0])
+		info = s.infoTx(b, spec, id)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// infoTx returns metadata of session id stored in bucket b of spec.
+func (s *BoltStore) infoTx(b *bolt.Bucket, spec *bucketSpec, id string) SessionInfo {
+	info := SessionInfo{
+		ID:       id,
+		Bucket:   string(spec.name),
+		Version:  decodeUint(b.Get(keyVersion)),
+		Size:     len(b.Get(keyValues)),
+		Accesses: decodeUint(b.Get(keyAccessCount)),
+		Pinned:   b.Get(keyPinned) != nil,
+	}
+	info.ExpiresAt, _ = decodeExpiry(b.Get(keyExpiredAt))
+	info.LastAccess, _ = decodeExpiry(b.Get(keyLastAccess))
+	info.CreatedAt, _ = decodeExpiry(b.Get(keyCreatedAt))
+	if s.counts != nil {
+		info.Accesses += s.counts.pending(id)
+	}
+	return info
+}
@@ -0,0 +1,138 @@
+package boltstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// setLastAccess overwrites stored last access time of session id.
+func setLastAccess(t *testing.T, store *BoltStore, id string, at time.Time) {
+	t.Helper()
+	err := store.DB().Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(store.options.BucketName).Bucket([]byte(id)).Put(keyLastAccess, encodeExpiry(at))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLastAccess(t *testing.T) {
+	for _, opts := range []Options{{AccessResolution: time.Hour}, {AccessResolution: time.Hour, CacheSize: 10}} {
+		store := newTestStore(t, opts)
+		cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 1})
+		_, session := loadCookie(t, store, "session-key", cookie)
+		info, err := store.Info(session.ID)
+		if err != nil || time.Since(info.LastAccess) > time.Minute {
+			t.Fatalf("Expected last access on save; Got %+v, %v", info, err)
+		}
+		if info.Version != 1 || info.Bucket != "sessions" || info.Size == 0 {
+			t.Errorf("Unexpected info %+v", info)
+		}
+
+		// within resolution, not written
+		recent := time.Now().Add(-30 * time.Minute).Truncate(time.Second)
+		setLastAccess(t, store, session.ID, recent)
+		store.forget(session.ID)
+		loadCookie(t, store, "session-key", cookie)
+		if info, _ = store.Info(session.ID); !info.LastAccess.Equal(recent) {
+			t.Errorf("Expected throttled last access %v; Got %v", recent, info.LastAccess)
+		}
+
+		setLastAccess(t, store, session.ID, time.Now().Add(-2*time.Hour))
+		store.forget(session.ID)
+		loadCookie(t, store, "session-key", cookie)
+		if info, _ = store.Info(session.ID); time.Since(info.LastAccess) > time.Minute {
+			t.Errorf("Expected updated last access; Got %v", info.LastAccess)
+		}
+	}
+
+	store := newTestStore(t, Options{})
+	cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 1})
+	_, session := loadCookie(t, store, "session-key", cookie)
+	if info, err := store.Info(session.ID); err != nil || !info.LastAccess.IsZero() {
+		t.Errorf("Expected untracked last access; Got %+v, %v", info, err)
+	}
+	if _, err := store.Info("missing"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound; Got %v", err)
+	}
+}
+
+func TestAccessCount(t *testing.T) {
+	store := newTestStore(t, Options{CountAccesses: true, CacheSize: 10})
+	cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 1})
+	var id string
+	for i := 0; i < 3; i++ {
+		_, session := loadCookie(t, store, "session-key", cookie)
+		id = session.ID
+	}
+	if info, err := store.Info(id); err != nil || info.Accesses != 3 {
+		t.Fatalf("Expected 3 pending accesses; Got %+v, %v", info, err)
+	}
+
+	store.flushAccesses()
+	loadCookie(t, store, "session-key", cookie)
+	if info, _ := store.Info(id); info.Accesses != 4 {
+		t.Errorf("Expected 4 accesses; Got %d", info.Accesses)
+	}
+	store.DB().View(func(tx *bolt.Tx) error {
+		if n := decodeUint(tx.Bucket(store.options.BucketName).Bucket([]byte(id)).Get(keyAccessCount)); n != 3 {
+			t.Errorf("Expected 3 stored accesses; Got %d", n)
+		}
+		return nil
+	})
+}
+
+func TestIdleTimeout(t *testing.T) {
+	store := newTestStore(t, Options{IdleTimeout: time.Hour})
+	if store.options.AccessResolution != 6*time.Minute {
+		t.Errorf("Expected default access resolution; Got %v", store.options.AccessResolution)
+	}
+	idle := saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 1})
+	active := saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 2})
+	_, session := loadCookie(t, store, "session-key", idle)
+	setLastAccess(t, store, session.ID, time.Now().Add(-2*time.Hour))
+
+	store.reapBucket(store.buckets[0])
+	if store.Exists(session.ID) {
+		t.Error("Expected idle session to be reaped")
+	}
+	if _, session = loadCookie(t, store, "session-key", active); session.IsNew {
+		t.Error("Expected active session")
+	}
+}
+
+func TestListUserSessions(t *testing.T) {
+	store := newTestStore(t, Options{UserKey: "user", AccessResolution: time.Hour})
+	first := saveNew(t, store, "session-key", map[interface{}]interface{}{"user": "alice"})
+	saveNew(t, store, "session-key", map[interface{}]interface{}{"user": "bob"})
+	second := saveNew(t, store, "session-key", map[interface{}]interface{}{"user": "alice"})
+	_, s1 := loadCookie(t, store, "session-key", first)
+	_, s2 := loadCookie(t, store, "session-key", second)
+
+	list, err := store.ListUserSessions(context.Background(), "alice")
+	if err != nil || len(list) != 2 || list[0].ID != s1.ID || list[1].ID != s2.ID {
+		t.Fatalf("Expected both sessions of alice in save order; Got %+v, %v", list, err)
+	}
+	if time.Since(list[0].LastAccess) > time.Minute || list[0].Version != 1 {
+		t.Errorf("Expected session info; Got %+v", list[0])
+	}
+
+	// sessions logged out or switched to another user are not listed
+	req, session := loadCookie(t, store, "session-key", first)
+	session.Values["user"] = "bob"
+	if err := store.Save(req, NewRecorder(), session); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete(context.Background(), s2.ID); err != nil {
+		t.Fatal(err)
+	}
+	if list, err = store.ListUserSessions(context.Background(), "alice"); err != nil || len(list) != 0 {
+		t.Errorf("Expected no sessions of alice; Got %+v, %v", list, err)
+	}
+	if list, err = store.ListUserSessions(context.Background(), "bob"); err != nil || len(list) != 2 {
+		t.Errorf("Expected both sessions of bob; Got %+v, %v", list, err)
+	}
+}
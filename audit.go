@@ -0,0 +1,64 @@
+package boltstore
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// AuditEntry is a security relevant session transition recorded by the
+// store, such as the start and end of an impersonation.
+type AuditEntry struct {
+	At      time.Time `json:"at"`
+	Event   string    `json:"event"`
+	Actor   string    `json:"actor"`   // user performing the transition
+	User    string    `json:"user"`    // user the transition applies to
+	Session string    `json:"session"` // session ID, redacted with Options.RedactIDs
+}
+
+// AuditLog calls fn with recorded audit entries, oldest first, until fn
+// returns an error or ctx is done.
+func (s *BoltStore) AuditLog(ctx context.Context, fn func(AuditEntry) error) error {
+	if err := s.enter(); err != nil {
+		return err
+	}
+	defer s.leave()
+	return s.db.View(func(tx *bolt.Tx) error {
+		audit := tx.Bucket(auditBucketName(s.options.BucketName))
+		if audit == nil {
+			return nil
+		}
+		return audit.ForEach(func(k, v []byte) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			var e AuditEntry
+			if err := json.Unmarshal(v, &e); err != nil {
+				return fmt.Errorf("decode audit entry error: %w", err)
+			}
+			return fn(e)
+		})
+	})
+}
+
+// auditTx appends e to the audit log within transaction tx.
+func (s *BoltStore) auditTx(tx *bolt.Tx, e AuditEntry) error {
+	audit, err := tx.CreateBucketIfNotExists(auditBucketName(s.options.BucketName))
+	if err != nil {
+		return fmt.Errorf("create audit bucket error: %w", err)
+	}
+	seq, err := audit.NextSequence()
+	if err != nil {
+		return err
+	}
+	e.At = time.Now().UTC()
+	e.Session = s.safeID(e.Session)
+	b, _ := json.Marshal(e)
+	var key [8]byte // big endian keeps entries in order
+	binary.BigEndian.PutUint64(key[:], seq)
+	return audit.Put(key[:], b)
+}
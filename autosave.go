@@ -0,0 +1,192 @@
+package boltstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+)
+
+// SaveErrorAction is what AutoSave does when a session can't be saved.
+type SaveErrorAction int
+
+const (
+	// SaveErrorProblem responds with status 500 and an
+	// application/problem+json body instead of the handler response.
+	SaveErrorProblem SaveErrorAction = iota
+	// SaveErrorRetry saves again up to AutoSaveOptions.Retries times, then
+	// responds like SaveErrorProblem.
+	SaveErrorRetry
+	// SaveErrorCookie keeps session values in the cookie as a CookieStore
+	// payload encoded with Options.CookieStoreKeyPairs, which the store
+	// saves server-side under a new ID on the next request. Without the key
+	// pairs or if the values don't fit a cookie, it responds like
+	// SaveErrorProblem.
+	SaveErrorCookie
+)
+
+// AutoSaveOptions configure AutoSave.
+type AutoSaveOptions struct {
+	OnError SaveErrorAction
+	Retries int // saves retried with SaveErrorRetry, 1 by default
+}
+
+// autoSaveKey is the request context key of the autoSave of a request.
+type autoSaveKey struct{}
+
+// AutoSave returns middleware saving sessions the handler got with Get
+// before the response is written, so handlers don't call Save. Save errors
+// are reported to OnError hooks and handled as configured by opts.OnError.
+func (s *BoltStore) AutoSave(opts AutoSaveOptions) func(http.Handler) http.Handler {
+	if opts.Retries == 0 {
+		opts.Retries = 1
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			as := &autoSave{store: s, opts: opts, w: w}
+			as.r = r.WithContext(context.WithValue(r.Context(), autoSaveKey{}, as))
+			next.ServeHTTP(as, as.r)
+			as.save()
+		})
+	}
+}
+
+// autoSave is the response writer of AutoSave, saving the sessions of
+// a request before the response header is written.
+type autoSave struct {
+	store *BoltStore
+	opts  AutoSaveOptions
+	w     http.ResponseWriter
+	r     *http.Request
+
+	mu     sync.Mutex
+	saved  bool
+	failed bool // the problem response was written, the handler's one is discarded
+
+	tmu      sync.Mutex // guards sessions, untracked by saves within save
+	sessions []*sessions.Session
+}
+
+// track adds a session got with Get during request r to its autoSave.
+func track(r *http.Request, session *sessions.Session) {
+	as, ok := r.Context().Value(autoSaveKey{}).(*autoSave)
+	if !ok {
+		return
+	}
+	as.tmu.Lock()
+	defer as.tmu.Unlock()
+	for _, tracked := range as.sessions {
+		if tracked == session {
+			return
+		}
+	}
+	as.sessions = append(as.sessions, session)
+}
+
+// untrack removes a session saved or deleted by the handler of request r
+// from its autoSave, so it isn't written again after the handler returns.
+func untrack(r *http.Request, session *sessions.Session) {
+	if r == nil {
+		return
+	}
+	as, ok := r.Context().Value(autoSaveKey{}).(*autoSave)
+	if !ok {
+		return
+	}
+	as.tmu.Lock()
+	defer as.tmu.Unlock()
+	for i, tracked := range as.sessions {
+		if tracked == session {
+			as.sessions = append(as.sessions[:i:i], as.sessions[i+1:]...)
+			return
+		}
+	}
+}
+
+func (as *autoSave) Header() http.Header {
+	return as.w.Header()
+}
+
+func (as *autoSave) WriteHeader(code int) {
+	if as.save() {
+		as.w.WriteHeader(code)
+	}
+}
+
+func (as *autoSave) Write(b []byte) (int, error) {
+	if !as.save() {
+		return len(b), nil
+	}
+	return as.w.Write(b)
+}
+
+// Unwrap returns the wrapped response writer, see http.ResponseController.
+func (as *autoSave) Unwrap() http.ResponseWriter {
+	return as.w
+}
+
+// save saves tracked sessions once. returns false if the problem response
+// was written instead.
+func (as *autoSave) save() bool {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	if as.saved {
+		return !as.failed
+	}
+	as.saved = true
+	as.tmu.Lock()
+	tracked := append([]*sessions.Session(nil), as.sessions...)
+	as.tmu.Unlock()
+	for _, session := range tracked {
+		err := as.store.Save(as.r, as.w, session)
+		if err == nil {
+			continue
+		}
+		as.store.reportError(fmt.Errorf("auto save session %q error: %w", session.Name(), err))
+		switch as.opts.OnError {
+		case SaveErrorRetry:
+			for i := 0; i < as.opts.Retries && err != nil; i++ {
+				err = as.store.Save(as.r, as.w, session)
+			}
+		case SaveErrorCookie:
+			err = as.store.saveCookie(as.w, session)
+		}
+		if err != nil {
+			as.failed = true
+			writeProblem(as.w)
+			return false
+		}
+	}
+	return true
+}
+
+// saveCookie sets the values of session as a CookieStore payload cookie.
+func (s *BoltStore) saveCookie(w http.ResponseWriter, session *sessions.Session) error {
+	if s.legacy == nil {
+		return errors.New("no CookieStore key pairs to keep values in cookie")
+	}
+	name := cookieName(s.options, session.Name())
+	encoded, err := securecookie.EncodeMulti(name, stripMeta(session).Values, s.legacy...)
+	if err != nil {
+		return fmt.Errorf("encode cookie session error: %w", err)
+	}
+	setCookies(w, name, encoded, session.Options, s.options.CookieScopes)
+	return nil
+}
+
+// writeProblem responds with an RFC 9457 problem of a failed session save.
+func writeProblem(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(http.StatusInternalServerError)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"type":   "about:blank",
+		"title":  http.StatusText(http.StatusInternalServerError),
+		"status": http.StatusInternalServerError,
+		"detail": "session could not be saved",
+	})
+}
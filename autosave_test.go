@@ -0,0 +1,94 @@
+package boltstore
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// autoSaveHandler sets value v in the session and responds with 201.
+func autoSaveHandler(t *testing.T, store *BoltStore, v string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session, err := store.Get(r, "session-key")
+		if err != nil {
+			t.Errorf("Error getting session: %v", err)
+		}
+		session.Values["v"] = v
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created"))
+	})
+}
+
+func TestAutoSave(t *testing.T) {
+	store := newTestStore(t, Options{})
+	h := store.AutoSave(AutoSaveOptions{})(autoSaveHandler(t, store, "saved"))
+	rsp := httptest.NewRecorder()
+	h.ServeHTTP(rsp, httptest.NewRequest("GET", "http://localhost:8080/", nil))
+	if rsp.Code != http.StatusCreated || rsp.Body.String() != "created" {
+		t.Fatalf("Expected handler response; Got %d %q", rsp.Code, rsp.Body)
+	}
+	if _, session := loadCookie(t, store, "session-key", rsp.Header().Get("Set-Cookie")); session.Values["v"] != "saved" {
+		t.Errorf("Expected saved session; Got %v", session.Values)
+	}
+}
+
+func TestAutoSaveErrors(t *testing.T) {
+	big := strings.Repeat("x", 100)
+	for name, opts := range map[string]AutoSaveOptions{
+		"problem": {},
+		"retry":   {OnError: SaveErrorRetry, Retries: 2},
+		"cookie":  {OnError: SaveErrorCookie}, // without CookieStoreKeyPairs
+	} {
+		store := newTestStore(t, Options{MaxLength: 50})
+		var errs int
+		store.OnError(func(error) { errs++ })
+		h := store.AutoSave(opts)(autoSaveHandler(t, store, big))
+		rsp := httptest.NewRecorder()
+		h.ServeHTTP(rsp, httptest.NewRequest("GET", "http://localhost:8080/", nil))
+		if rsp.Code != http.StatusInternalServerError || rsp.Header().Get("Content-Type") != "application/problem+json" {
+			t.Errorf("%s: expected problem response; Got %d %q", name, rsp.Code, rsp.Header().Get("Content-Type"))
+		}
+		if strings.Contains(rsp.Body.String(), "created") {
+			t.Errorf("%s: expected handler response discarded; Got %q", name, rsp.Body)
+		}
+		if errs != 1 {
+			t.Errorf("%s: expected reported error; Got %d", name, errs)
+		}
+	}
+}
+
+func TestAutoSaveCookie(t *testing.T) {
+	store := newTestStore(t, Options{MaxLength: 50, CookieStoreKeyPairs: [][]byte{[]byte("cookie-key")}})
+	h := store.AutoSave(AutoSaveOptions{OnError: SaveErrorCookie})(autoSaveHandler(t, store, strings.Repeat("x", 30)))
+	rsp := httptest.NewRecorder()
+	h.ServeHTTP(rsp, httptest.NewRequest("GET", "http://localhost:8080/", nil))
+	if rsp.Code != http.StatusCreated {
+		t.Fatalf("Expected handler response; Got %d %q", rsp.Code, rsp.Body)
+	}
+	_, session := loadCookie(t, store, "session-key", rsp.Header().Get("Set-Cookie"))
+	if session.ID != "" || session.Values["v"] != strings.Repeat("x", 30) {
+		t.Errorf("Expected values kept in cookie; Got %q %v", session.ID, session.Values)
+	}
+}
+
+func TestAutoSaveSaved(t *testing.T) {
+	store := newTestStore(t, Options{UserKey: "user"})
+	cookie := saveNew(t, store, "session", map[interface{}]interface{}{"user": "u1"})
+	h := store.AutoSave(AutoSaveOptions{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := Logout(r, w, store); err != nil {
+			t.Errorf("Error logging out: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	rsp := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "http://localhost:8080/", nil)
+	req.Header.Add("Cookie", cookie)
+	h.ServeHTTP(rsp, req)
+	if rsp.Code != http.StatusNoContent {
+		t.Fatalf("Expected handler response; Got %d %q", rsp.Code, rsp.Body)
+	}
+	if cookies := rsp.Header()["Set-Cookie"]; len(cookies) != 1 {
+		t.Errorf("Expected a single cookie; Got %v", cookies)
+	}
+}
@@ -0,0 +1,35 @@
+package boltstore
+
+import (
+	"context"
+	"time"
+)
+
+// Record is a session record kept by a Backend.
+type Record struct {
+	Data      []byte    // serialized session values
+	ExpiresAt time.Time // server-side expiration time
+	Version   uint64    // incremented on every write
+}
+
+// Backend is a key-value persistence layer for session records, used by
+// BackendStore. It is the extension point for custom storage engines:
+// implementations must be safe for concurrent use, must not retain slices
+// passed to or returned from them, and must preserve ExpiresAt with at least
+// second precision. Package backendtest provides the conformance suite.
+type Backend interface {
+	// Get returns the record of session id, nil if it is not stored.
+	Get(ctx context.Context, id string) (*Record, error)
+	// Put stores the record of session id, replacing the existing one.
+	// It fails with ErrConflict without writing if the stored record has a
+	// version not older than rec.Version, so concurrent writers of the same
+	// version don't overwrite each other.
+	Put(ctx context.Context, id string, rec *Record) error
+	// Delete removes the record of session id, deleting an absent record
+	// is not an error.
+	Delete(ctx context.Context, id string) error
+	// ScanExpired calls fn with the ID of every record expired at time now.
+	ScanExpired(ctx context.Context, now time.Time, fn func(id string) error) error
+	// Close releases the backend resources.
+	Close() error
+}
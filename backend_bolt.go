@@ -0,0 +1,109 @@
+package boltstore
+
+import (
+	"context"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// boltBackend is a Backend keeping records in a bolt bucket with the same
+// bucket-per-session layout as BoltStore.
+type boltBackend struct {
+	db     *bolt.DB
+	bucket []byte
+}
+
+// NewBoltBackend returns a Backend storing records in bucket of db.
+// Closing the backend closes db.
+func NewBoltBackend(db *bolt.DB, bucket []byte) (Backend, error) {
+	err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &boltBackend{db: db, bucket: bucket}, nil
+}
+
+func (b *boltBackend) Get(ctx context.Context, id string) (*Record, error) {
+	var rec *Record
+	err := b.db.View(func(tx *bolt.Tx) error {
+		sb := tx.Bucket(b.bucket).Bucket([]byte(id))
+		if sb == nil {
+			return nil
+		}
+		data := sb.Get(keyValues)
+		if data == nil {
+			return nil
+		}
+		expiresAt, _ := decodeExpiry(sb.Get(keyExpiredAt))
+		rec = &Record{
+			Data:      append([]byte{}, data...),
+			ExpiresAt: expiresAt,
+			Version:   decodeUint(sb.Get(keyVersion)),
+		}
+		return nil
+	})
+	return rec, err
+}
+
+func (b *boltBackend) Put(ctx context.Context, id string, rec *Record) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		sb, err := tx.Bucket(b.bucket).CreateBucketIfNotExists([]byte(id))
+		if err != nil {
+			return err
+		}
+		if v := sb.Get(keyVersion); v != nil && decodeUint(v) >= rec.Version {
+			return ErrConflict
+		}
+		if err := sb.Put(keyVersion, encodeUint(rec.Version)); err != nil {
+			return err
+		}
+		if err := sb.Put(keyValues, rec.Data); err != nil {
+			return err
+		}
+		return sb.Put(keyExpiredAt, encodeExpiry(rec.ExpiresAt))
+	})
+}
+
+func (b *boltBackend) Delete(ctx context.Context, id string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		err := tx.Bucket(b.bucket).DeleteBucket([]byte(id))
+		if err == bolt.ErrBucketNotFound {
+			return nil
+		}
+		return err
+	})
+}
+
+func (b *boltBackend) ScanExpired(ctx context.Context, now time.Time, fn func(id string) error) error {
+	var ids []string
+	err := b.db.View(func(tx *bolt.Tx) error {
+		root := tx.Bucket(b.bucket)
+		return root.ForEach(func(k, v []byte) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if v == nil && isExpired(root.Bucket(k).Get(keyExpiredAt), now) {
+				ids = append(ids, string(k))
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+	// fn is called out of the read transaction, so it may write
+	for _, id := range ids {
+		if err := fn(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *boltBackend) Close() error {
+	return b.db.Close()
+}
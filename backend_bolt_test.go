@@ -0,0 +1,25 @@
+package boltstore_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/maxim0r/boltstore"
+	"github.com/maxim0r/boltstore/backendtest"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestBoltBackend(t *testing.T) {
+	backendtest.Run(t, func(t *testing.T) boltstore.Backend {
+		db, err := bolt.Open(filepath.Join(t.TempDir(), "test.db"), 0600, &bolt.Options{Timeout: time.Second})
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := boltstore.NewBoltBackend(db, []byte("sessions"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	})
+}
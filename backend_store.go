@@ -0,0 +1,269 @@
+package boltstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+)
+
+// BackendStore stores sessions in a Backend, sharing cookie handling,
+// serialization and expiry logic with BoltStore. Bolt specific features
+// (locking, caching, name buckets, soft delete etc.) are BoltStore only,
+// NewBackendStore fails if their options are set.
+type BackendStore struct {
+	backend Backend
+	Codecs  []securecookie.Codec
+	Options *sessions.Options // default session configuration
+	options Options           // store options
+	cancel  context.CancelFunc
+	bg      sync.WaitGroup // reaper running until Close
+}
+
+// NewBackendStore returns a new BackendStore. Records are reaped using the
+// backend until Close or until ctx is done, unless Options.NoReaper is set.
+func NewBackendStore(ctx context.Context, backend Backend, opts Options) (*BackendStore, error) {
+	if names := unsupportedOptions(opts); len(names) > 0 {
+		return nil, fmt.Errorf("options not supported by BackendStore: %s", strings.Join(names, ", "))
+	}
+	opts = setOptions(opts)
+
+	if opts.KeyPairs == nil && opts.CookieMode != CookieRaw && opts.CookieCodec == nil {
+		return nil, errors.New("store secret key is absent")
+	}
+
+	bs := &BackendStore{
+		backend: backend,
+		Codecs:  newCodecs(opts),
+		Options: &sessions.Options{
+			Path:   "/",
+			MaxAge: int(opts.SessionExpire / time.Second),
+		},
+		options: opts,
+	}
+
+	ctx, bs.cancel = context.WithCancel(ctx)
+	if !opts.NoReaper {
+		bs.bg.Add(1)
+		go bs.worker(ctx)
+	}
+
+	return bs, nil
+}
+
+// unsupportedOptions returns the names of options set in o which only
+// BoltStore implements.
+func unsupportedOptions(o Options) []string {
+	options := []struct {
+		name string
+		set  bool
+	}{
+		{"BucketName", o.BucketName != nil},
+		{"LockSessions", o.LockSessions},
+		{"ShareLoads", o.ShareLoads},
+		{"ShareLoadsTTL", o.ShareLoadsTTL != 0},
+		{"CacheSize", o.CacheSize != 0},
+		{"CacheBytes", o.CacheBytes != 0},
+		{"CacheTTL", o.CacheTTL != 0},
+		{"ExpiredGrace", o.ExpiredGrace != 0},
+		{"SoftDelete", o.SoftDelete != 0},
+		{"AccessResolution", o.AccessResolution != 0},
+		{"CountAccesses", o.CountAccesses},
+		{"IdleTimeout", o.IdleTimeout != 0},
+		{"ServerTiming", o.ServerTiming},
+		{"RedactIDs", o.RedactIDs},
+		{"Durability", o.Durability != ProfileDurable},
+		{"NoSync", o.NoSync},
+		{"SyncInterval", o.SyncInterval != 0},
+		{"MaxBatchSize", o.MaxBatchSize != 0},
+		{"MaxBatchDelay", o.MaxBatchDelay != 0},
+		{"ExpectedSessions", o.ExpectedSessions != 0},
+		{"AverageSize", o.AverageSize != 0},
+		{"WarmUp", o.WarmUp},
+		{"SkipCorrupt", o.SkipCorrupt},
+		{"SaveRetries", o.SaveRetries != 0},
+		{"SaveRetryBackoff", o.SaveRetryBackoff != 0},
+		{"MaxWriters", o.MaxWriters != 0},
+		{"UserKey", o.UserKey != ""},
+		{"MaxDecodeFailures", o.MaxDecodeFailures != 0},
+		{"BlockDuration", o.BlockDuration != 0},
+		{"MaxNewPerIP", o.MaxNewPerIP != 0},
+		{"MaxUserSessions", o.MaxUserSessions != 0},
+		{"UserLimit", o.UserLimit != 0},
+		{"NoMigrate", o.NoMigrate},
+		{"FillPercent", o.FillPercent != 0},
+		{"ProvisionalExpire", o.ProvisionalExpire != 0},
+		{"MaxLifetime", o.MaxLifetime != 0},
+		{"RenewBelow", o.RenewBelow != 0},
+		{"TraceIDFunc", o.TraceIDFunc != nil},
+		{"OnNew", o.OnNew != nil},
+		{"Upgrades", o.Upgrades != nil},
+		{"ClientIP", o.ClientIP != nil},
+		{"Fingerprint", o.Fingerprint != nil},
+		{"Names", o.Names != nil},
+		{"MaxBlobSize", o.MaxBlobSize != 0},
+		{"CookieStoreKeyPairs", o.CookieStoreKeyPairs != nil},
+	}
+	var names []string
+	for _, opt := range options {
+		if opt.set {
+			names = append(names, opt.name)
+		}
+	}
+	return names
+}
+
+// Close stops the reaper, waiting for a reap in progress, and closes the
+// backend.
+func (s *BackendStore) Close() error {
+	s.cancel()
+	s.bg.Wait()
+	return s.backend.Close()
+}
+
+// Backend returns the store backend.
+func (s *BackendStore) Backend() Backend {
+	return s.backend
+}
+
+// CookieCodec returns the codec of session ID cookies: Options.CookieCodec
+// or the Codecs of the store.
+func (s *BackendStore) CookieCodec() CookieCodec {
+	if s.options.CookieCodec != nil {
+		return s.options.CookieCodec
+	}
+	return codecList(s.Codecs)
+}
+
+// Get returns a session for the given name after adding it to the registry.
+func (s *BackendStore) Get(r *http.Request, name string) (*sessions.Session, error) {
+	return sessions.GetRegistry(r).Get(s, name)
+}
+
+// New returns a session for the given name without adding it to the registry.
+func (s *BackendStore) New(r *http.Request, name string) (*sessions.Session, error) {
+	var (
+		err error
+		ok  bool
+	)
+	session := sessions.NewSession(s, name)
+	// make a copy
+	options := *s.Options
+	session.Options = &options
+	session.IsNew = true
+	cname := cookieName(s.options, name)
+	if c, errCookie := r.Cookie(cname); errCookie == nil {
+		var id string
+		id, err = s.CookieCodec().Decode(cname, c.Value)
+		if err == nil {
+			session.ID = id
+			ok, err = s.load(r.Context(), session)
+			session.IsNew = !(err == nil && ok) // not new if no error and data available
+			if err == nil && !ok {
+				// stale cookie, a new ID is issued on save
+				session.ID = ""
+			}
+		}
+	}
+	return session, err
+}
+
+// Save adds a single session to the response.
+func (s *BackendStore) Save(r *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	ctx := context.Background()
+	if r != nil {
+		ctx = r.Context()
+	}
+	// Marked for deletion.
+	if session.Options.MaxAge <= 0 {
+		if err := s.backend.Delete(ctx, session.ID); err != nil {
+			return fmt.Errorf("delete session from store error: %w", err)
+		}
+		clearCookie(w, s.options, session)
+		return nil
+	}
+	// Build an alphanumeric key for the store.
+	if session.ID == "" {
+		session.ID = newSessionID()
+	}
+	if err := s.save(ctx, session); err != nil {
+		return fmt.Errorf("save session to store error: %w", err)
+	}
+	return writeCookie(w, s.options, s.CookieCodec(), session)
+}
+
+// load reads the session from backend.
+// returns true if there is an active session record.
+func (s *BackendStore) load(ctx context.Context, session *sessions.Session) (bool, error) {
+	rec, err := s.backend.Get(ctx, session.ID)
+	if err != nil || rec == nil || rec.ExpiresAt.Before(skewedNow(s.options)) {
+		return false, err
+	}
+	if err := s.options.Serializer.Deserialize(rec.Data, session); err != nil {
+		return false, err
+	}
+	expireKeys(session, time.Now())
+	setMeta(session, metaVersion, rec.Version)
+	return true, nil
+}
+
+// save stores the session in backend.
+func (s *BackendStore) save(ctx context.Context, session *sessions.Session) error {
+	expireKeys(session, time.Now())
+	b, err := encodeSession(s.options.Serializer, session, s.options.MaxLength)
+	if err != nil {
+		return err
+	}
+	rec := &Record{
+		Data:      b,
+		ExpiresAt: time.Now().Add(s.options.SessionExpire),
+		Version:   Version(session) + 1,
+	}
+	for {
+		err := s.backend.Put(ctx, session.ID, rec)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, ErrConflict) || s.options.OptimisticLocking {
+			return err
+		}
+		// the last save wins without optimistic locking, as with BoltStore
+		stored, err := s.backend.Get(ctx, session.ID)
+		if err != nil {
+			return err
+		}
+		rec.Version = 1
+		if stored != nil {
+			rec.Version = stored.Version + 1
+		}
+	}
+	setMeta(session, metaVersion, rec.Version)
+	return nil
+}
+
+func (s *BackendStore) worker(ctx context.Context) {
+	defer s.bg.Done()
+	ticker := time.NewTicker(s.options.ReapCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := s.backend.ScanExpired(ctx, skewedNow(s.options), func(id string) error {
+				return s.backend.Delete(ctx, id)
+			})
+			if err != nil && ctx.Err() == nil {
+				log.Printf("boltstore: remove expired sessions error: %v", err)
+			}
+		}
+	}
+}
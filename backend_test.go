@@ -0,0 +1,128 @@
+package boltstore
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/sessions"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestBackendStore(t *testing.T) {
+	ctx := context.Background()
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "test.db"), 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend, err := NewBoltBackend(db, []byte("sessions"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := NewBackendStore(ctx, backend, Options{KeyPairs: [][]byte{[]byte("secret-key")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	rsp := NewRecorder()
+	session, err := store.Get(req, "session-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	session.Values["n"] = 1
+	if err := session.Save(req, rsp); err != nil {
+		t.Fatalf("Error saving session: %v", err)
+	}
+
+	req, _ = http.NewRequest("GET", "http://localhost:8080/", nil)
+	req.Header.Add("Cookie", rsp.Header()["Set-Cookie"][0])
+	session, err = store.New(req, "session-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.IsNew || session.Values["n"] != 1 || Version(session) != 1 {
+		t.Errorf("Expected stored session; Got %v %v", session.IsNew, session.Values)
+	}
+
+	// saves without a request, like BoltStore
+	if err := store.Save(nil, NewRecorder(), session); err != nil || Version(session) != 2 {
+		t.Errorf("Expected save without request; Got %v", err)
+	}
+
+	var expired []string
+	backend.ScanExpired(ctx, time.Now().Add(48*time.Hour), func(id string) error {
+		expired = append(expired, id)
+		return nil
+	})
+	if len(expired) != 1 || expired[0] != session.ID {
+		t.Errorf("Expected %q to expire; Got %v", session.ID, expired)
+	}
+}
+
+func TestBackendStoreOptions(t *testing.T) {
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "test.db"), 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend, err := NewBoltBackend(db, []byte("sessions"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer backend.Close()
+
+	_, err = NewBackendStore(context.Background(), backend, Options{
+		KeyPairs:     [][]byte{[]byte("secret-key")},
+		LockSessions: true,
+		SoftDelete:   time.Hour,
+	})
+	if err == nil || !strings.Contains(err.Error(), "LockSessions, SoftDelete") {
+		t.Errorf("Expected unsupported options error; Got %v", err)
+	}
+}
+
+func TestBackendStoreConflict(t *testing.T) {
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "test.db"), 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend, err := NewBoltBackend(db, []byte("sessions"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, locking := range []bool{false, true} {
+		store, err := NewBackendStore(context.Background(), backend, Options{
+			KeyPairs:          [][]byte{[]byte("secret-key")},
+			OptimisticLocking: locking,
+			NoReaper:          true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		session, _ := store.New(&http.Request{}, "session-key")
+		if err := store.Save(nil, NewRecorder(), session); err != nil {
+			t.Fatal(err)
+		}
+		// two requests load version 1 and save concurrently
+		other := sessions.NewSession(store, "session-key")
+		other.ID = session.ID
+		other.Options = session.Options
+		setMeta(other, metaVersion, Version(session))
+		if err := store.Save(nil, NewRecorder(), other); err != nil {
+			t.Fatal(err)
+		}
+		err = store.Save(nil, NewRecorder(), session)
+		switch {
+		case locking && !errors.Is(err, ErrConflict):
+			t.Errorf("Expected ErrConflict with optimistic locking; Got %v", err)
+		case !locking && (err != nil || Version(session) != 3):
+			t.Errorf("Expected the last save to win as version 3; Got %v, version %d", err, Version(session))
+		}
+	}
+	backend.Close()
+}
@@ -0,0 +1,224 @@
+// Package backendtest provides a conformance suite for boltstore.Backend
+// implementations.
+//
+// A third party backend is verified with:
+//
+//	func TestBackend(t *testing.T) {
+//		backendtest.Run(t, func(t *testing.T) boltstore.Backend {
+//			return newMyBackend(t)
+//		})
+//	}
+package backendtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/maxim0r/boltstore"
+)
+
+// Run runs the conformance suite, calling newBackend for a fresh empty
+// backend in every subtest. Backends are closed by the suite.
+func Run(t *testing.T, newBackend func(t *testing.T) boltstore.Backend) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, b boltstore.Backend)
+	}{
+		{"GetAbsent", testGetAbsent},
+		{"PutGet", testPutGet},
+		{"Overwrite", testOverwrite},
+		{"Conflict", testConflict},
+		{"Delete", testDelete},
+		{"ScanExpired", testScanExpired},
+		{"ScanExpiredDelete", testScanExpiredDelete},
+		{"Concurrent", testConcurrent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBackend(t)
+			defer b.Close()
+			tt.fn(t, b)
+		})
+	}
+}
+
+// expiry returns an expiration time d from now, with second precision
+// which all backends must preserve.
+func expiry(d time.Duration) time.Time {
+	return time.Now().Add(d).Truncate(time.Second)
+}
+
+func put(t *testing.T, b boltstore.Backend, id string, rec *boltstore.Record) {
+	t.Helper()
+	if err := b.Put(context.Background(), id, rec); err != nil {
+		t.Fatalf("Put(%q) error: %v", id, err)
+	}
+}
+
+func get(t *testing.T, b boltstore.Backend, id string) *boltstore.Record {
+	t.Helper()
+	rec, err := b.Get(context.Background(), id)
+	if err != nil {
+		t.Fatalf("Get(%q) error: %v", id, err)
+	}
+	return rec
+}
+
+func equal(a, b *boltstore.Record) bool {
+	return string(a.Data) == string(b.Data) && a.Version == b.Version && a.ExpiresAt.Equal(b.ExpiresAt)
+}
+
+func testGetAbsent(t *testing.T, b boltstore.Backend) {
+	if rec := get(t, b, "absent"); rec != nil {
+		t.Errorf("Expected nil record; Got %+v", rec)
+	}
+}
+
+func testPutGet(t *testing.T, b boltstore.Backend) {
+	want := &boltstore.Record{Data: []byte("data"), ExpiresAt: expiry(time.Hour), Version: 1}
+	put(t, b, "a", want)
+	if got := get(t, b, "a"); got == nil || !equal(got, want) {
+		t.Errorf("Expected %+v; Got %+v", want, got)
+	}
+
+	// the stored record must not alias the caller's data
+	want.Data[0] = 'x'
+	if got := get(t, b, "a"); got == nil || string(got.Data) != "data" {
+		t.Errorf("Expected stored data to be copied; Got %+v", got)
+	}
+}
+
+func testOverwrite(t *testing.T, b boltstore.Backend) {
+	put(t, b, "a", &boltstore.Record{Data: []byte("old"), ExpiresAt: expiry(time.Hour), Version: 1})
+	want := &boltstore.Record{Data: []byte("new"), ExpiresAt: expiry(2 * time.Hour), Version: 2}
+	put(t, b, "a", want)
+	if got := get(t, b, "a"); got == nil || !equal(got, want) {
+		t.Errorf("Expected %+v; Got %+v", want, got)
+	}
+}
+
+func testConflict(t *testing.T, b boltstore.Backend) {
+	want := &boltstore.Record{Data: []byte("first"), ExpiresAt: expiry(time.Hour), Version: 2}
+	put(t, b, "a", want)
+	for _, v := range []uint64{1, 2} {
+		err := b.Put(context.Background(), "a", &boltstore.Record{Data: []byte("stale"), ExpiresAt: expiry(time.Hour), Version: v})
+		if !errors.Is(err, boltstore.ErrConflict) {
+			t.Errorf("Expected ErrConflict putting version %d over 2; Got %v", v, err)
+		}
+	}
+	if got := get(t, b, "a"); got == nil || !equal(got, want) {
+		t.Errorf("Expected %+v to be kept; Got %+v", want, got)
+	}
+}
+
+func testDelete(t *testing.T, b boltstore.Backend) {
+	ctx := context.Background()
+	put(t, b, "a", &boltstore.Record{Data: []byte("data"), ExpiresAt: expiry(time.Hour), Version: 1})
+	if err := b.Delete(ctx, "a"); err != nil {
+		t.Fatalf("Delete error: %v", err)
+	}
+	if rec := get(t, b, "a"); rec != nil {
+		t.Errorf("Expected deleted record; Got %+v", rec)
+	}
+	if err := b.Delete(ctx, "a"); err != nil {
+		t.Errorf("Expected deleting absent record to succeed; Got %v", err)
+	}
+}
+
+func scan(t *testing.T, b boltstore.Backend, now time.Time) []string {
+	t.Helper()
+	var ids []string
+	err := b.ScanExpired(context.Background(), now, func(id string) error {
+		ids = append(ids, id)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ScanExpired error: %v", err)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func testScanExpired(t *testing.T, b boltstore.Backend) {
+	now := expiry(0)
+	put(t, b, "expired", &boltstore.Record{Data: []byte("x"), ExpiresAt: now.Add(-time.Minute), Version: 1})
+	put(t, b, "active", &boltstore.Record{Data: []byte("x"), ExpiresAt: now.Add(time.Hour), Version: 1})
+	if ids := scan(t, b, now); len(ids) != 1 || ids[0] != "expired" {
+		t.Errorf("Expected [expired]; Got %v", ids)
+	}
+	if ids := scan(t, b, now.Add(2*time.Hour)); len(ids) != 2 {
+		t.Errorf("Expected both records to expire later; Got %v", ids)
+	}
+}
+
+func testScanExpiredDelete(t *testing.T, b boltstore.Backend) {
+	ctx := context.Background()
+	now := expiry(0)
+	for i := 0; i < 10; i++ {
+		put(t, b, fmt.Sprint(i), &boltstore.Record{Data: []byte("x"), ExpiresAt: now.Add(-time.Minute), Version: 1})
+	}
+	// the reaper deletes records from within fn
+	err := b.ScanExpired(ctx, now, func(id string) error {
+		return b.Delete(ctx, id)
+	})
+	if err != nil {
+		t.Fatalf("ScanExpired error: %v", err)
+	}
+	if ids := scan(t, b, now); len(ids) != 0 {
+		t.Errorf("Expected all records to be deleted; Got %v", ids)
+	}
+}
+
+func testConcurrent(t *testing.T, b boltstore.Backend) {
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			id := fmt.Sprint(i)
+			for v := uint64(1); v <= 10; v++ {
+				if err := b.Put(ctx, id, &boltstore.Record{Data: []byte(id), ExpiresAt: expiry(time.Hour), Version: v}); err != nil {
+					t.Errorf("Put error: %v", err)
+					return
+				}
+				if _, err := b.Get(ctx, id); err != nil {
+					t.Errorf("Get error: %v", err)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	for i := 0; i < 8; i++ {
+		if rec := get(t, b, fmt.Sprint(i)); rec == nil || rec.Version != 10 {
+			t.Errorf("Expected version 10; Got %+v", rec)
+		}
+	}
+
+	// writers of the same version: exactly one wins
+	var won int32
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := b.Put(ctx, "shared", &boltstore.Record{Data: []byte(fmt.Sprint(i)), ExpiresAt: expiry(time.Hour), Version: 1})
+			switch {
+			case err == nil:
+				atomic.AddInt32(&won, 1)
+			case !errors.Is(err, boltstore.ErrConflict):
+				t.Errorf("Put error: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if won != 1 {
+		t.Errorf("Expected a single concurrent Put of version 1 to succeed; Got %d", won)
+	}
+}
@@ -0,0 +1,154 @@
+// Package badgerbackend provides a BadgerDB boltstore.Backend, for
+// write-heavy deployments using boltstore.BackendStore.
+package badgerbackend
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/maxim0r/boltstore"
+)
+
+// headerLen is the length of the record header: version and expiration time.
+const headerLen = 16
+
+// Backend keeps session records as prefixed badger keys. Records are also
+// written with a badger TTL, so expired records are dropped by compaction
+// even if they are never reaped.
+type Backend struct {
+	db     *badger.DB
+	prefix []byte
+}
+
+var _ boltstore.Backend = (*Backend)(nil)
+
+// New returns a Backend storing records of db under keys prefixed with prefix.
+// Closing the backend closes db.
+func New(db *badger.DB, prefix string) *Backend {
+	return &Backend{db: db, prefix: []byte(prefix)}
+}
+
+func (b *Backend) key(id string) []byte {
+	return append(append([]byte{}, b.prefix...), id...)
+}
+
+func (b *Backend) Get(ctx context.Context, id string) (*boltstore.Record, error) {
+	var rec *boltstore.Record
+	err := b.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(b.key(id))
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		v, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+		rec, err = decode(v)
+		return err
+	})
+	return rec, err
+}
+
+func (b *Backend) Put(ctx context.Context, id string, rec *boltstore.Record) error {
+	e := badger.NewEntry(b.key(id), encode(rec))
+	if ttl := time.Until(rec.ExpiresAt); ttl > 0 {
+		e = e.WithTTL(ttl)
+	}
+	err := b.db.Update(func(txn *badger.Txn) error {
+		item, err := txn.Get(b.key(id))
+		if err == nil {
+			err = item.Value(func(v []byte) error {
+				if len(v) >= headerLen && binary.BigEndian.Uint64(v) >= rec.Version {
+					return boltstore.ErrConflict
+				}
+				return nil
+			})
+		}
+		if err != nil && !errors.Is(err, badger.ErrKeyNotFound) {
+			return err
+		}
+		return txn.SetEntry(e)
+	})
+	if errors.Is(err, badger.ErrConflict) {
+		// a concurrent transaction wrote the record after it was read
+		return boltstore.ErrConflict
+	}
+	return err
+}
+
+func (b *Backend) Delete(ctx context.Context, id string) error {
+	return b.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete(b.key(id))
+	})
+}
+
+func (b *Backend) ScanExpired(ctx context.Context, now time.Time, fn func(id string) error) error {
+	var ids []string
+	err := b.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = b.prefix
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			item := it.Item()
+			var expiresAt time.Time
+			err := item.Value(func(v []byte) error {
+				rec, err := decode(v)
+				if err != nil {
+					return err
+				}
+				expiresAt = rec.ExpiresAt
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if expiresAt.Before(now) {
+				ids = append(ids, string(item.Key()[len(b.prefix):]))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := fn(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *Backend) Close() error {
+	return b.db.Close()
+}
+
+// encode formats the record as header followed by data.
+func encode(rec *boltstore.Record) []byte {
+	v := make([]byte, headerLen+len(rec.Data))
+	binary.BigEndian.PutUint64(v, rec.Version)
+	binary.BigEndian.PutUint64(v[8:], uint64(rec.ExpiresAt.Unix()))
+	copy(v[headerLen:], rec.Data)
+	return v
+}
+
+func decode(v []byte) (*boltstore.Record, error) {
+	if len(v) < headerLen {
+		return nil, errors.New("badgerbackend: malformed record")
+	}
+	return &boltstore.Record{
+		Version:   binary.BigEndian.Uint64(v),
+		ExpiresAt: time.Unix(int64(binary.BigEndian.Uint64(v[8:])), 0),
+		Data:      append([]byte{}, v[headerLen:]...),
+	}, nil
+}
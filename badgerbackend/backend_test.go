@@ -0,0 +1,19 @@
+package badgerbackend
+
+import (
+	"testing"
+
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/maxim0r/boltstore"
+	"github.com/maxim0r/boltstore/backendtest"
+)
+
+func TestBackend(t *testing.T) {
+	backendtest.Run(t, func(t *testing.T) boltstore.Backend {
+		db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLogger(nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return New(db, "session_")
+	})
+}
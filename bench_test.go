@@ -0,0 +1,71 @@
+package boltstore
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// benchmarkLoadSave measures a request loading a stored session and saving
+// it back, with optional changes of its values.
+func benchmarkLoadSave(b *testing.B, opts Options, change bool) {
+	store := newTestStore(b, opts)
+	cookie := saveNew(b, store, "session-key", map[interface{}]interface{}{
+		"user":  "foo@example.com",
+		"roles": "reader,writer",
+		"csrf":  "6b8f2a0e9c1d4e7f",
+	})
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		req, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+		req.Header.Add("Cookie", cookie)
+		session, err := store.New(req, "session-key")
+		if err != nil || session.IsNew {
+			b.Fatalf("Expected stored session; Got %v", err)
+		}
+		if change {
+			session.Values["n"] = i
+		}
+		if err := store.Save(req, NewRecorder(), session); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLoad(b *testing.B) {
+	store := newTestStore(b, Options{})
+	cookie := saveNew(b, store, "session-key", map[interface{}]interface{}{"user": "foo@example.com"})
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		req, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+		req.Header.Add("Cookie", cookie)
+		if session, err := store.New(req, "session-key"); err != nil || session.IsNew {
+			b.Fatalf("Expected stored session; Got %v", err)
+		}
+	}
+}
+
+func BenchmarkLoadSave(b *testing.B)         { benchmarkLoadSave(b, Options{}, true) }
+func BenchmarkLoadSaveCached(b *testing.B)   { benchmarkLoadSave(b, Options{CacheSize: 100}, true) }
+func BenchmarkLoadSaveSameData(b *testing.B) { benchmarkLoadSave(b, Options{}, false) }
+
+// BenchmarkFillPercent measures write amplification of new sessions saves
+// with random IDs, as page bytes written and pages split per save.
+func BenchmarkFillPercent(b *testing.B) {
+	for _, fill := range []float64{0, 0.25, 0.9} {
+		b.Run(fmt.Sprint(fill), func(b *testing.B) {
+			store := newTestStore(b, Options{FillPercent: fill})
+			before := store.DB().Stats().TxStats
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				saveNew(b, store, "session-key", map[interface{}]interface{}{"n": i})
+			}
+			b.StopTimer()
+			stats := store.DB().Stats().TxStats
+			b.ReportMetric(float64(stats.PageAlloc-before.PageAlloc)/float64(b.N), "page-B/op")
+			b.ReportMetric(float64(stats.Split-before.Split)/float64(b.N), "splits/op")
+		})
+	}
+}
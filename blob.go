@@ -0,0 +1,206 @@
+package boltstore
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// blobChunkSize is the size of chunks blobs are stored in, so large payloads
+// don't need a single contiguous value.
+const blobChunkSize = 64 << 10
+
+// errBlobClosed is returned by reads of a blob reader after Close.
+var errBlobClosed = errors.New("read of closed blob")
+
+// errBlobChanged is returned by reads of a blob reader when the blob was
+// replaced or removed since it was opened.
+var errBlobChanged = errors.New("blob changed while read")
+
+// AttachBlob stores the content of r as blob name of the active session with
+// given id, replacing the existing blob of that name, for large payloads such
+// as uploaded drafts or generated reports. Blobs are kept out of the
+// serialized values and MaxLength, in chunks nested under the session bucket,
+// so they are removed with the session when it is deleted or reaped.
+// r is read before the write transaction starts, so a slow reader doesn't
+// block other writes, up to Options.MaxBlobSize bytes: longer content fails
+// with ErrBlobTooLarge. Returns ErrNotFound if there is no active session.
+func (s *BoltStore) AttachBlob(sessionID, name string, r io.Reader) error {
+	if err := s.enter(); err != nil {
+		return err
+	}
+	defer s.leave()
+	if s.options.MaxBlobSize > 0 {
+		// one byte over the limit tells an exact fit from a longer blob
+		r = io.LimitReader(r, int64(s.options.MaxBlobSize)+1)
+	}
+	var (
+		chunks [][]byte
+		size   int
+	)
+	for {
+		chunk := make([]byte, blobChunkSize)
+		n, err := io.ReadFull(r, chunk)
+		if n > 0 {
+			chunks = append(chunks, chunk[:n])
+			size += n
+		}
+		if s.options.MaxBlobSize > 0 && size > s.options.MaxBlobSize {
+			return ErrBlobTooLarge
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("read blob error: %w", err)
+		}
+	}
+	return s.write(func(tx *bolt.Tx) error {
+		b := s.activeBucket(tx, []byte(sessionID), time.Now())
+		if b == nil {
+			return ErrNotFound
+		}
+		blobs, err := b.CreateBucketIfNotExists(keyBlobs)
+		if err != nil {
+			return fmt.Errorf("create blobs bucket error: %w", err)
+		}
+		if blobs.Bucket([]byte(name)) != nil {
+			if err := blobs.DeleteBucket([]byte(name)); err != nil {
+				return fmt.Errorf("delete blob error: %w", err)
+			}
+		}
+		blob, err := blobs.CreateBucket([]byte(name))
+		if err != nil {
+			return fmt.Errorf("create blob bucket error: %w", err)
+		}
+		// the generation tells open readers the blob was replaced
+		gen, err := blobs.NextSequence()
+		if err != nil {
+			return err
+		}
+		if err := blob.SetSequence(gen); err != nil {
+			return err
+		}
+		// chunks are appended in key order
+		blob.FillPercent = 1
+		for i, chunk := range chunks {
+			if err := blob.Put(blobChunkKey(i), chunk); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// OpenBlob returns a reader of blob name of the active session with given id,
+// see AttachBlob. Every chunk is read in a short transaction of its own, so
+// an open reader doesn't hold the db; reads fail if the blob is replaced or
+// removed in the meantime. Returns ErrNotFound if there is no active session
+// or no such blob.
+func (s *BoltStore) OpenBlob(sessionID, name string) (io.ReadCloser, error) {
+	r := &blobReader{s: s, id: []byte(sessionID), name: []byte(name)}
+	err := r.view(func(blob *bolt.Bucket) error {
+		if blob == nil {
+			return ErrNotFound
+		}
+		r.gen = blob.Sequence()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// DeleteBlob removes blob name of the session with given id, removing an
+// absent blob is not an error.
+func (s *BoltStore) DeleteBlob(sessionID, name string) error {
+	if err := s.enter(); err != nil {
+		return err
+	}
+	defer s.leave()
+	return s.write(func(tx *bolt.Tx) error {
+		b, _ := s.findTx(tx, []byte(sessionID), s.buckets[0])
+		if b == nil {
+			return nil
+		}
+		blobs := b.Bucket(keyBlobs)
+		if blobs == nil || blobs.Bucket([]byte(name)) == nil {
+			return nil
+		}
+		return blobs.DeleteBucket([]byte(name))
+	})
+}
+
+// blobChunkKey returns the key of chunk i of a blob.
+func blobChunkKey(i int) []byte {
+	var k [8]byte
+	binary.BigEndian.PutUint64(k[:], uint64(i))
+	return k[:]
+}
+
+// blobReader reads a blob chunk by chunk.
+type blobReader struct {
+	s      *BoltStore
+	id     []byte
+	name   []byte
+	gen    uint64 // blob generation at open
+	next   int    // index of the next chunk to read
+	chunk  []byte // unread part of the current chunk
+	eof    bool
+	closed bool
+}
+
+// view calls fn with the blob bucket within a read transaction, nil if the
+// session is not active or has no such blob.
+func (r *blobReader) view(fn func(blob *bolt.Bucket) error) error {
+	if err := r.s.enter(); err != nil {
+		return err
+	}
+	defer r.s.leave()
+	return r.s.db.View(func(tx *bolt.Tx) error {
+		var blob *bolt.Bucket
+		if b := r.s.activeBucket(tx, r.id, time.Now()); b != nil {
+			if blobs := b.Bucket(keyBlobs); blobs != nil {
+				blob = blobs.Bucket(r.name)
+			}
+		}
+		return fn(blob)
+	})
+}
+
+func (r *blobReader) Read(p []byte) (int, error) {
+	if r.closed {
+		return 0, errBlobClosed
+	}
+	for len(r.chunk) == 0 {
+		if r.eof {
+			return 0, io.EOF
+		}
+		err := r.view(func(blob *bolt.Bucket) error {
+			if blob == nil || blob.Sequence() != r.gen {
+				return errBlobChanged
+			}
+			chunk := blob.Get(blobChunkKey(r.next))
+			r.chunk = append(r.chunk[:0], chunk...)
+			r.eof = chunk == nil
+			return nil
+		})
+		if err != nil {
+			return 0, err
+		}
+		r.next++
+	}
+	n := copy(p, r.chunk)
+	r.chunk = r.chunk[n:]
+	return n, nil
+}
+
+func (r *blobReader) Close() error {
+	r.closed = true
+	return nil
+}
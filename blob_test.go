@@ -0,0 +1,90 @@
+package boltstore
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+)
+
+func TestBlob(t *testing.T) {
+	store := newTestStore(t, Options{MaxLength: 1024})
+	cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 1})
+	_, session := loadCookie(t, store, "session-key", cookie)
+
+	data := bytes.Repeat([]byte("0123456789"), blobChunkSize/4)
+	if err := store.AttachBlob(session.ID, "report", bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+	r, err := store.OpenBlob(session.ID, "report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(r)
+	r.Close()
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("Expected blob of %d bytes; Got %d, %v", len(data), len(got), err)
+	}
+
+	if err := store.AttachBlob(session.ID, "report", bytes.NewReader([]byte("short"))); err != nil {
+		t.Fatal(err)
+	}
+	r, _ = store.OpenBlob(session.ID, "report")
+	got, _ = io.ReadAll(r)
+	r.Close()
+	if string(got) != "short" {
+		t.Errorf("Expected replaced blob; Got %q", got)
+	}
+
+	if err := store.DeleteBlob(session.ID, "report"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.OpenBlob(session.ID, "report"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound for deleted blob; Got %v", err)
+	}
+	if err := store.AttachBlob("missing", "report", bytes.NewReader(data)); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound; Got %v", err)
+	}
+
+	store.AttachBlob(session.ID, "draft", bytes.NewReader(data))
+	if err := store.Delete(context.Background(), session.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.OpenBlob(session.ID, "draft"); err != ErrNotFound {
+		t.Errorf("Expected blob to be removed with the session; Got %v", err)
+	}
+}
+
+func TestBlobReader(t *testing.T) {
+	store := newTestStore(t, Options{MaxBlobSize: 2 * blobChunkSize})
+	cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 1})
+	_, session := loadCookie(t, store, "session-key", cookie)
+
+	data := bytes.Repeat([]byte{1}, 2*blobChunkSize)
+	if err := store.AttachBlob(session.ID, "report", bytes.NewReader(data)); err != nil {
+		t.Fatalf("Expected blob of MaxBlobSize to be stored; Got %v", err)
+	}
+	if err := store.AttachBlob(session.ID, "report", bytes.NewReader(append(data, 1))); err != ErrBlobTooLarge {
+		t.Errorf("Expected ErrBlobTooLarge; Got %v", err)
+	}
+
+	// an open reader doesn't hold the db, a replaced blob fails its reads
+	r, err := store.OpenBlob(session.ID, "report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if _, err := io.ReadFull(r, make([]byte, blobChunkSize)); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.AttachBlob(session.ID, "report", bytes.NewReader([]byte("new"))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Read(make([]byte, 1)); err != errBlobChanged {
+		t.Errorf("Expected errBlobChanged; Got %v", err)
+	}
+	r.Close()
+	if _, err := r.Read(make([]byte, 1)); err != errBlobClosed {
+		t.Errorf("Expected errBlobClosed; Got %v", err)
+	}
+}
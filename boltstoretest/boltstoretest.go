@@ -0,0 +1,142 @@
+// Package boltstoretest provides helpers for testing handlers using
+// boltstore sessions.
+//
+// A request cycle and assertions on the stored session:
+//
+//	func TestLogin(t *testing.T) {
+//		store := boltstoretest.NewStore(t, boltstore.Options{})
+//		cookie := boltstoretest.Serve(t, store, loginHandler, httptest.NewRequest("POST", "/login", body), "")
+//		boltstoretest.AssertSessionValue(t, store, cookie, "user", "alice")
+//	}
+package boltstoretest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/sessions"
+	"github.com/maxim0r/boltstore"
+)
+
+// NewStore returns a store on a temporary db file, closed with the test.
+// A fixed key pair is used unless opts.KeyPairs is set.
+func NewStore(t testing.TB, opts boltstore.Options) *boltstore.BoltStore {
+	t.Helper()
+	if opts.KeyPairs == nil {
+		opts.KeyPairs = [][]byte{[]byte("boltstoretest-key")}
+	}
+	store, err := boltstore.NewStore(context.Background(), filepath.Join(t.TempDir(), "sessions.db"), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// Serve serves req with h, sending cookie if not empty. returns the session
+// cookie set by the response, or cookie if none was set.
+func Serve(t testing.TB, store *boltstore.BoltStore, h http.Handler, req *http.Request, cookie string) string {
+	t.Helper()
+	if cookie != "" {
+		req.Header.Add("Cookie", cookie)
+	}
+	rsp := httptest.NewRecorder()
+	h.ServeHTTP(rsp, req)
+	if set := rsp.Header().Get("Set-Cookie"); set != "" {
+		return set
+	}
+	return cookie
+}
+
+// Update loads the session name of cookie (a new one if cookie is empty),
+// calls fn to change it and saves it, as a handler would in a request.
+// returns the session cookie.
+func Update(t testing.TB, store *boltstore.BoltStore, name, cookie string, fn func(*sessions.Session)) string {
+	t.Helper()
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session, err := store.New(r, name)
+		if err != nil {
+			t.Fatalf("Error getting session %q: %v", name, err)
+		}
+		fn(session)
+		if err := store.Save(r, w, session); err != nil {
+			t.Fatalf("Error saving session %q: %v", name, err)
+		}
+	})
+	return Serve(t, store, h, httptest.NewRequest("GET", "http://localhost/", nil), cookie)
+}
+
+// Session returns the stored session of cookie, read like BoltStore.Peek
+// without refreshing or locking it, or nil if it is not stored.
+func Session(t testing.TB, store *boltstore.BoltStore, cookie string) *sessions.Session {
+	t.Helper()
+	c := parseCookie(cookie)
+	if c == nil {
+		t.Fatalf("Malformed session cookie %q", cookie)
+	}
+	id, err := store.CookieCodec().Decode(c.Name, c.Value)
+	if err != nil {
+		t.Fatalf("Error decoding session cookie %q: %v", c.Name, err)
+	}
+	session, err := store.Peek(id)
+	if errors.Is(err, boltstore.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		t.Fatalf("Error loading session: %v", err)
+	}
+	return session
+}
+
+// AssertSessionValue fails the test unless the session of cookie is stored
+// and has value want under key.
+func AssertSessionValue(t testing.TB, store *boltstore.BoltStore, cookie string, key, want interface{}) {
+	t.Helper()
+	session := Session(t, store, cookie)
+	if session == nil {
+		t.Errorf("Expected stored session of cookie %q", cookieName(cookie))
+		return
+	}
+	if got, ok := session.Values[key]; !ok || !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected session value %v = %#v; Got %#v", key, want, got)
+	}
+}
+
+// AssertNoSession fails the test if a session of cookie is stored.
+func AssertNoSession(t testing.TB, store *boltstore.BoltStore, cookie string) {
+	t.Helper()
+	if session := Session(t, store, cookie); session != nil {
+		t.Errorf("Expected no stored session of cookie %q; Got %v", cookieName(cookie), session.Values)
+	}
+}
+
+// AssertCookieDeleted fails the test unless cookie deletes the session
+// cookie in the browser.
+func AssertCookieDeleted(t testing.TB, cookie string) {
+	t.Helper()
+	c := parseCookie(cookie)
+	if c == nil || c.MaxAge >= 0 {
+		t.Errorf("Expected deleting cookie; Got %q", cookie)
+	}
+}
+
+// cookieName returns the name of a Set-Cookie or Cookie header value.
+func cookieName(cookie string) string {
+	name, _, _ := strings.Cut(cookie, "=")
+	return strings.TrimSpace(name)
+}
+
+// parseCookie parses a Set-Cookie header value.
+func parseCookie(cookie string) *http.Cookie {
+	rsp := http.Response{Header: http.Header{"Set-Cookie": {cookie}}}
+	if cookies := rsp.Cookies(); len(cookies) > 0 {
+		return cookies[0]
+	}
+	return nil
+}
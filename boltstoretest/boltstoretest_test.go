@@ -0,0 +1,56 @@
+package boltstoretest_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/sessions"
+	"github.com/maxim0r/boltstore"
+	"github.com/maxim0r/boltstore/boltstoretest"
+)
+
+func TestHelpers(t *testing.T) {
+	store := boltstoretest.NewStore(t, boltstore.Options{})
+	cookie := boltstoretest.Update(t, store, "session", "", func(s *sessions.Session) {
+		s.Values["user"] = "alice"
+	})
+	boltstoretest.AssertSessionValue(t, store, cookie, "user", "alice")
+
+	logout := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session, _ := store.Get(r, "session")
+		session.Options.MaxAge = -1
+		store.Save(r, w, session)
+	})
+	deleted := boltstoretest.Serve(t, store, logout, httptest.NewRequest("POST", "/logout", nil), cookie)
+	boltstoretest.AssertCookieDeleted(t, deleted)
+	boltstoretest.AssertNoSession(t, store, cookie)
+}
+
+func TestFaultStore(t *testing.T) {
+	store := boltstoretest.NewStore(t, boltstore.Options{})
+	f := boltstoretest.NewFaultStore(store)
+	f.FailSave(2, nil)
+	f.CorruptLoad(3)
+
+	save := func() error {
+		req := httptest.NewRequest("GET", "/", nil)
+		session, _ := f.Get(req, "session")
+		return f.Save(req, httptest.NewRecorder(), session)
+	}
+	if err := save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := save(); err != boltstoretest.ErrInjected {
+		t.Errorf("Expected injected save failure; Got %v", err)
+	}
+	req := httptest.NewRequest("GET", "/", nil)
+	var corrupt *boltstore.CorruptRecordError
+	if session, err := f.New(req, "session"); !errors.As(err, &corrupt) || !session.IsNew {
+		t.Errorf("Expected corrupt load; Got %v", err)
+	}
+	if f.Loads() != 3 || f.Saves() != 2 {
+		t.Errorf("Expected 3 loads and 2 saves; Got %d %d", f.Loads(), f.Saves())
+	}
+}
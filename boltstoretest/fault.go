@@ -0,0 +1,156 @@
+package boltstoretest
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/sessions"
+	"github.com/maxim0r/boltstore"
+)
+
+// ErrInjected is the default error of injected failures.
+var ErrInjected = errors.New("boltstoretest: injected failure")
+
+// FaultStore is a sessions.Store wrapping another store with scripted
+// failures, to test error paths of handlers around session persistence.
+// Loads and saves are numbered from 1 in call order.
+type FaultStore struct {
+	Store sessions.Store
+
+	mu         sync.Mutex
+	loads      int
+	saves      int
+	loadFaults map[int]error
+	saveFaults map[int]error
+	failSaves  error
+	loadDelay  time.Duration
+}
+
+// NewFaultStore returns a fault injecting wrapper of store.
+func NewFaultStore(store sessions.Store) *FaultStore {
+	return &FaultStore{
+		Store:      store,
+		loadFaults: make(map[int]error),
+		saveFaults: make(map[int]error),
+	}
+}
+
+// FailSave makes save n fail with err, ErrInjected if nil, without saving.
+func (f *FaultStore) FailSave(n int, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.saveFaults[n] = orInjected(err)
+}
+
+// FailSaves makes all following saves fail with err, nil stops failing them.
+func (f *FaultStore) FailSaves(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failSaves = err
+}
+
+// FailLoad makes load n return a new session and err, ErrInjected if nil.
+func (f *FaultStore) FailLoad(n int, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.loadFaults[n] = orInjected(err)
+}
+
+// CorruptLoad makes load n fail as the store does for a record which can't
+// be deserialized, with a new session and *boltstore.CorruptRecordError.
+func (f *FaultStore) CorruptLoad(n int) {
+	f.FailLoad(n, &boltstore.CorruptRecordError{ID: "injected", Err: errors.New("unexpected EOF")})
+}
+
+// DelayLoads delays all following loads by d.
+func (f *FaultStore) DelayLoads(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.loadDelay = d
+}
+
+// Loads returns the count of loads so far.
+func (f *FaultStore) Loads() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.loads
+}
+
+// Saves returns the count of saves so far, including failed ones.
+func (f *FaultStore) Saves() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.saves
+}
+
+// Get returns a session for the given name after adding it to the registry.
+func (f *FaultStore) Get(r *http.Request, name string) (*sessions.Session, error) {
+	return sessions.GetRegistry(r).Get(f, name)
+}
+
+// New loads the session of the wrapped store, unless the load is scripted
+// to fail.
+func (f *FaultStore) New(r *http.Request, name string) (*sessions.Session, error) {
+	f.mu.Lock()
+	f.loads++
+	err := f.loadFaults[f.loads]
+	delay := f.loadDelay
+	f.mu.Unlock()
+	if delay > 0 {
+		t := time.NewTimer(delay)
+		select {
+		case <-r.Context().Done():
+			t.Stop()
+			err = r.Context().Err()
+		case <-t.C:
+		}
+	}
+	if err != nil {
+		// a new session of the wrapped store, as for a request without cookie
+		clean := r.Clone(r.Context())
+		clean.Header.Del("Cookie")
+		session, _ := f.Store.New(clean, name)
+		return f.own(session), err
+	}
+	session, err := f.Store.New(r, name)
+	return f.own(session), err
+}
+
+// own returns a copy of session of the wrapped store bound to f, so
+// sessions.Save saves it through f.
+func (f *FaultStore) own(session *sessions.Session) *sessions.Session {
+	if session == nil {
+		return nil
+	}
+	s := sessions.NewSession(f, session.Name())
+	s.ID = session.ID
+	s.Values = session.Values
+	s.Options = session.Options
+	s.IsNew = session.IsNew
+	return s
+}
+
+// Save saves the session with the wrapped store, unless the save is
+// scripted to fail.
+func (f *FaultStore) Save(r *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	f.mu.Lock()
+	f.saves++
+	err := f.saveFaults[f.saves]
+	if err == nil {
+		err = f.failSaves
+	}
+	f.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return f.Store.Save(r, w, session)
+}
+
+func orInjected(err error) error {
+	if err == nil {
+		return ErrInjected
+	}
+	return err
+}
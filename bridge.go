@@ -0,0 +1,86 @@
+package boltstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// bridgeQueue is the count of invalidations waiting to be published before
+// new ones are dropped.
+const bridgeQueue = 1024
+
+// PubSub is a broadcast channel of a message broker shared by store
+// instances, such as a NATS subject or a Redis pub/sub channel.
+type PubSub interface {
+	// Publish sends msg to all subscribers.
+	Publish(ctx context.Context, msg []byte) error
+	// Subscribe calls fn with every published message until ctx is done.
+	Subscribe(ctx context.Context, fn func(msg []byte)) error
+}
+
+// bridgeMessage is a session invalidation published by Bridge.
+type bridgeMessage struct {
+	Origin string `json:"origin"` // publishing bridge, its own messages are ignored
+	ID     string `json:"id"`
+	Event  string `json:"event"`
+}
+
+// Bridge keeps in-memory data of store instances sharing ps coherent until
+// ctx is done or the store is closed: session saves, deletes and expirations
+// are published to ps, and sessions changed by other instances are dropped
+// from the cache and shared loads. Messages are JSON objects with the raw
+// session ID and the event name, published in background; publish errors and
+// invalidations dropped when the broker falls behind are reported to OnError.
+func (s *BoltStore) Bridge(ctx context.Context, ps PubSub) error {
+	if err := s.enter(); err != nil {
+		return err
+	}
+	defer s.leave()
+	origin := newSessionID()
+	err := ps.Subscribe(ctx, func(msg []byte) {
+		var m bridgeMessage
+		if err := json.Unmarshal(msg, &m); err != nil {
+			s.reportError(fmt.Errorf("decode invalidation message error: %w", err))
+			return
+		}
+		if m.Origin != origin {
+			s.forget(m.ID)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("subscribe to invalidations error: %w", err)
+	}
+
+	queue := make(chan bridgeMessage, bridgeQueue)
+	store := s.ctx
+	s.OnInvalidate(func(id string, ev Event) {
+		if ctx.Err() != nil || store.Err() != nil {
+			return
+		}
+		select {
+		case queue <- bridgeMessage{Origin: origin, ID: id, Event: ev.String()}:
+		default:
+			s.reportError(errors.New("invalidation publish queue is full, message dropped"))
+		}
+	})
+	s.bg.Add(1)
+	go func() {
+		defer s.bg.Done()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-store.Done():
+				return
+			case m := <-queue:
+				b, _ := json.Marshal(m)
+				if err := ps.Publish(ctx, b); err != nil {
+					s.reportError(fmt.Errorf("publish invalidation error: %w", err))
+				}
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,66 @@
+package boltstore
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memPubSub is an in-process PubSub.
+type memPubSub struct {
+	mu   sync.Mutex
+	subs []func([]byte)
+	sent chan []byte
+}
+
+func (ps *memPubSub) Publish(ctx context.Context, msg []byte) error {
+	ps.mu.Lock()
+	subs := ps.subs
+	ps.mu.Unlock()
+	for _, fn := range subs {
+		fn(msg)
+	}
+	ps.sent <- msg
+	return nil
+}
+
+func (ps *memPubSub) Subscribe(ctx context.Context, fn func([]byte)) error {
+	ps.mu.Lock()
+	ps.subs = append(ps.subs, fn)
+	ps.mu.Unlock()
+	return nil
+}
+
+func TestBridge(t *testing.T) {
+	ctx := context.Background()
+	ps := &memPubSub{sent: make(chan []byte, 10)}
+	a := newTestStore(t, Options{CacheSize: 10})
+	b := newTestStore(t, Options{CacheSize: 10})
+	for _, store := range []*BoltStore{a, b} {
+		if err := store.Bridge(ctx, ps); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a session cached by b is dropped when a publishes its change
+	cookie := saveNew(t, b, "session-key", map[interface{}]interface{}{"n": 1})
+	_, session := loadCookie(t, b, "session-key", cookie)
+	<-ps.sent // b's own save, ignored by b
+	if _, ok := b.cache.get(session.ID); !ok {
+		t.Fatal("Expected cached session")
+	}
+	a.invalidate(ctx, session.ID, EventDelete)
+	select {
+	case msg := <-ps.sent:
+		if want := `"id":"` + session.ID + `","event":"delete"`; !strings.Contains(string(msg), want) {
+			t.Errorf("Expected message with %s; Got %s", want, msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected published invalidation")
+	}
+	if _, ok := b.cache.get(session.ID); ok {
+		t.Error("Expected session dropped from cache of the other store")
+	}
+}
@@ -0,0 +1,133 @@
+package boltstore
+
+import (
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// NameOptions overrides store options for sessions of a single name.
+type NameOptions struct {
+	SessionExpire time.Duration     // 0 - store SessionExpire
+	MaxLength     int               // 0 - store MaxLength
+	Serializer    SessionSerializer // nil - store Serializer
+	FillPercent   float64           // 0 - store FillPercent
+	SingleUse     bool              // sessions are deleted by their first load, see SingleUse
+}
+
+// bucketSpec is a bucket holding sessions and its settings.
+type bucketSpec struct {
+	name      []byte
+	expire    time.Duration
+	maxLength int
+	serial    SessionSerializer
+	fill      float64
+	single    bool
+}
+
+// newBucketSpecs returns the main sessions bucket followed by buckets of
+// session names configured in o.Names.
+func newBucketSpecs(o Options) ([]*bucketSpec, map[string]*bucketSpec) {
+	main := &bucketSpec{
+		name:      o.BucketName,
+		expire:    o.SessionExpire,
+		maxLength: o.MaxLength,
+		serial:    o.Serializer,
+		fill:      o.FillPercent,
+	}
+	specs := []*bucketSpec{main}
+	named := make(map[string]*bucketSpec, len(o.Names))
+	for name, no := range o.Names {
+		spec := &bucketSpec{
+			name:      nameBucketName(o.BucketName, name),
+			expire:    no.SessionExpire,
+			maxLength: no.MaxLength,
+			serial:    no.Serializer,
+			fill:      no.FillPercent,
+			single:    no.SingleUse,
+		}
+		if spec.expire == 0 {
+			spec.expire = main.expire
+		}
+		if spec.maxLength == 0 {
+			spec.maxLength = main.maxLength
+		}
+		if spec.serial == nil {
+			spec.serial = main.serial
+		}
+		if spec.fill == 0 {
+			spec.fill = main.fill
+		}
+		specs = append(specs, spec)
+		named[name] = spec
+	}
+	return specs, named
+}
+
+// nameBucketName returns name of the bucket for sessions of the given name.
+func nameBucketName(bucket []byte, name string) []byte {
+	return append(append(append([]byte{}, bucket...), '.'), name...)
+}
+
+// bucketOf returns the bucket for sessions of the given name.
+func (s *BoltStore) bucketOf(name string) *bucketSpec {
+	if spec, ok := s.named[name]; ok {
+		return spec
+	}
+	return s.buckets[0]
+}
+
+// sessionName returns the session name stored in bucket spec, empty for
+// the main bucket.
+func (s *BoltStore) sessionName(spec *bucketSpec) string {
+	for name, named := range s.named {
+		if named == spec {
+			return name
+		}
+	}
+	return ""
+}
+
+// writeTx returns the sessions bucket of spec within write transaction tx,
+// with its fill percent applied to pages split by the transaction.
+func (spec *bucketSpec) writeTx(tx *bolt.Tx) *bolt.Bucket {
+	b := tx.Bucket(spec.name)
+	if spec.fill != 0 {
+		b.FillPercent = spec.fill
+	}
+	return b
+}
+
+// findTx looks up the bucket of session id within transaction tx, starting
+// with prefer bucket. returns nil if the session is not stored.
+func (s *BoltStore) findTx(tx *bolt.Tx, id []byte, prefer *bucketSpec) (*bolt.Bucket, *bucketSpec) {
+	if b := tx.Bucket(prefer.name).Bucket(id); b != nil {
+		return b, prefer
+	}
+	for _, spec := range s.buckets {
+		if spec == prefer {
+			continue
+		}
+		if b := tx.Bucket(spec.name).Bucket(id); b != nil {
+			return b, spec
+		}
+	}
+	return nil, nil
+}
+
+// createBuckets creates all sessions and control buckets, if they don't exist,
+// and stamps the layout version, see layoutTx.
+func (s *BoltStore) createBuckets(tx *bolt.Tx) error {
+	for _, spec := range s.buckets {
+		if _, err := tx.CreateBucketIfNotExists(spec.name); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.CreateBucketIfNotExists(controlBucketName(s.options.BucketName)); err != nil {
+		return err
+	}
+	if err := s.initCount(tx); err != nil {
+		return err
+	}
+	return s.layoutTx(tx)
+}
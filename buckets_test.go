@@ -0,0 +1,189 @@
+package boltstore
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gorilla/sessions"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestNameBuckets(t *testing.T) {
+	store := newTestStore(t, Options{
+		Names: map[string]NameOptions{
+			"flash": {SessionExpire: time.Minute, MaxLength: 64},
+		},
+	})
+	ctx := context.Background()
+	flash := saveNew(t, store, "flash", map[interface{}]interface{}{"n": 1})
+	saveNew(t, store, "auth", map[interface{}]interface{}{"n": 2})
+
+	_, session := loadCookie(t, store, "flash", flash)
+	if session.IsNew || session.Values["n"] != 1 {
+		t.Fatalf("Expected flash session; Got %v", session.Values)
+	}
+	if session.Options.MaxAge != 60 {
+		t.Errorf("Expected cookie MaxAge 60; Got %d", session.Options.MaxAge)
+	}
+	if ttl, err := store.TTL(session.ID); err != nil || ttl > time.Minute {
+		t.Errorf("Expected TTL up to a minute; Got %v, %v", ttl, err)
+	}
+	store.DB().View(func(tx *bolt.Tx) error {
+		if tx.Bucket([]byte("sessions.flash")).Bucket([]byte(session.ID)) == nil {
+			t.Error("Expected flash session in own bucket")
+		}
+		return nil
+	})
+
+	session.Values["big"] = make([]byte, 64)
+	if err := store.Save(nil, NewRecorder(), session); err == nil {
+		t.Error("Expected MaxLength error, got nil")
+	}
+
+	if n, _ := store.Count(ctx); n != 2 {
+		t.Errorf("Expected 2 sessions; Got %d", n)
+	}
+	if n, _ := store.CountActive(ctx); n != 2 {
+		t.Errorf("Expected 2 active sessions; Got %d", n)
+	}
+}
+
+func TestNameByID(t *testing.T) {
+	store := newTestStore(t, Options{
+		Names: map[string]NameOptions{
+			"flash": {SessionExpire: time.Minute},
+		},
+	})
+	ctx := context.Background()
+	_, session := loadCookie(t, store, "flash", saveNew(t, store, "flash", map[interface{}]interface{}{"n": 1}))
+	id := session.ID
+	check := func(by string, session *sessions.Session) {
+		t.Helper()
+		if session.Name() != "flash" || session.Options.MaxAge != 60 {
+			t.Errorf("%s: Expected flash session; Got %q, MaxAge %d", by, session.Name(), session.Options.MaxAge)
+		}
+	}
+
+	err := store.UpdateByID(ctx, id, func(session *sessions.Session) error {
+		check("UpdateByID", session)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.Txn(ctx, func(txn *StoreTxn) error {
+		session, err := txn.Get(id)
+		if err == nil {
+			check("StoreTxn.Get", session)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session, err := store.Peek(id); err != nil {
+		t.Fatal(err)
+	} else {
+		check("Peek", session)
+	}
+	err = store.ForEach(ctx, func(_ string, session *sessions.Session) error {
+		check("ForEach", session)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNameSerializer(t *testing.T) {
+	store := newTestStore(t, Options{
+		Names: map[string]NameOptions{
+			"api": {Serializer: JSONSerializer{}},
+		},
+	})
+	api := saveNew(t, store, "api", map[interface{}]interface{}{"n": "json"})
+	app := saveNew(t, store, "app", map[interface{}]interface{}{"n": "gob"})
+
+	_, session := loadCookie(t, store, "api", api)
+	if session.Values["n"] != "json" {
+		t.Errorf("Expected api session; Got %v", session.Values)
+	}
+	store.DB().View(func(tx *bolt.Tx) error {
+		data := tx.Bucket([]byte("sessions.api")).Bucket([]byte(session.ID)).Get(keyValues)
+		if string(data) != `{"n":"json"}` {
+			t.Errorf("Expected JSON encoded api session; Got %q", data)
+		}
+		return nil
+	})
+	if _, session = loadCookie(t, store, "app", app); session.Values["n"] != "gob" {
+		t.Errorf("Expected app session; Got %v", session.Values)
+	}
+}
+
+func TestNameFillPercent(t *testing.T) {
+	store := newTestStore(t, Options{
+		FillPercent: 0.9,
+		Names: map[string]NameOptions{
+			"api": {},
+			"app": {FillPercent: 0.3},
+		},
+	})
+	for name, fill := range map[string]float64{"": 0.9, "api": 0.9, "app": 0.3} {
+		if got := store.bucketOf(name).fill; got != fill {
+			t.Errorf("Expected %q fill percent %v; Got %v", name, fill, got)
+		}
+	}
+	saveNew(t, store, "app", map[interface{}]interface{}{"n": 1})
+}
+
+func TestMigrateBucket(t *testing.T) {
+	ctx := context.Background()
+	fn := filepath.Join(t.TempDir(), "test.db")
+	opts := Options{KeyPairs: [][]byte{[]byte("secret-key")}, BucketName: []byte("old")}
+	store, err := NewStore(ctx, fn, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 1})
+	store.Close()
+
+	opts.BucketName = []byte("new")
+	store, err = NewStore(ctx, fn, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if err := store.MigrateBucket(ctx, []byte("old"), []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	if _, session := loadCookie(t, store, "session-key", cookie); session.IsNew || session.Values["n"] != 1 {
+		t.Errorf("Expected migrated session; Got %v", session.Values)
+	}
+	if n, _ := store.Count(ctx); n != 1 {
+		t.Errorf("Expected 1 session; Got %d", n)
+	}
+	store.DB().View(func(tx *bolt.Tx) error {
+		if tx.Bucket([]byte("old")) != nil || tx.Bucket([]byte("old_control")) != nil {
+			t.Error("Expected old buckets to be removed")
+		}
+		return nil
+	})
+}
+
+func TestMigrateOwnBucket(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t, Options{})
+	saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 1})
+	if err := store.MigrateBucket(ctx, store.options.BucketName, []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := store.Count(ctx); n != 0 {
+		t.Errorf("Expected no sessions left; Got %d", n)
+	}
+	saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 2})
+	if _, err := store.Reap(ctx); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,106 @@
+package boltstore
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// sessionCache is an LRU cache of deserialized session records, bounded by
+// count and total serialized size.
+type sessionCache struct {
+	mu       sync.Mutex
+	ll       *list.List
+	items    map[string]*list.Element
+	maxCount int
+	maxBytes int
+	ttl      time.Duration
+	bytes    int
+}
+
+type cacheEntry struct {
+	id      string
+	rec     *record
+	expires time.Time // zero - never
+}
+
+func newSessionCache(maxCount, maxBytes int, ttl time.Duration) *sessionCache {
+	return &sessionCache{
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+		maxCount: maxCount,
+		maxBytes: maxBytes,
+		ttl:      ttl,
+	}
+}
+
+// get returns the cached record of session id.
+func (c *sessionCache) get(id string) (*record, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[id]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*cacheEntry)
+	if !e.expires.IsZero() && time.Now().After(e.expires) {
+		c.removeElement(el)
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	return e.rec, true
+}
+
+// put adds or replaces the record of session id, evicting least recently
+// used records over the limits.
+func (c *sessionCache) put(id string, rec *record) {
+	if c.maxBytes > 0 && rec.size > c.maxBytes {
+		c.remove(id)
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[id]; ok {
+		c.removeElement(el)
+	}
+	e := &cacheEntry{id: id, rec: rec}
+	if c.ttl > 0 {
+		e.expires = time.Now().Add(c.ttl)
+	}
+	c.items[id] = c.ll.PushFront(e)
+	c.bytes += rec.size
+	for c.ll.Len() > c.maxCount || (c.maxBytes > 0 && c.bytes > c.maxBytes) {
+		c.removeElement(c.ll.Back())
+	}
+}
+
+// remove drops the record of session id.
+func (c *sessionCache) remove(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[id]; ok {
+		c.removeElement(el)
+	}
+}
+
+func (c *sessionCache) removeElement(el *list.Element) {
+	e := c.ll.Remove(el).(*cacheEntry)
+	delete(c.items, e.id)
+	c.bytes -= e.rec.size
+}
+
+// len returns number of cached records.
+func (c *sessionCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+// purge drops all cached records.
+func (c *sessionCache) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ll.Init()
+	c.items = make(map[string]*list.Element)
+	c.bytes = 0
+}
@@ -0,0 +1,55 @@
+package boltstore
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionCache(t *testing.T) {
+	c := newSessionCache(2, 10, 0)
+	c.put("a", &record{version: 1, size: 4})
+	c.put("b", &record{version: 2, size: 4})
+	c.get("a")
+	c.put("c", &record{version: 3, size: 4}) // over both limits, evicts b
+	if _, ok := c.get("b"); ok {
+		t.Error("Expected b to be evicted")
+	}
+	if rec, ok := c.get("a"); !ok || rec.version != 1 {
+		t.Errorf("Expected a to be cached; Got %v", rec)
+	}
+	c.put("d", &record{size: 11}) // too big
+	if _, ok := c.get("d"); ok {
+		t.Error("Expected d not to be cached")
+	}
+	c.remove("a")
+	if c.len() != 1 || c.bytes != 4 {
+		t.Errorf("Expected 1 record of 4 bytes; Got %d of %d", c.len(), c.bytes)
+	}
+
+	c = newSessionCache(1, 0, time.Millisecond)
+	c.put("a", &record{})
+	time.Sleep(2 * time.Millisecond)
+	if _, ok := c.get("a"); ok {
+		t.Error("Expected a to expire")
+	}
+}
+
+func TestStoreCache(t *testing.T) {
+	store := newTestStore(t, Options{CacheSize: 10})
+	cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 1})
+	if store.cache.len() != 1 {
+		t.Fatalf("Expected saved session to be cached; Got %d", store.cache.len())
+	}
+
+	req, session := loadCookie(t, store, "session-key", cookie)
+	if session.Values["n"] != 1 {
+		t.Errorf("Expected 1; Got %v", session.Values["n"])
+	}
+	session.Options.MaxAge = -1
+	if err := store.Save(req, NewRecorder(), session); err != nil {
+		t.Fatalf("Error deleting session: %v", err)
+	}
+	if store.cache.len() != 0 {
+		t.Errorf("Expected deleted session to be dropped from cache; Got %d", store.cache.len())
+	}
+}
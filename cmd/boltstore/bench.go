@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/maxim0r/boltstore"
+	bolt "go.etcd.io/bbolt"
+)
+
+// benchConfig is a workload replayed by bench.
+type benchConfig struct {
+	sessions int
+	ops      int
+	reads    float64
+	size     int
+	workers  int
+	opts     boltstore.Options
+}
+
+// benchResult are measurements of a workload run.
+type benchResult struct {
+	elapsed   time.Duration
+	latencies []time.Duration
+	errors    int
+}
+
+func bench(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	db := fs.String("db", "", "bolt database file, created if missing")
+	bucket := fs.String("bucket", "bench_sessions", "prefix of buckets of synthetic sessions, removed after the run")
+	sessions := fs.Int("sessions", 1000, "synthetic sessions to generate")
+	ops := fs.Int("ops", 10000, "requests to replay")
+	reads := fs.Float64("reads", 0.9, "fraction of requests only reading the session")
+	size := fs.Int("size", 512, "session value size in bytes")
+	workers := fs.Int("workers", 8, "concurrent requests")
+	serializers := fs.String("serializers", "gob,json", "comma separated serializers to run: gob, json")
+	layouts := fs.String("layouts", "main,named", "comma separated layouts to run: main bucket or a named sessions bucket")
+	profile := fs.String("profile", "durable", "durability profile: durable, balanced, throughput")
+	cache := fs.Int("cache", 0, "cached sessions (0 - cache disabled)")
+	fs.Parse(args)
+	if *db == "" {
+		return fmt.Errorf("-db is required")
+	}
+	profiles := map[string]boltstore.Profile{
+		"durable":    boltstore.ProfileDurable,
+		"balanced":   boltstore.ProfileBalanced,
+		"throughput": boltstore.ProfileThroughput,
+	}
+	p, ok := profiles[*profile]
+	if !ok {
+		return fmt.Errorf("unknown profile %q", *profile)
+	}
+
+	fmt.Printf("%-6s %-6s %10s %10s %10s %10s %10s %7s\n", "serial", "layout", "req/s", "p50", "p90", "p99", "max", "errors")
+	for _, ser := range strings.Split(*serializers, ",") {
+		for _, layout := range strings.Split(*layouts, ",") {
+			cfg := benchConfig{sessions: *sessions, ops: *ops, reads: *reads, size: *size, workers: *workers}
+			cfg.opts = boltstore.Options{
+				KeyPairs:   [][]byte{[]byte("boltstore-bench")},
+				BucketName: []byte(*bucket + "_" + ser + "_" + layout),
+				Durability: p,
+				CacheSize:  *cache,
+			}
+			switch ser {
+			case "gob":
+				cfg.opts.Serializer = boltstore.GobSerializer{}
+			case "json":
+				cfg.opts.Serializer = boltstore.JSONSerializer{}
+			default:
+				return fmt.Errorf("unknown serializer %q", ser)
+			}
+			name := "session"
+			switch layout {
+			case "main":
+			case "named":
+				name = "bench"
+				cfg.opts.Names = map[string]boltstore.NameOptions{name: {}}
+			default:
+				return fmt.Errorf("unknown layout %q", layout)
+			}
+			res, err := runBench(ctx, *db, name, cfg)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%-6s %-6s %10.0f %10v %10v %10v %10v %7d\n", ser, layout,
+				float64(len(res.latencies))/res.elapsed.Seconds(),
+				percentile(res.latencies, 0.5), percentile(res.latencies, 0.9),
+				percentile(res.latencies, 0.99), percentile(res.latencies, 1), res.errors)
+		}
+	}
+	return removeBuckets(*db, []byte(*bucket))
+}
+
+// runBench seeds synthetic sessions of name into a store of db file and
+// replays the configured request mix against them.
+func runBench(ctx context.Context, db, name string, cfg benchConfig) (*benchResult, error) {
+	store, err := boltstore.NewStore(ctx, db, cfg.opts)
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+
+	value := strings.Repeat("x", cfg.size)
+	cookies := make([]string, cfg.sessions)
+	for i := range cookies {
+		req := httptest.NewRequest("GET", "http://localhost/", nil)
+		session, err := store.New(req, name)
+		if err != nil {
+			return nil, err
+		}
+		session.Values["user"] = fmt.Sprintf("user%d@example.com", i)
+		session.Values["data"] = value
+		rsp := httptest.NewRecorder()
+		if err := store.Save(req, rsp, session); err != nil {
+			return nil, fmt.Errorf("seed session error: %w", err)
+		}
+		cookies[i] = rsp.Header().Get("Set-Cookie")
+	}
+
+	res := &benchResult{latencies: make([]time.Duration, 0, cfg.ops)}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	next := make(chan int)
+	start := time.Now()
+	for w := 0; w < cfg.workers; w++ {
+		wg.Add(1)
+		go func(rnd *rand.Rand) {
+			defer wg.Done()
+			for i := range next {
+				t := time.Now()
+				err := benchRequest(store, name, cookies[rnd.Intn(len(cookies))], rnd.Float64() >= cfg.reads, i)
+				d := time.Since(t)
+				mu.Lock()
+				res.latencies = append(res.latencies, d)
+				if err != nil {
+					res.errors++
+				}
+				mu.Unlock()
+			}
+		}(rand.New(rand.NewSource(int64(w))))
+	}
+	for i := 0; i < cfg.ops && ctx.Err() == nil; i++ {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	res.elapsed = time.Since(start)
+	return res, nil
+}
+
+// benchRequest loads the session of cookie and saves it changed if write.
+func benchRequest(store *boltstore.BoltStore, name, cookie string, write bool, n int) error {
+	req := httptest.NewRequest("GET", "http://localhost/", nil)
+	req.Header.Set("Cookie", cookie)
+	session, err := store.New(req, name)
+	if err != nil || !write {
+		return err
+	}
+	session.Values["n"] = n
+	return store.Save(req, discardWriter{}, session)
+}
+
+// discardWriter is a ResponseWriter dropping the response.
+type discardWriter struct{}
+
+func (discardWriter) Header() http.Header         { return http.Header{} }
+func (discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (discardWriter) WriteHeader(int)             {}
+
+// percentile returns the q quantile of latencies, sorting them.
+func percentile(latencies []time.Duration, q float64) time.Duration {
+	if len(latencies) == 0 {
+		return 0
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	i := int(q*float64(len(latencies))+0.5) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(latencies) {
+		i = len(latencies) - 1
+	}
+	return latencies[i].Round(time.Microsecond)
+}
+
+// removeBuckets removes buckets of db file with prefix, left by bench runs.
+func removeBuckets(fn string, prefix []byte) error {
+	db, err := bolt.Open(fn, 0600, &bolt.Options{Timeout: 3 * time.Second})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.Update(func(tx *bolt.Tx) error {
+		var names [][]byte
+		tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+			if bytes.HasPrefix(name, prefix) {
+				names = append(names, append([]byte{}, name...))
+			}
+			return nil
+		})
+		for _, name := range names {
+			if err := tx.DeleteBucket(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
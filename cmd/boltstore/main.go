@@ -0,0 +1,95 @@
+// Command boltstore provides maintenance tools for boltstore databases.
+//
+// Usage:
+//
+//	boltstore <command> [flags]
+//
+// Commands:
+//
+//	migrate-yosssi  import sessions stored by github.com/yosssi/boltstore
+//	bench           replay a synthetic request mix and report throughput and latency
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/maxim0r/boltstore"
+)
+
+// command is a CLI subcommand.
+type command struct {
+	name  string
+	usage string
+	run   func(ctx context.Context, args []string) error
+}
+
+var commands = []command{
+	{"migrate-yosssi", "import sessions stored by github.com/yosssi/boltstore", migrateYosssi},
+	{"bench", "replay a synthetic request mix and report throughput and latency", bench},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	for _, c := range commands {
+		if c.name == os.Args[1] {
+			if err := c.run(context.Background(), os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "boltstore %s: %v\n", c.name, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: boltstore <command> [flags]\n\ncommands:")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-16s %s\n", c.name, c.usage)
+	}
+}
+
+// storeFlags registers flags common for commands opening a store.
+func storeFlags(fs *flag.FlagSet) (db, bucket *string) {
+	db = fs.String("db", "", "bolt database file")
+	bucket = fs.String("bucket", "sessions", "sessions bucket name")
+	return db, bucket
+}
+
+// openStore opens the store of db file. Cookie keys are not used by
+// maintenance commands.
+func openStore(ctx context.Context, db, bucket string) (*boltstore.BoltStore, error) {
+	if db == "" {
+		return nil, fmt.Errorf("-db is required")
+	}
+	return boltstore.NewStore(ctx, db, boltstore.Options{
+		KeyPairs:   [][]byte{[]byte("boltstore-cli")},
+		BucketName: []byte(bucket),
+	})
+}
+
+func migrateYosssi(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("migrate-yosssi", flag.ExitOnError)
+	db, bucket := storeFlags(fs)
+	from := fs.String("from", "sessions", "yosssi/boltstore bucket name")
+	fs.Parse(args)
+
+	store, err := openStore(ctx, *db, *bucket)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	n, err := store.ImportYosssi(ctx, store.DB(), []byte(*from))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("imported %d sessions\n", n)
+	return nil
+}
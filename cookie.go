@@ -0,0 +1,143 @@
+package boltstore
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/sessions"
+)
+
+// cookieReuseAge is the max age of a cookie value sent back unchanged on
+// save. Older cookies are encoded again to renew their timestamp before
+// securecookie max age rejects them.
+const cookieReuseAge = time.Hour
+
+// sentCookie is a cookie value decoded with the current key.
+type sentCookie struct {
+	id    string
+	value string
+	at    time.Time // securecookie timestamp
+}
+
+// rememberCookie keeps value of the cookie the session ID was decoded from,
+// if its timestamp can be read.
+func rememberCookie(session *sessions.Session, value string) {
+	if at, ok := cookieTime(value); ok {
+		setMeta(session, metaCookie, &sentCookie{id: session.ID, value: value, at: at})
+	}
+}
+
+// reuseAge returns the max age of a cookie value sent back unchanged,
+// within the first half of the securecookie max age.
+func reuseAge(opts Options) time.Duration {
+	if opts.CookieMaxAge > 0 && opts.CookieMaxAge/2 < cookieReuseAge {
+		return opts.CookieMaxAge / 2
+	}
+	return cookieReuseAge
+}
+
+// reusableCookie returns the cookie value the session was loaded with, if
+// it is younger than maxAge and can be sent back instead of encoding the ID
+// again.
+func reusableCookie(session *sessions.Session, maxAge time.Duration) (string, bool) {
+	v, _ := getMeta(session, metaCookie)
+	c, ok := v.(*sentCookie)
+	if !ok || c.id != session.ID || time.Since(c.at) > maxAge {
+		return "", false
+	}
+	return c.value, true
+}
+
+// cookieTime reads the timestamp of securecookie encoded value: base64 of
+// "timestamp|value|mac" with decimal unix seconds.
+func cookieTime(value string) (time.Time, bool) {
+	var buf [12]byte
+	if len(value) < 16 {
+		return time.Time{}, false
+	}
+	n, err := base64.URLEncoding.Decode(buf[:], []byte(value[:16]))
+	if err != nil {
+		return time.Time{}, false
+	}
+	var sec int64
+	for _, c := range buf[:n] {
+		if c == '|' {
+			return time.Unix(sec, 0), true
+		}
+		if c < '0' || c > '9' {
+			break
+		}
+		sec = sec*10 + int64(c-'0')
+	}
+	return time.Time{}, false
+}
+
+// cookieOptions returns the options of the cookie of a saved session, with
+// the browser lifetime of opts.CookieLifetime.
+func cookieOptions(session *sessions.Session, opts Options) *sessions.Options {
+	if opts.CookieLifetime == 0 {
+		return session.Options
+	}
+	o := *session.Options
+	o.MaxAge = int(opts.CookieLifetime / time.Second)
+	if o.MaxAge < 0 {
+		// no Max-Age, a browser session cookie
+		o.MaxAge = 0
+	}
+	return &o
+}
+
+// cookieName returns the name of cookies of sessions of name, see
+// Options.CookieName.
+func cookieName(opts Options, name string) string {
+	if opts.CookieName == nil {
+		return name
+	}
+	return opts.CookieName(name)
+}
+
+// CookieScope is a domain and path a session cookie is set for, see
+// Options.CookieScopes.
+type CookieScope struct {
+	Domain string
+	Path   string // "" - the session cookie path
+}
+
+// setCookies sets the cookie name with value and opts, and a copy of it for
+// every scope.
+func setCookies(w http.ResponseWriter, name, value string, opts *sessions.Options, scopes []CookieScope) {
+	http.SetCookie(w, sessions.NewCookie(name, value, opts))
+	for _, scope := range scopes {
+		o := *opts
+		o.Domain = scope.Domain
+		if scope.Path != "" {
+			o.Path = scope.Path
+		}
+		http.SetCookie(w, sessions.NewCookie(name, value, &o))
+	}
+}
+
+// setCookie sets the cookie of the stored session on w.
+func (s *BoltStore) setCookie(w http.ResponseWriter, session *sessions.Session) error {
+	return writeCookie(w, s.options, s.CookieCodec(), session)
+}
+
+// writeCookie sets the cookie of the stored session on w, with the ID
+// encoded by codec, for stores with options opts.
+func writeCookie(w http.ResponseWriter, opts Options, codec CookieCodec, session *sessions.Session) error {
+	name := cookieName(opts, session.Name())
+	encoded, err := codec.Encode(name, session.ID)
+	if err != nil {
+		return fmt.Errorf("encode cookie error: %w", err)
+	}
+	setCookies(w, name, encoded, cookieOptions(session, opts), opts.CookieScopes)
+	return nil
+}
+
+// clearCookie expires the cookie of the deleted session on w, for stores
+// with options opts.
+func clearCookie(w http.ResponseWriter, opts Options, session *sessions.Session) {
+	setCookies(w, cookieName(opts, session.Name()), "", session.Options, opts.CookieScopes)
+}
@@ -0,0 +1,171 @@
+package boltstore
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/securecookie"
+)
+
+func TestReuseCookie(t *testing.T) {
+	store := newTestStore(t, Options{})
+	cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 1})
+	req, session := loadCookie(t, store, "session-key", cookie)
+	rsp := NewRecorder()
+	if err := store.Save(req, rsp, session); err != nil {
+		t.Fatal(err)
+	}
+	if got := rsp.Header().Get("Set-Cookie"); strings.Split(got, ";")[0] != strings.Split(cookie, ";")[0] {
+		t.Errorf("Expected unchanged cookie %q; Got %q", cookie, got)
+	}
+
+	// a new ID is encoded
+	session.ID = newSessionID()
+	rsp = NewRecorder()
+	if err := store.Save(req, rsp, session); err != nil {
+		t.Fatal(err)
+	}
+	if got := rsp.Header().Get("Set-Cookie"); strings.Split(got, ";")[0] == strings.Split(cookie, ";")[0] {
+		t.Error("Expected cookie of a new ID")
+	}
+}
+
+func TestCookieTime(t *testing.T) {
+	value, err := securecookie.EncodeMulti("session-key", "id", securecookie.CodecsFromPairs([]byte("secret-key"))...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if at, ok := cookieTime(value); !ok || time.Since(at) > time.Minute {
+		t.Errorf("Expected current timestamp; Got %v %v", at, ok)
+	}
+	if _, ok := cookieTime("garbage"); ok {
+		t.Error("Expected no timestamp of malformed value")
+	}
+}
+
+func TestCookieTuning(t *testing.T) {
+	store := newTestStore(t, Options{CookieSerializer: securecookie.JSONEncoder{}, CookieMaxLength: 64})
+	req, session := loadCookie(t, store, "session-key", "")
+	if err := store.Save(req, NewRecorder(), session); err == nil {
+		t.Error("Expected securecookie max length error")
+	}
+
+	store = newTestStore(t, Options{CookieSerializer: securecookie.JSONEncoder{}})
+	cookie := saveNew(t, store, "session-key", nil)
+	value := strings.TrimPrefix(strings.Split(cookie, ";")[0], "session-key=")
+	sc := securecookie.New([]byte("secret-key"), nil)
+	sc.SetSerializer(securecookie.JSONEncoder{})
+	var id string
+	if err := sc.Decode("session-key", value, &id); err != nil {
+		t.Errorf("Expected JSON encoded ID; Got %v", err)
+	}
+
+	if got := reuseAge(setOptions(Options{SessionExpire: 10 * time.Minute})); got != 5*time.Minute {
+		t.Errorf("Expected reuse within half of SessionExpire; Got %v", got)
+	}
+	if got := reuseAge(setOptions(Options{})); got != cookieReuseAge {
+		t.Errorf("Expected default reuse age; Got %v", got)
+	}
+}
+
+func TestRenewBelow(t *testing.T) {
+	store := newTestStore(t, Options{RenewBelow: 0.5, SessionExpire: time.Hour})
+	cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 1})
+	req, session := loadCookie(t, store, "session-key", cookie)
+	ttl, _ := store.TTL(session.ID)
+	session.Values["n"] = 2
+	rsp := NewRecorder()
+	if err := store.Save(req, rsp, session); err != nil {
+		t.Fatal(err)
+	}
+	if got := rsp.Header().Get("Set-Cookie"); got != "" {
+		t.Errorf("Expected no cookie while most of the lifetime remains; Got %q", got)
+	}
+	if after, _ := store.TTL(session.ID); after > ttl {
+		t.Errorf("Expected expiry kept; Got %v after %v", after, ttl)
+	}
+
+	setExpiry(t, store, session.ID, time.Now().Add(20*time.Minute))
+	req, session = loadCookie(t, store, "session-key", cookie)
+	if session.Values["n"] != 2 {
+		t.Fatalf("Expected saved values; Got %v", session.Values)
+	}
+	rsp = NewRecorder()
+	if err := store.Save(req, rsp, session); err != nil {
+		t.Fatal(err)
+	}
+	if ttl, _ := store.TTL(session.ID); rsp.Header().Get("Set-Cookie") == "" || ttl < 50*time.Minute {
+		t.Errorf("Expected renewed cookie and expiry; Got ttl %v", ttl)
+	}
+}
+
+func TestCookieLifetime(t *testing.T) {
+	for _, tc := range []struct {
+		lifetime time.Duration
+		want     string
+	}{
+		{0, "Max-Age=86400"},
+		{time.Hour, "Max-Age=3600"},
+		{-1, ""},
+	} {
+		store := newTestStore(t, Options{CookieLifetime: tc.lifetime})
+		cookie := saveNew(t, store, "session-key", nil)
+		if tc.want == "" && (strings.Contains(cookie, "Max-Age") || strings.Contains(cookie, "Expires")) {
+			t.Errorf("Expected browser session cookie; Got %q", cookie)
+		}
+		if tc.want != "" && !strings.Contains(cookie, tc.want) {
+			t.Errorf("Expected %s; Got %q", tc.want, cookie)
+		}
+		_, session := loadCookie(t, store, "session-key", cookie)
+		if ttl, _ := store.TTL(session.ID); session.IsNew || ttl < 23*time.Hour {
+			t.Errorf("Expected server-side expiry unchanged; Got %v", ttl)
+		}
+	}
+}
+
+func TestCookieName(t *testing.T) {
+	staging := newTestStore(t, Options{CookieName: func(name string) string { return "staging_" + name }})
+	cookie := saveNew(t, staging, "session-key", map[interface{}]interface{}{"n": 1})
+	if !strings.HasPrefix(cookie, "staging_session-key=") {
+		t.Fatalf("Expected prefixed cookie name; Got %q", cookie)
+	}
+	if _, session := loadCookie(t, staging, "session-key", cookie); session.IsNew || session.Values["n"] != 1 {
+		t.Errorf("Expected session of the prefixed cookie; Got %v", session.Values)
+	}
+
+	// a cookie of another environment is ignored
+	prod := newTestStore(t, Options{})
+	other := saveNew(t, prod, "session-key", nil)
+	if _, session := loadCookie(t, staging, "session-key", other); !session.IsNew {
+		t.Error("Expected a new session ignoring the unprefixed cookie")
+	}
+}
+
+func TestCookieScopes(t *testing.T) {
+	store := newTestStore(t, Options{CookieScopes: []CookieScope{{Domain: "new.example.com"}, {Domain: "old.example.com", Path: "/app"}}})
+	req, session := loadCookie(t, store, "session-key", "")
+	rsp := NewRecorder()
+	if err := store.Save(req, rsp, session); err != nil {
+		t.Fatal(err)
+	}
+	cookies := (&http.Response{Header: rsp.Header()}).Cookies()
+	if len(cookies) != 3 || cookies[1].Domain != "new.example.com" || cookies[1].Path != "/" || cookies[2].Domain != "old.example.com" || cookies[2].Path != "/app" {
+		t.Fatalf("Expected cookies of all scopes; Got %v", rsp.Header()["Set-Cookie"])
+	}
+	for _, c := range cookies[1:] {
+		if c.Value != cookies[0].Value {
+			t.Errorf("Expected the same value in all scopes; Got %q", c.Value)
+		}
+	}
+
+	session.Options.MaxAge = -1
+	rsp = NewRecorder()
+	if err := store.Save(req, rsp, session); err != nil {
+		t.Fatal(err)
+	}
+	if n := len((&http.Response{Header: rsp.Header()}).Cookies()); n != 3 {
+		t.Errorf("Expected cookies of all scopes cleared; Got %d", n)
+	}
+}
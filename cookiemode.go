@@ -0,0 +1,157 @@
+package boltstore
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gorilla/securecookie"
+)
+
+// CookieMode is how session IDs are encoded in cookies.
+type CookieMode int
+
+const (
+	// CookieEncrypted encodes IDs with securecookie and Options.KeyPairs:
+	// signed, and encrypted if the pairs include block keys.
+	CookieEncrypted CookieMode = iota
+	// CookieSigned sends IDs in clear followed by '.' and their base64
+	// HMAC-SHA256 signed with the hash keys of Options.KeyPairs, so edge
+	// infrastructure can route on IDs.
+	CookieSigned
+	// CookieRaw sends bare IDs, their secrecy is the only protection. Meant
+	// for internal tools and staging, Options.KeyPairs are not required.
+	CookieRaw
+)
+
+// errCookieID is returned decoding cookies which are not IDs of the mode.
+var errCookieID = errors.New("boltstore: cookie is not a session ID")
+
+// newCodecs returns the session cookie codecs of opts.
+func newCodecs(opts Options) []securecookie.Codec {
+	switch opts.CookieMode {
+	case CookieSigned:
+		var codecs []securecookie.Codec
+		for i := 0; i < len(opts.KeyPairs); i += 2 {
+			codecs = append(codecs, signedCodec(opts.KeyPairs[i]))
+		}
+		return codecs
+	case CookieRaw:
+		return []securecookie.Codec{rawCodec{}}
+	}
+	codecs := securecookie.CodecsFromPairs(opts.KeyPairs...)
+	for _, c := range codecs {
+		sc := c.(*securecookie.SecureCookie)
+		sc.MaxAge(int(opts.CookieMaxAge / time.Second))
+		sc.MinAge(int(opts.CookieMinAge / time.Second))
+		if opts.CookieMaxLength != 0 {
+			sc.MaxLength(opts.CookieMaxLength)
+		}
+		if opts.CookieSerializer != nil {
+			sc.SetSerializer(opts.CookieSerializer)
+		}
+	}
+	return codecs
+}
+
+// signedCodec encodes IDs signed with a hash key, see CookieSigned.
+type signedCodec []byte
+
+func (c signedCodec) mac(name, id string) string {
+	h := hmac.New(sha256.New, c)
+	h.Write([]byte(name))
+	h.Write([]byte{'|'})
+	h.Write([]byte(id))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+func (c signedCodec) Encode(name string, value interface{}) (string, error) {
+	id, ok := value.(string)
+	if !ok {
+		return "", errCookieID
+	}
+	return id + "." + c.mac(name, id), nil
+}
+
+func (c signedCodec) Decode(name, value string, dst interface{}) error {
+	i := strings.LastIndexByte(value, '.')
+	if i < 0 || !isSessionID(value[:i]) || !hmac.Equal([]byte(value[i+1:]), []byte(c.mac(name, value[:i]))) {
+		return errCookieID
+	}
+	return setID(dst, value[:i])
+}
+
+// rawCodec encodes bare IDs, see CookieRaw.
+type rawCodec struct{}
+
+func (rawCodec) Encode(name string, value interface{}) (string, error) {
+	id, ok := value.(string)
+	if !ok {
+		return "", errCookieID
+	}
+	return id, nil
+}
+
+func (rawCodec) Decode(name, value string, dst interface{}) error {
+	if !isSessionID(value) {
+		return errCookieID
+	}
+	return setID(dst, value)
+}
+
+// setID stores id in dst, a *string.
+func setID(dst interface{}, id string) error {
+	p, ok := dst.(*string)
+	if !ok {
+		return errCookieID
+	}
+	*p = id
+	return nil
+}
+
+// isSessionID reports whether id is formatted as newSessionID returns.
+func isSessionID(id string) bool {
+	if len(id) != len(idBuf{}.id) {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if c := id[i]; (c < 'A' || c > 'Z') && (c < '2' || c > '7') {
+			return false
+		}
+	}
+	return true
+}
+
+// CookieCodec encodes session IDs in cookie values, for signing schemes
+// other than securecookie such as HSM-backed or Ed25519 signatures. Decode
+// must reject values it didn't encode.
+type CookieCodec interface {
+	Encode(name, id string) (string, error)
+	Decode(name, value string) (id string, err error)
+}
+
+// codecList is the CookieCodec of securecookie codecs: IDs are encoded
+// with the first one and decoded with any of them.
+type codecList []securecookie.Codec
+
+func (c codecList) Encode(name, id string) (string, error) {
+	return securecookie.EncodeMulti(name, id, c...)
+}
+
+func (c codecList) Decode(name, value string) (string, error) {
+	var id string
+	err := securecookie.DecodeMulti(name, value, &id, c...)
+	return id, err
+}
+
+// CookieCodec returns the codec of session ID cookies: Options.CookieCodec
+// or the Codecs of the store.
+func (s *BoltStore) CookieCodec() CookieCodec {
+	if s.options.CookieCodec != nil {
+		return s.options.CookieCodec
+	}
+	return codecList(s.Codecs)
+}
@@ -0,0 +1,88 @@
+package boltstore
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/securecookie"
+)
+
+func TestCookieMode(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts Options
+	}{
+		{"signed", Options{CookieMode: CookieSigned, KeyPairs: [][]byte{[]byte("new-key"), nil, []byte("secret-key"), nil}}},
+		{"raw", Options{CookieMode: CookieRaw, KeyPairs: [][]byte{}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newTestStore(t, tc.opts)
+			cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 1})
+			req, session := loadCookie(t, store, "session-key", cookie)
+			if session.IsNew || session.Values["n"] != 1 {
+				t.Fatalf("Expected stored session; Got %v", session.Values)
+			}
+			value, _ := req.Cookie("session-key")
+			if !strings.HasPrefix(value.Value, session.ID) {
+				t.Errorf("Expected ID in clear; Got %q for %q", value.Value, session.ID)
+			}
+
+			req, _ = http.NewRequest("GET", "http://localhost:8080/", nil)
+			req.AddCookie(&http.Cookie{Name: "session-key", Value: strings.Repeat("A", 52) + ".x"})
+			if session, err := store.New(req, "session-key"); err == nil || !session.IsNew {
+				t.Error("Expected new session and decode error of a forged cookie")
+			}
+		})
+	}
+
+	// cookies signed with old keys are accepted
+	old := newTestStore(t, Options{CookieMode: CookieSigned})
+	cookie := saveNew(t, old, "session-key", nil)
+	store := newTestStore(t, Options{CookieMode: CookieSigned, KeyPairs: [][]byte{[]byte("new-key"), nil, []byte("secret-key"), nil}})
+	req, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	req.Header.Add("Cookie", cookie)
+	c, _ := req.Cookie("session-key")
+	var id string
+	if err := securecookie.DecodeMulti("session-key", c.Value, &id, store.Codecs...); err != nil || !strings.HasPrefix(c.Value, id+".") {
+		t.Errorf("Expected decoded ID; Got %q, %v", id, err)
+	}
+	if err := signedCodec("other-key").Decode("session-key", c.Value, &id); err == nil {
+		t.Error("Expected signature error with another key")
+	}
+}
+
+// ed25519Codec signs IDs with an Ed25519 key.
+type ed25519Codec ed25519.PrivateKey
+
+func (c ed25519Codec) Encode(name, id string) (string, error) {
+	sig := ed25519.Sign(ed25519.PrivateKey(c), []byte(name+"|"+id))
+	return id + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+func (c ed25519Codec) Decode(name, value string) (string, error) {
+	id, sig, _ := strings.Cut(value, ".")
+	b, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !ed25519.Verify(ed25519.PrivateKey(c).Public().(ed25519.PublicKey), []byte(name+"|"+id), b) {
+		return "", errors.New("invalid signature")
+	}
+	return id, nil
+}
+
+func TestCookieCodec(t *testing.T) {
+	_, key, _ := ed25519.GenerateKey(nil)
+	store := newTestStore(t, Options{CookieCodec: ed25519Codec(key), KeyPairs: [][]byte{}})
+	cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 1})
+	if _, session := loadCookie(t, store, "session-key", cookie); session.IsNew || session.Values["n"] != 1 {
+		t.Fatalf("Expected stored session; Got %v", session.Values)
+	}
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	req.AddCookie(&http.Cookie{Name: "session-key", Value: strings.Repeat("A", 52) + ".x"})
+	if session, err := store.New(req, "session-key"); err == nil || !session.IsNew {
+		t.Error("Expected new session and decode error of a forged cookie")
+	}
+}
@@ -0,0 +1,29 @@
+package boltstore
+
+import (
+	"context"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// dropCorrupt deletes the record of session id which failed to deserialize
+// with cause, and reports it to error hooks.
+func (s *BoltStore) dropCorrupt(ctx context.Context, id string, cause error) {
+	err := s.write(func(tx *bolt.Tx) error {
+		_, spec := s.findTx(tx, []byte(id), s.buckets[0])
+		if spec == nil {
+			return nil
+		}
+		if err := tx.Bucket(spec.name).DeleteBucket([]byte(id)); err != nil {
+			return err
+		}
+		return s.addCount(tx, -1)
+	})
+	s.reportError(s.traced(ctx, cause))
+	if err != nil {
+		s.forget(id)
+		s.reportError(s.traced(ctx, err))
+		return
+	}
+	s.invalidate(ctx, id, EventDelete)
+}
@@ -0,0 +1,44 @@
+package boltstore
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestSkipCorrupt(t *testing.T) {
+	for _, skip := range []bool{false, true} {
+		store := newTestStore(t, Options{SkipCorrupt: skip})
+		var reported []error
+		store.OnError(func(err error) { reported = append(reported, err) })
+		cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 1})
+		_, session := loadCookie(t, store, "session-key", cookie)
+		id := session.ID
+		store.DB().Update(func(tx *bolt.Tx) error {
+			return tx.Bucket(store.options.BucketName).Bucket([]byte(id)).Put(keyValues, []byte("garbage"))
+		})
+
+		req, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+		req.Header.Add("Cookie", cookie)
+		_, err := store.New(req, "session-key")
+		var ce *CorruptRecordError
+		if !skip {
+			if !errors.As(err, &ce) || ce.ID != id {
+				t.Errorf("Expected CorruptRecordError; Got %v", err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(reported) != 1 || !errors.As(reported[0], &ce) {
+			t.Errorf("Expected reported corrupt record; Got %v", reported)
+		}
+		if n, _ := store.Count(context.Background()); n != 0 || store.Exists(id) {
+			t.Errorf("Expected corrupt record to be deleted; Got count %d", n)
+		}
+	}
+}
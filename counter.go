@@ -0,0 +1,90 @@
+package boltstore
+
+import (
+	"log"
+	"sync"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// accessCounter accumulates session access counts in memory between
+// flushes. Counts not flushed yet are lost on crash, which is tolerated:
+// the counters are approximate.
+type accessCounter struct {
+	mu     sync.Mutex
+	counts map[string]uint64
+}
+
+func newAccessCounter() *accessCounter {
+	return &accessCounter{counts: make(map[string]uint64)}
+}
+
+// inc counts an access of session id.
+func (c *accessCounter) inc(id string) {
+	c.mu.Lock()
+	c.counts[id]++
+	c.mu.Unlock()
+}
+
+// pending returns the count of session id not flushed yet.
+func (c *accessCounter) pending(id string) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counts[id]
+}
+
+// take returns and resets pending counts.
+func (c *accessCounter) take() map[string]uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	counts := c.counts
+	c.counts = make(map[string]uint64, len(counts))
+	return counts
+}
+
+// restore adds counts taken but not flushed back to pending counts.
+func (c *accessCounter) restore(counts map[string]uint64) {
+	c.mu.Lock()
+	for id, n := range counts {
+		c.counts[id] += n
+	}
+	c.mu.Unlock()
+}
+
+// countAccess counts a load of session id, if enabled.
+func (s *BoltStore) countAccess(id string) {
+	if s.counts != nil {
+		s.counts.inc(id)
+	}
+}
+
+// flushAccesses adds pending access counts to stored sessions in a single
+// write transaction. Counts of sessions removed in the meantime are dropped,
+// counts of a flush shed under Options.MaxWriters load wait for the next one.
+func (s *BoltStore) flushAccesses() {
+	if s.counts == nil {
+		return
+	}
+	counts := s.counts.take()
+	if len(counts) == 0 {
+		return
+	}
+	ok, err := s.refresh(func(tx *bolt.Tx) error {
+		for id, n := range counts {
+			b, _ := s.findTx(tx, []byte(id), s.buckets[0])
+			if b == nil {
+				continue
+			}
+			if err := b.Put(keyAccessCount, encodeUint(decodeUint(b.Get(keyAccessCount))+n)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if !ok {
+		s.counts.restore(counts)
+	}
+	if err != nil {
+		log.Printf("boltstore: flush session access counts error: %v", err)
+	}
+}
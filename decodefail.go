@@ -0,0 +1,144 @@
+package boltstore
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// decodeClients is the max count of clients whose cookie decode failures
+// are tracked, failures of more clients are only counted in total.
+const decodeClients = 10000
+
+// DecodeFailure is a session cookie of a request which failed to decode,
+// passed to OnDecodeFailure hooks.
+type DecodeFailure struct {
+	IP      string // client IP address, see Options.ClientIP
+	Name    string // session name
+	Count   int    // failures of the client within the last minute
+	Blocked bool   // the client is blocked by this failure, see Options.MaxDecodeFailures
+}
+
+// CookieStats are counts of session cookie decode failures since the store
+// was created.
+type CookieStats struct {
+	DecodeFailures uint64 // cookies failing to decode
+	Blocked        uint64 // cookies ignored because their client is blocked
+	BlockedClients int    // clients blocked now
+}
+
+// decodeFailures tracks cookie decode failures per client.
+type decodeFailures struct {
+	mu      sync.Mutex
+	clients map[string]*clientFailures
+	total   atomic.Uint64
+	blocked atomic.Uint64
+}
+
+// clientFailures are recent decode failures of a client.
+type clientFailures struct {
+	n     int
+	since time.Time // start of the counting window
+	until time.Time // end of the block, zero if not blocked
+}
+
+// OnDecodeFailure registers fn to be called with every session cookie
+// failing to decode, for example to log scanners. Hooks are called
+// synchronously and must not block.
+func (s *BoltStore) OnDecodeFailure(fn func(DecodeFailure)) {
+	s.hooks.mu.Lock()
+	s.hooks.decode = append(s.hooks.decode, fn)
+	s.hooks.mu.Unlock()
+}
+
+// decodeFailed counts a cookie of session name of request r failing to
+// decode and notifies hooks.
+func (s *BoltStore) decodeFailed(r *http.Request, name string) {
+	s.decodes.total.Add(1)
+	if s.options.MaxDecodeFailures <= 0 && !s.hasDecodeHooks() {
+		return
+	}
+	ip := s.clientIP(r)
+	now := time.Now()
+	f := DecodeFailure{IP: ip, Name: name}
+
+	s.decodes.mu.Lock()
+	if s.decodes.clients == nil {
+		s.decodes.clients = make(map[string]*clientFailures)
+	}
+	c, ok := s.decodes.clients[ip]
+	if !ok && len(s.decodes.clients) < decodeClients {
+		c = &clientFailures{}
+		s.decodes.clients[ip] = c
+	}
+	if c != nil {
+		if now.Sub(c.since) > time.Minute {
+			c.n, c.since = 0, now
+		}
+		c.n++
+		f.Count = c.n
+		if limit := s.options.MaxDecodeFailures; limit > 0 && c.n == limit+1 {
+			c.until = now.Add(s.options.BlockDuration)
+			f.Blocked = true
+		}
+	}
+	s.decodes.mu.Unlock()
+
+	s.hooks.mu.RLock()
+	defer s.hooks.mu.RUnlock()
+	for _, fn := range s.hooks.decode {
+		fn(f)
+	}
+}
+
+// blockedClient reports whether cookies of the client of request r are
+// ignored, see Options.MaxDecodeFailures.
+func (s *BoltStore) blockedClient(r *http.Request) bool {
+	if s.options.MaxDecodeFailures <= 0 {
+		return false
+	}
+	ip := s.clientIP(r)
+	s.decodes.mu.Lock()
+	defer s.decodes.mu.Unlock()
+	c, ok := s.decodes.clients[ip]
+	if !ok || !time.Now().Before(c.until) {
+		return false
+	}
+	s.decodes.blocked.Add(1)
+	return true
+}
+
+// pruneDecodes drops clients without recent failures or blocks, called by
+// the reaper.
+func (s *BoltStore) pruneDecodes() {
+	now := time.Now()
+	s.decodes.mu.Lock()
+	defer s.decodes.mu.Unlock()
+	for ip, c := range s.decodes.clients {
+		if now.Sub(c.since) > time.Minute && !now.Before(c.until) {
+			delete(s.decodes.clients, ip)
+		}
+	}
+}
+
+// cookieStats returns the decode failure counts.
+func (s *BoltStore) cookieStats() CookieStats {
+	st := CookieStats{DecodeFailures: s.decodes.total.Load(), Blocked: s.decodes.blocked.Load()}
+	now := time.Now()
+	s.decodes.mu.Lock()
+	for _, c := range s.decodes.clients {
+		if now.Before(c.until) {
+			st.BlockedClients++
+		}
+	}
+	s.decodes.mu.Unlock()
+	return st
+}
+
+// hasDecodeHooks reports whether any decode failure hooks are registered.
+func (s *BoltStore) hasDecodeHooks() bool {
+	s.hooks.mu.RLock()
+	defer s.hooks.mu.RUnlock()
+	return len(s.hooks.decode) > 0
+}
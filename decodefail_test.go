@@ -0,0 +1,57 @@
+package boltstore
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gorilla/sessions"
+)
+
+func TestMaxDecodeFailures(t *testing.T) {
+	store := newTestStore(t, Options{MaxDecodeFailures: 2})
+	var failures []DecodeFailure
+	store.OnDecodeFailure(func(f DecodeFailure) { failures = append(failures, f) })
+	cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"foo": "bar"})
+	load := func(addr, cookie string) (*sessions.Session, error) {
+		req, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+		req.RemoteAddr = addr
+		req.Header.Add("Cookie", cookie)
+		return store.New(req, "session-key")
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := load("192.0.2.1:1000", "session-key=garbage"); err == nil {
+			t.Fatal("Expected garbage cookie to fail decoding")
+		}
+	}
+	if len(failures) != 3 || failures[0].IP != "192.0.2.1" || failures[0].Name != "session-key" || failures[2].Count != 3 {
+		t.Fatalf("Expected 3 failures of the client reported; Got %+v", failures)
+	}
+	if failures[1].Blocked || !failures[2].Blocked {
+		t.Errorf("Expected the client blocked over the limit; Got %+v", failures)
+	}
+
+	session, err := load("192.0.2.1:2000", cookie)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !session.IsNew {
+		t.Error("Expected cookies of the blocked client ignored")
+	}
+	session, err = load("192.0.2.2:1000", cookie)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.IsNew || session.Values["foo"] != "bar" {
+		t.Error("Expected other clients not blocked")
+	}
+
+	st, err := store.Stats(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (CookieStats{DecodeFailures: 3, Blocked: 1, BlockedClients: 1}); st.Cookies != want {
+		t.Errorf("Expected cookie stats %+v; Got %+v", want, st.Cookies)
+	}
+}
@@ -0,0 +1,102 @@
+package boltstore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Delete deletes the session with given id. With Options.SoftDelete the
+// session is kept for the undo window and can be restored with Undelete.
+// Deleting a session which is not stored is not an error.
+func (s *BoltStore) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := s.enter(); err != nil {
+		return err
+	}
+	defer s.leave()
+	return s.traced(ctx, s.delete(ctx, s.newSession("", id)))
+}
+
+// Undelete restores the soft deleted session with given id, if its undo
+// window has not passed yet. The session is subject to its regular expiry.
+func (s *BoltStore) Undelete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := s.enter(); err != nil {
+		return err
+	}
+	defer s.leave()
+	err := s.write(func(tx *bolt.Tx) error {
+		bucket, _ := s.findTx(tx, []byte(id), s.buckets[0])
+		if bucket == nil {
+			return ErrNotFound
+		}
+		if v := bucket.Get(keyDeletedUntil); v == nil || isExpired(v, time.Now()) {
+			return ErrNotFound
+		}
+		return bucket.Delete(keyDeletedUntil)
+	})
+	if err != nil {
+		return s.traced(ctx, err)
+	}
+	s.invalidate(ctx, id, EventSave)
+	return nil
+}
+
+// DeleteAll removes all sessions by dropping and recreating all sessions and
+// control buckets in a single transaction. Invalidation hooks are notified
+// with EventDelete for every removed session.
+func (s *BoltStore) DeleteAll(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := s.enter(); err != nil {
+		return err
+	}
+	defer s.leave()
+	var ids []string
+	collect := s.hasInvalidateHooks()
+	err := s.writeOnce(func(tx *bolt.Tx) error {
+		names := [][]byte{controlBucketName(s.options.BucketName)}
+		for _, spec := range s.buckets {
+			names = append(names, spec.name)
+			if !collect {
+				continue
+			}
+			err := tx.Bucket(spec.name).ForEach(func(k, v []byte) error {
+				if v == nil {
+					ids = append(ids, string(k))
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		for _, name := range names {
+			if err := tx.DeleteBucket(name); err != nil {
+				return fmt.Errorf("delete bucket %q error: %w", string(name), err)
+			}
+		}
+		return s.createBuckets(tx)
+	})
+	if s.loads != nil {
+		s.loads.purge()
+	}
+	if s.cache != nil {
+		s.cache.purge()
+	}
+	if err != nil {
+		return s.traced(ctx, err)
+	}
+	for _, id := range ids {
+		s.invalidate(ctx, id, EventDelete)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package boltstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestSoftDelete(t *testing.T) {
+	store := newTestStore(t, Options{SoftDelete: time.Minute})
+	ctx := context.Background()
+	cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 1})
+	_, session := loadCookie(t, store, "session-key", cookie)
+	id := session.ID
+
+	if err := store.Delete(ctx, id); err != nil {
+		t.Fatalf("Error deleting session: %v", err)
+	}
+	if _, session = loadCookie(t, store, "session-key", cookie); !session.IsNew {
+		t.Fatal("Expected deleted session to be new")
+	}
+
+	if err := store.Undelete(ctx, id); err != nil {
+		t.Fatalf("Error restoring session: %v", err)
+	}
+	if _, session = loadCookie(t, store, "session-key", cookie); session.IsNew || session.Values["n"] != 1 {
+		t.Errorf("Expected restored session; Got %v %v", session.IsNew, session.Values)
+	}
+
+	if err := store.Undelete(ctx, id); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for not deleted session; Got %v", err)
+	}
+}
+
+func TestDeleteAll(t *testing.T) {
+	store := newTestStore(t, Options{CacheSize: 10})
+	ctx := context.Background()
+	var deleted int
+	store.OnInvalidate(func(id string, ev Event) {
+		if ev == EventDelete {
+			deleted++
+		}
+	})
+	cookie := saveNew(t, store, "session-key", nil)
+	saveNew(t, store, "session-key", nil)
+
+	if err := store.DeleteAll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := store.Count(ctx); n != 0 || deleted != 2 || store.cache.len() != 0 {
+		t.Errorf("Expected no sessions; Got %d, %d deleted, %d cached", n, deleted, store.cache.len())
+	}
+	if _, session := loadCookie(t, store, "session-key", cookie); !session.IsNew {
+		t.Error("Expected new session after DeleteAll")
+	}
+	saveNew(t, store, "session-key", nil)
+}
+
+func TestHardDelete(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t, Options{})
+	cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 1})
+	_, session := loadCookie(t, store, "session-key", cookie)
+	if err := store.Delete(ctx, session.ID); err != nil {
+		t.Fatal(err)
+	}
+	store.DB().View(func(tx *bolt.Tx) error {
+		if tx.Bucket(store.options.BucketName).Bucket([]byte(session.ID)) != nil {
+			t.Error("Expected session bucket to be removed")
+		}
+		return nil
+	})
+	if n, _ := store.Count(ctx); n != 0 {
+		t.Errorf("Expected 0 sessions; Got %d", n)
+	}
+}
+
+func TestReapOrphans(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t, Options{})
+	store.DB().Update(func(tx *bolt.Tx) error {
+		if _, err := tx.Bucket(store.options.BucketName).CreateBucket([]byte("orphan")); err != nil {
+			return err
+		}
+		return store.addCount(tx, 1)
+	})
+	saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 1})
+
+	store.reapBucket(store.buckets[0])
+	if n, _ := store.Count(ctx); n != 1 {
+		t.Errorf("Expected orphan to be reaped; Got %d sessions", n)
+	}
+}
@@ -0,0 +1,101 @@
+package boltstore
+
+import (
+	"context"
+	"log"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Profile is a preset of db settings and save strategy, trading durability
+// of recent saves for write throughput.
+type Profile int
+
+const (
+	// ProfileDurable fsyncs every commit, each save is its own transaction.
+	ProfileDurable Profile = iota
+	// ProfileBalanced collapses concurrent saves into batched transactions,
+	// every batch is fsynced.
+	ProfileBalanced
+	// ProfileThroughput batches saves and sets Options.NoSync: saves of the
+	// last sync interval can be lost on a crash of the machine.
+	ProfileThroughput
+)
+
+// boltOptions adjusts options of a db opened by the store for the profile.
+func (p Profile) boltOptions(o *bolt.Options) *bolt.Options {
+	if p == ProfileThroughput {
+		o.NoFreelistSync = true
+	}
+	return o
+}
+
+// write runs fn in a write transaction, batched with concurrent writes
+// unless the store is durable. fn may be called more than once. With
+// Options.MaxWriters it fails with ErrOverloaded if too many writes wait.
+func (s *BoltStore) write(fn func(*bolt.Tx) error) error {
+	if !s.limit.acquire(false) {
+		s.wstats.overloaded.Add(1)
+		return ErrOverloaded
+	}
+	defer s.limit.release()
+	return s.commit(fn)
+}
+
+// refresh runs fn like write, unless half of Options.MaxWriters writes are
+// waiting, so refreshes of access data are shed before saves. returns false
+// if fn was skipped.
+func (s *BoltStore) refresh(fn func(*bolt.Tx) error) (bool, error) {
+	if !s.limit.acquire(true) {
+		s.wstats.shed.Add(1)
+		return false, nil
+	}
+	defer s.limit.release()
+	return true, s.commit(fn)
+}
+
+// writeOnce runs fn like write in a transaction of its own, never batched,
+// for fn with side effects which must not run again.
+func (s *BoltStore) writeOnce(fn func(*bolt.Tx) error) error {
+	if !s.limit.acquire(false) {
+		s.wstats.overloaded.Add(1)
+		return ErrOverloaded
+	}
+	defer s.limit.release()
+	start := s.wstats.begin()
+	err := s.db.Update(fn)
+	s.wstats.end(start, err)
+	return err
+}
+
+// commit runs fn in a write transaction of the durability profile.
+func (s *BoltStore) commit(fn func(*bolt.Tx) error) error {
+	start := s.wstats.begin()
+	var err error
+	if s.options.Durability == ProfileDurable {
+		err = s.db.Update(fn)
+	} else {
+		err = s.db.Batch(fn)
+	}
+	s.wstats.end(start, err)
+	return err
+}
+
+// syncer fsyncs the db every interval until ctx is done.
+// NoSync is set on the db, affecting all its users.
+func (s *BoltStore) syncer(ctx context.Context, interval time.Duration) {
+	defer s.bg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.db.Sync(); err != nil {
+				log.Printf("boltstore: sync db error: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package boltstore
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDurabilityProfiles(t *testing.T) {
+	for _, p := range []Profile{ProfileDurable, ProfileBalanced, ProfileThroughput} {
+		store := newTestStore(t, Options{Durability: p})
+		if store.DB().NoSync != (p == ProfileThroughput) {
+			t.Errorf("Profile %d: unexpected NoSync %v", p, store.DB().NoSync)
+		}
+		var wg sync.WaitGroup
+		cookies := make([]string, 10)
+		for i := range cookies {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				cookies[i] = saveNew(t, store, "session-key", map[interface{}]interface{}{"n": i})
+			}(i)
+		}
+		wg.Wait()
+		for i, cookie := range cookies {
+			if _, session := loadCookie(t, store, "session-key", cookie); session.Values["n"] != i {
+				t.Errorf("Profile %d: expected saved session %d; Got %v", p, i, session.Values)
+			}
+		}
+	}
+}
+
+func TestNoSync(t *testing.T) {
+	store := newTestStore(t, Options{NoSync: true, SyncInterval: time.Millisecond})
+	if !store.DB().NoSync {
+		t.Fatal("Expected NoSync db")
+	}
+	cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 1})
+	time.Sleep(5 * time.Millisecond) // a few syncs
+	path := store.DB().Path()
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewStore(context.Background(), path, Options{KeyPairs: [][]byte{[]byte("secret-key")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if _, session := loadCookie(t, store, "session-key", cookie); session.Values["n"] != 1 {
+		t.Errorf("Expected synced session; Got %v", session.Values)
+	}
+}
+
+func TestBatchWindow(t *testing.T) {
+	store := newTestStore(t, Options{Durability: ProfileBalanced, MaxBatchSize: 4, MaxBatchDelay: 100 * time.Microsecond})
+	if store.DB().MaxBatchSize != 4 || store.DB().MaxBatchDelay != 100*time.Microsecond {
+		t.Errorf("Expected batch window; Got %d %v", store.DB().MaxBatchSize, store.DB().MaxBatchDelay)
+	}
+	saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 1})
+}
+
+func BenchmarkBatchWindow(b *testing.B) {
+	for _, delay := range []time.Duration{0, 100 * time.Microsecond} {
+		b.Run(delay.String(), func(b *testing.B) {
+			store := newTestStore(b, Options{Durability: ProfileBalanced, MaxBatchDelay: delay})
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					saveNew(b, store, "session-key", map[interface{}]interface{}{"n": 1})
+				}
+			})
+		})
+	}
+}
+
+func TestCloseDrainsWrites(t *testing.T) {
+	store := newTestStore(t, Options{Durability: ProfileBalanced, MaxBatchDelay: 50 * time.Millisecond, ReapCheckInterval: time.Millisecond})
+	var wg sync.WaitGroup
+	errs := make([]error, 10)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			req, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+			session, err := store.New(req, "session-key")
+			if err == nil {
+				session.Values["n"] = i
+				err = store.Save(req, NewRecorder(), session)
+			}
+			errs[i] = err
+		}(i)
+	}
+	time.Sleep(10 * time.Millisecond) // saves wait for the batch to fill
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("Save %d: expected to finish before close; Got %v", i, err)
+		}
+	}
+}
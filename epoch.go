@@ -0,0 +1,112 @@
+package boltstore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/gorilla/sessions"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Sessions are stamped with the current revocation epoch when they are
+// created or their user changes. Revoking bumps the epoch and records it as
+// the epoch older sessions, of everyone or of one user, are revoked before,
+// so revoked sessions are rejected on load without scanning the store.
+
+// RevokeAll revokes all stored sessions: they are loaded as new sessions from
+// now on and removed by the reaper with their expiry. Sessions saved later are
+// not affected.
+func (s *BoltStore) RevokeAll(ctx context.Context) error {
+	return s.revoke(ctx, func(control *bolt.Bucket, epoch []byte) error {
+		return control.Put(keyRevoked, epoch)
+	})
+}
+
+// RevokeUser revokes all stored sessions of a user, identified by the
+// Options.UserKey session value, for example on password change. Sessions of
+// the user saved later are not affected. Users are compared by the value
+// formatted with fmt.Sprint.
+func (s *BoltStore) RevokeUser(ctx context.Context, user string) error {
+	return s.revoke(ctx, func(control *bolt.Bucket, epoch []byte) error {
+		users, err := control.CreateBucketIfNotExists(keyUsers)
+		if err != nil {
+			return fmt.Errorf("create users bucket error: %w", err)
+		}
+		return users.Put([]byte(user), epoch)
+	})
+}
+
+// revoke bumps the epoch and calls fn to record it as revoked.
+func (s *BoltStore) revoke(ctx context.Context, fn func(control *bolt.Bucket, epoch []byte) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := s.enter(); err != nil {
+		return err
+	}
+	defer s.leave()
+	err := s.write(func(tx *bolt.Tx) error {
+		control := tx.Bucket(controlBucketName(s.options.BucketName))
+		epoch := encodeUint(decodeUint(control.Get(keyEpoch)) + 1)
+		if err := control.Put(keyEpoch, epoch); err != nil {
+			return fmt.Errorf("put revocation epoch error: %w", err)
+		}
+		return fn(control, epoch)
+	})
+	// cached sessions are not checked, let them be read again
+	if s.loads != nil {
+		s.loads.purge()
+	}
+	if s.cache != nil {
+		s.cache.purge()
+	}
+	return s.traced(ctx, err)
+}
+
+// userOf returns the user of session, empty without Options.UserKey value.
+func (s *BoltStore) userOf(session *sessions.Session) []byte {
+	if s.options.UserKey == "" {
+		return nil
+	}
+	v, ok := session.Values[s.options.UserKey]
+	if !ok || v == nil {
+		return nil
+	}
+	return []byte(fmt.Sprint(v))
+}
+
+// stampTx stores the current epoch in session bucket b if the session is
+// new or its user changed, within transaction tx.
+func (s *BoltStore) stampTx(tx *bolt.Tx, b *bolt.Bucket, user []byte, created bool) error {
+	if !created && bytes.Equal(b.Get(keyUser), user) {
+		return nil
+	}
+	epoch := tx.Bucket(controlBucketName(s.options.BucketName)).Get(keyEpoch)
+	if epoch == nil {
+		epoch = encodeUint(0)
+	}
+	if err := b.Put(keyEpoch, epoch); err != nil {
+		return fmt.Errorf("put session epoch error: %w", err)
+	}
+	if user == nil {
+		return b.Delete(keyUser)
+	}
+	return b.Put(keyUser, user)
+}
+
+// revokedTx reports whether the session stored in bucket b is revoked,
+// within transaction tx.
+func (s *BoltStore) revokedTx(tx *bolt.Tx, b *bolt.Bucket) bool {
+	control := tx.Bucket(controlBucketName(s.options.BucketName))
+	epoch := decodeUint(b.Get(keyEpoch))
+	if epoch < decodeUint(control.Get(keyRevoked)) {
+		return true
+	}
+	user := b.Get(keyUser)
+	if user == nil {
+		return false
+	}
+	users := control.Bucket(keyUsers)
+	return users != nil && epoch < decodeUint(users.Get(user))
+}
@@ -0,0 +1,54 @@
+package boltstore
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRevoke(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t, Options{UserKey: "user", CacheSize: 10})
+	alice := saveNew(t, store, "session-key", map[interface{}]interface{}{"user": "alice"})
+	bob := saveNew(t, store, "session-key", map[interface{}]interface{}{"user": "bob"})
+	anon := saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 1})
+	loadCookie(t, store, "session-key", alice) // cached
+
+	if err := store.RevokeUser(ctx, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if _, session := loadCookie(t, store, "session-key", alice); !session.IsNew {
+		t.Error("Expected revoked session of alice")
+	}
+	if _, session := loadCookie(t, store, "session-key", bob); session.IsNew {
+		t.Error("Expected session of bob")
+	}
+
+	// sessions of alice created or logged in after the revocation are valid
+	again := saveNew(t, store, "session-key", map[interface{}]interface{}{"user": "alice"})
+	req, session := loadCookie(t, store, "session-key", anon)
+	session.Values["user"] = "alice"
+	if err := store.Save(req, NewRecorder(), session); err != nil {
+		t.Fatal(err)
+	}
+	for _, cookie := range []string{again, anon} {
+		if _, session := loadCookie(t, store, "session-key", cookie); session.IsNew {
+			t.Error("Expected session of alice after revocation")
+		}
+	}
+
+	if err := store.RevokeAll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for _, cookie := range []string{bob, again, anon} {
+		if _, session := loadCookie(t, store, "session-key", cookie); !session.IsNew {
+			t.Errorf("Expected revoked session; Got %v", session.Values)
+		}
+	}
+	if store.Exists(session.ID) {
+		t.Error("Expected revoked session not to exist")
+	}
+	cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"user": "bob"})
+	if _, session := loadCookie(t, store, "session-key", cookie); session.IsNew {
+		t.Error("Expected session saved after revocation")
+	}
+}
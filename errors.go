@@ -0,0 +1,78 @@
+package boltstore
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNotFound is returned when there is no stored session with given ID.
+	ErrNotFound = errors.New("session not found")
+
+	// ErrConflict is returned by Save when Options.OptimisticLocking is enabled
+	// and the stored session was changed since it was loaded.
+	ErrConflict = errors.New("session was modified concurrently")
+
+	// ErrOverloaded is returned by writes when Options.MaxWriters writes are
+	// already in progress.
+	ErrOverloaded = errors.New("too many concurrent session writes")
+
+	// ErrStoreClosed is returned by methods of a store after Close.
+	ErrStoreClosed = errors.New("session store closed")
+
+	// ErrRateLimited is returned by Save of a new session when its client
+	// created Options.MaxNewPerIP sessions within the last minute.
+	ErrRateLimited = errors.New("too many new sessions of the client")
+
+	// ErrBlobTooLarge is returned by AttachBlob when the blob is longer than
+	// Options.MaxBlobSize.
+	ErrBlobTooLarge = errors.New("blob too large")
+)
+
+// CorruptRecordError is returned on load of a stored session which can't be
+// deserialized, see Options.SkipCorrupt.
+type CorruptRecordError struct {
+	ID  string // session ID, redacted with Options.RedactIDs
+	Err error  // serializer error
+}
+
+func (e *CorruptRecordError) Error() string {
+	return fmt.Sprintf("corrupted session record %q: %v", e.ID, e.Err)
+}
+
+func (e *CorruptRecordError) Unwrap() error {
+	return e.Err
+}
+
+// SessionLimitError is returned by Save with LimitReject when the user of
+// a session already has Options.MaxUserSessions active sessions.
+type SessionLimitError struct {
+	User  string
+	Limit int
+}
+
+func (e *SessionLimitError) Error() string {
+	return fmt.Sprintf("user %q has %d active sessions", e.User, e.Limit)
+}
+
+// LayoutError is returned on store construction when the on-disk layout
+// is newer than the package supports, or older with Options.NoMigrate, see
+// MigrateLayout.
+type LayoutError struct {
+	Stored  uint64 // layout version of the db
+	Current uint64 // layout version of the package
+}
+
+func (e *LayoutError) Error() string {
+	return fmt.Sprintf("sessions layout version %d, expected %d", e.Stored, e.Current)
+}
+
+// BucketConflictError is returned on store construction when another store
+// on the same bolt.DB already uses one of its buckets.
+type BucketConflictError struct {
+	Bucket string
+}
+
+func (e *BucketConflictError) Error() string {
+	return fmt.Sprintf("bucket %q is used by another store on the same db", e.Bucket)
+}
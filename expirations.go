@@ -0,0 +1,57 @@
+package boltstore
+
+import "context"
+
+// expirationsBuffer is the count of IDs an Expirations channel buffers
+// before dropping the oldest ones.
+const expirationsBuffer = 128
+
+// Expirations returns a channel receiving IDs of sessions removed by the
+// reaper, so per-session server state can be torn down promptly. The channel
+// buffers the latest IDs, the oldest ones are dropped if it is not drained in
+// time. It is closed when ctx is done or the store is closed.
+func (s *BoltStore) Expirations(ctx context.Context) <-chan string {
+	ch := make(chan string, expirationsBuffer)
+	if s.enter() != nil {
+		close(ch)
+		return ch
+	}
+	defer s.leave()
+	s.hooks.mu.Lock()
+	s.hooks.expire = append(s.hooks.expire, ch)
+	s.hooks.mu.Unlock()
+
+	s.bg.Add(1)
+	go func(done <-chan struct{}) {
+		defer s.bg.Done()
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
+		s.hooks.mu.Lock()
+		defer s.hooks.mu.Unlock()
+		for i, c := range s.hooks.expire {
+			if c == ch {
+				s.hooks.expire = append(s.hooks.expire[:i], s.hooks.expire[i+1:]...)
+				break
+			}
+		}
+		close(ch)
+	}(s.ctx.Done())
+	return ch
+}
+
+// sendExpired passes id to ch, dropping the oldest buffered ID if ch is full.
+func sendExpired(ch chan string, id string) {
+	for {
+		select {
+		case ch <- id:
+			return
+		default:
+		}
+		select {
+		case <-ch:
+		default:
+		}
+	}
+}
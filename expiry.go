@@ -0,0 +1,101 @@
+package boltstore
+
+import (
+	"bytes"
+	"encoding/binary"
+	"time"
+)
+
+// expiryLen is the size of binary encoded expiration time.
+const expiryLen = 8
+
+// encodeExpiry formats session expiration time for storing, as big-endian
+// unix seconds. Times before the epoch are stored as the epoch.
+//
+// Older versions stored decimal unix seconds, which are still decoded. Binary
+// values are told apart by their zero first byte, an ASCII digit or sign
+// never is.
+func encodeExpiry(t time.Time) []byte {
+	// the slice is kept by bolt until commit, so it can't be pooled
+	b := make([]byte, expiryLen)
+	binary.BigEndian.PutUint64(b, expiryUnix(t))
+	return b
+}
+
+// expiryUnix returns unix seconds of t clamped to the epoch.
+func expiryUnix(t time.Time) uint64 {
+	if sec := t.Unix(); sec > 0 {
+		return uint64(sec)
+	}
+	return 0
+}
+
+// isBinaryExpiry reports whether stored value b is in binary format.
+func isBinaryExpiry(b []byte) bool {
+	return len(b) == expiryLen && b[0] == 0
+}
+
+// decodeExpiry parses stored session expiration time.
+// returns false if the value is absent or malformed.
+func decodeExpiry(b []byte) (time.Time, bool) {
+	if b == nil {
+		return time.Time{}, false
+	}
+	if isBinaryExpiry(b) {
+		return time.Unix(int64(binary.BigEndian.Uint64(b)), 0), true
+	}
+	expiredAt, ok := parseInt(b)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(expiredAt, 0), true
+}
+
+// parseInt parses a decimal int64 without converting b to a string.
+func parseInt(b []byte) (int64, bool) {
+	neg := len(b) > 0 && b[0] == '-'
+	if neg {
+		b = b[1:]
+	}
+	if len(b) == 0 || len(b) > 18 {
+		// longer values are not produced by encodeExpiry for sane times
+		return 0, false
+	}
+	var n int64
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+		n = n*10 + int64(c-'0')
+	}
+	if neg {
+		n = -n
+	}
+	return n, true
+}
+
+// isExpired reports whether stored expiration value b has passed,
+// absent or malformed values are treated as expired.
+func isExpired(b []byte, now time.Time) bool {
+	if isBinaryExpiry(b) {
+		// compared encoded, no parsing in the reaper scan loop. now is
+		// rounded up to whole seconds, so an expiry earlier within the
+		// current second counts as expired
+		var n [expiryLen]byte
+		binary.BigEndian.PutUint64(n[:], expiryUnix(now.Add(time.Second-1)))
+		return bytes.Compare(b, n[:]) < 0
+	}
+	t, ok := decodeExpiry(b)
+	return !ok || t.Before(now)
+}
+
+// expiryNow returns the time session expiries are checked against, now less
+// the Options.ClockSkew tolerance.
+func (s *BoltStore) expiryNow() time.Time {
+	return skewedNow(s.options)
+}
+
+// skewedNow returns now less the Options.ClockSkew tolerance of opts.
+func skewedNow(opts Options) time.Time {
+	return time.Now().Add(-opts.ClockSkew)
+}
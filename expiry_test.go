@@ -0,0 +1,216 @@
+package boltstore
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// setExpiry overwrites stored expiration time of session id.
+func setExpiry(t *testing.T, store *BoltStore, id string, at time.Time) {
+	t.Helper()
+	err := store.DB().Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(store.options.BucketName).Bucket([]byte(id)).Put(keyExpiredAt, encodeExpiry(at))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadExpired(t *testing.T) {
+	store := newTestStore(t, Options{})
+	cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 1})
+	_, session := loadCookie(t, store, "session-key", cookie)
+	if session.IsNew {
+		t.Fatal("Expected stored session")
+	}
+
+	setExpiry(t, store, session.ID, time.Now().Add(-time.Minute))
+	_, session = loadCookie(t, store, "session-key", cookie)
+	if !session.IsNew || session.ID != "" || len(session.Values) != 0 {
+		t.Errorf("Expected expired session to be new; Got %v %q %v", session.IsNew, session.ID, session.Values)
+	}
+}
+
+func TestExpiredGrace(t *testing.T) {
+	store := newTestStore(t, Options{ExpiredGrace: time.Hour})
+	cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 1})
+	_, session := loadCookie(t, store, "session-key", cookie)
+	id := session.ID
+
+	at := time.Now().Add(-time.Minute).Truncate(time.Second)
+	setExpiry(t, store, id, at)
+	_, session = loadCookie(t, store, "session-key", cookie)
+	if !session.IsNew || session.Values["n"] != 1 {
+		t.Errorf("Expected new session with expired values; Got %v %v", session.IsNew, session.Values)
+	}
+	if expiredAt, ok := Expired(session); !ok || !expiredAt.Equal(at) {
+		t.Errorf("Expected expired at %v; Got %v %v", at, expiredAt, ok)
+	}
+
+	setExpiry(t, store, id, time.Now().Add(-2*time.Hour))
+	_, session = loadCookie(t, store, "session-key", cookie)
+	if _, ok := Expired(session); ok || len(session.Values) != 0 {
+		t.Errorf("Expected empty session after grace; Got %v", session.Values)
+	}
+}
+
+func TestDecodeExpiry(t *testing.T) {
+	at := time.Unix(1700000000, 0)
+	if got, ok := decodeExpiry(encodeExpiry(at)); !ok || !got.Equal(at) {
+		t.Errorf("Expected %v; Got %v %v", at, got, ok)
+	}
+	if got, ok := decodeExpiry([]byte("-5")); !ok || got.Unix() != -5 {
+		t.Errorf("Expected -5; Got %v %v", got.Unix(), ok)
+	}
+	for _, b := range []string{"", "-", "17x", "1234567890123456789"} {
+		if _, ok := decodeExpiry([]byte(b)); ok {
+			t.Errorf("Expected %q to be malformed", b)
+		}
+	}
+}
+
+func TestIsExpired(t *testing.T) {
+	at := time.Unix(1700000000, 0)
+	tests := []struct {
+		b       []byte
+		now     time.Time
+		expired bool
+	}{
+		{encodeExpiry(at), at.Add(-time.Second), false},
+		{encodeExpiry(at), at, false},
+		{encodeExpiry(at), at.Add(time.Millisecond), true},
+		{encodeExpiry(at), at.Add(time.Hour), true},
+		{encodeExpiry(time.Unix(-5, 0)), time.Unix(0, 1), true},
+		{[]byte{0, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, at, false},
+		{[]byte(strconv.FormatInt(at.Unix(), 10)), at.Add(time.Second), true},
+		{[]byte("17x"), at, true},
+	}
+	for _, tt := range tests {
+		if got := isExpired(tt.b, tt.now); got != tt.expired {
+			t.Errorf("isExpired(%v, %v): Expected %v; Got %v", tt.b, tt.now.Unix(), tt.expired, got)
+		}
+	}
+}
+
+func BenchmarkEncodeExpiry(b *testing.B) {
+	now := time.Now()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		encodeExpiry(now)
+	}
+}
+
+func BenchmarkIsExpired(b *testing.B) {
+	v := encodeExpiry(time.Now())
+	now := time.Now()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		isExpired(v, now)
+	}
+}
+
+func TestDecimalExpiry(t *testing.T) {
+	store := newTestStore(t, Options{})
+	cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 1})
+	_, session := loadCookie(t, store, "session-key", cookie)
+
+	// format of older versions
+	at := time.Now().Add(time.Hour).Truncate(time.Second)
+	err := store.DB().Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(store.options.BucketName).Bucket([]byte(session.ID)).Put(keyExpiredAt, []byte(strconv.FormatInt(at.Unix(), 10)))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, session = loadCookie(t, store, "session-key", cookie); session.IsNew {
+		t.Fatal("Expected session with decimal expiry")
+	}
+	if ttl, err := store.TTL(session.ID); err != nil || ttl <= 59*time.Minute {
+		t.Errorf("Expected TTL about an hour; Got %v, %v", ttl, err)
+	}
+	if len(encodeExpiry(at)) != 8 {
+		t.Errorf("Expected binary expiry; Got %q", encodeExpiry(at))
+	}
+	if !isExpired(encodeExpiry(at), at.Add(time.Millisecond)) || isExpired(encodeExpiry(at), at) {
+		t.Error("Unexpected binary expiry comparison")
+	}
+}
+
+func TestMaxLifetime(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t, Options{MaxLifetime: time.Hour, ExpiredGrace: time.Hour})
+	cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"user": "alice"})
+	req, session := loadCookie(t, store, "session-key", cookie)
+	if ttl, _ := store.TTL(session.ID); ttl > time.Hour {
+		t.Errorf("Expected expiry capped at the max lifetime; Got %v", ttl)
+	}
+	info, err := store.Info(session.ID)
+	if err != nil || time.Since(info.CreatedAt) > time.Minute {
+		t.Fatalf("Expected creation time; Got %+v, %v", info, err)
+	}
+
+	// created before the max lifetime, saved with a longer expiry
+	err = store.DB().Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(store.options.BucketName).Bucket([]byte(session.ID)).Put(keyCreatedAt, encodeExpiry(time.Now().Add(-2*time.Hour)))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(req, NewRecorder(), session); err != nil {
+		t.Fatal(err)
+	}
+	if _, session = loadCookie(t, store, "session-key", cookie); !session.IsNew || session.Values["user"] != nil {
+		t.Errorf("Expected a new session without values past the max lifetime; Got %v", session.Values)
+	}
+	if n, err := store.Reap(ctx); err != nil || n != 1 {
+		t.Errorf("Expected reaped session; Got %d, %v", n, err)
+	}
+}
+
+func TestClockSkew(t *testing.T) {
+	store := newTestStore(t, Options{ClockSkew: time.Minute})
+	cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 1})
+	_, session := loadCookie(t, store, "session-key", cookie)
+
+	// written by a host with the clock 30s behind
+	setExpiry(t, store, session.ID, time.Now().Add(-30*time.Second))
+	if _, session = loadCookie(t, store, "session-key", cookie); session.IsNew || !store.Exists(session.ID) {
+		t.Error("Expected session expired within the tolerance")
+	}
+	if n, err := store.Reap(context.Background()); err != nil || n != 0 {
+		t.Errorf("Expected no reaped sessions; Got %d, %v", n, err)
+	}
+
+	setExpiry(t, store, session.ID, time.Now().Add(-2*time.Minute))
+	if _, session = loadCookie(t, store, "session-key", cookie); !session.IsNew {
+		t.Error("Expected session expired beyond the tolerance")
+	}
+}
+
+func TestProvisionalExpire(t *testing.T) {
+	store := newTestStore(t, Options{ProvisionalExpire: 10 * time.Minute})
+	req, session := loadCookie(t, store, "session-key", "")
+	session.Values["n"] = 1
+	rsp := NewRecorder()
+	if err := store.Save(req, rsp, session); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(req, NewRecorder(), session); err != nil {
+		t.Fatal(err)
+	}
+	if ttl, _ := store.TTL(session.ID); ttl > 10*time.Minute {
+		t.Errorf("Expected provisional expiry of a new session; Got %v", ttl)
+	}
+
+	// the return visit promotes it
+	if _, session = loadCookie(t, store, "session-key", rsp.Header().Get("Set-Cookie")); session.IsNew {
+		t.Fatal("Expected provisional session loaded")
+	}
+	if ttl, _ := store.TTL(session.ID); ttl <= 10*time.Minute {
+		t.Errorf("Expected full expiry after the return visit; Got %v", ttl)
+	}
+}
@@ -0,0 +1,66 @@
+package boltstore
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// redacted replaces values of redacted keys in exports.
+const redacted = "[REDACTED]"
+
+// ExportOptions configures Export.
+type ExportOptions struct {
+	IncludeExpired bool      // export expired sessions the reaper has not removed yet
+	ExpiresAfter   time.Time // export only sessions expiring after this time (zero - any)
+	ExpiresBefore  time.Time // export only sessions expiring before this time (zero - any)
+	Redact         []string  // value keys replaced with "[REDACTED]"; raw data is omitted then
+}
+
+// Export streams sessions to w as newline-delimited JSON, one record in the
+// canonical format per line (see FORMAT.md). Records are read within a single
+// read transaction and written as they are read. Deleted sessions are never
+// exported. Values are redacted with registered RedactFuncs, raw data is
+// omitted if any are registered.
+func (s *BoltStore) Export(ctx context.Context, w io.Writer, opts ExportOptions) error {
+	if err := s.enter(); err != nil {
+		return err
+	}
+	defer s.leave()
+	now := time.Now()
+	enc := json.NewEncoder(w)
+	return s.db.View(func(tx *bolt.Tx) error {
+		return s.eachTx(ctx, tx, func(spec *bucketSpec, id []byte, b *bolt.Bucket) error {
+			if b.Get(keyValues) == nil || b.Get(keyDeletedUntil) != nil {
+				return nil
+			}
+			if !opts.IncludeExpired && isExpired(b.Get(keyExpiredAt), now) {
+				return nil
+			}
+			expiresAt, _ := decodeExpiry(b.Get(keyExpiredAt))
+			if !opts.ExpiresAfter.IsZero() && !expiresAt.After(opts.ExpiresAfter) {
+				return nil
+			}
+			if !opts.ExpiresBefore.IsZero() && !expiresAt.Before(opts.ExpiresBefore) {
+				return nil
+			}
+			rec, err := s.exportRecord(spec, id, b)
+			if err != nil {
+				return err
+			}
+			s.redactRecord(rec)
+			if len(opts.Redact) > 0 {
+				rec.Data = nil
+				for _, k := range opts.Redact {
+					if _, ok := rec.Values[k]; ok {
+						rec.Values[k] = redacted
+					}
+				}
+			}
+			return enc.Encode(rec)
+		})
+	})
+}
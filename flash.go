@@ -0,0 +1,104 @@
+package boltstore
+
+import (
+	"encoding/json"
+
+	"github.com/gorilla/sessions"
+)
+
+// typedFlashesKey is the default session values key of typed flashes.
+const typedFlashesKey = "_typed_flash"
+
+// Severity is a flash message level.
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeveritySuccess
+	SeverityWarning
+	SeverityError
+)
+
+// String returns the severity name, usable as a CSS class in templates.
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeveritySuccess:
+		return "success"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+	return "unknown"
+}
+
+// MarshalText encodes severity by name.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes severity name, unknown names are SeverityInfo.
+func (s *Severity) UnmarshalText(b []byte) error {
+	switch string(b) {
+	case "success":
+		*s = SeveritySuccess
+	case "warning":
+		*s = SeverityWarning
+	case "error":
+		*s = SeverityError
+	default:
+		*s = SeverityInfo
+	}
+	return nil
+}
+
+// Flash is a template friendly flash message.
+type Flash struct {
+	Severity Severity
+	Message  string
+}
+
+// AddFlash adds a typed flash message to the session. Flashes are stored
+// JSON encoded, so T needs no gob registration with any serializer.
+//
+// A single variadic argument is accepted, and it is optional: it defines
+// the flash key. If not defined "_typed_flash" is used by default.
+func AddFlash[T any](session *sessions.Session, value T, vars ...string) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	key := typedFlashesKey
+	if len(vars) > 0 {
+		key = vars[0]
+	}
+	session.AddFlash(string(b), key)
+	return nil
+}
+
+// Flashes returns and drops typed flash messages of the session. Flashes
+// which can't be decoded as T are skipped.
+//
+// A single variadic argument is accepted, and it is optional: it defines
+// the flash key. If not defined "_typed_flash" is used by default.
+func Flashes[T any](session *sessions.Session, vars ...string) []T {
+	key := typedFlashesKey
+	if len(vars) > 0 {
+		key = vars[0]
+	}
+	var flashes []T
+	for _, v := range session.Flashes(key) {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		var f T
+		if err := json.Unmarshal([]byte(s), &f); err != nil {
+			continue
+		}
+		flashes = append(flashes, f)
+	}
+	return flashes
+}
@@ -0,0 +1,90 @@
+package boltstore
+
+import (
+	"sync"
+	"time"
+)
+
+// loadCall is an in-flight or recently completed load.
+type loadCall struct {
+	wg      sync.WaitGroup
+	rec     *record
+	err     error
+	expires time.Time // zero while in flight
+}
+
+// loadGroup collapses concurrent loads of the same session ID into one and
+// shares the result with loads started within ttl after it completes.
+type loadGroup struct {
+	mu    sync.Mutex
+	calls map[string]*loadCall
+	ttl   time.Duration
+}
+
+func newLoadGroup(ttl time.Duration) *loadGroup {
+	return &loadGroup{
+		calls: make(map[string]*loadCall),
+		ttl:   ttl,
+	}
+}
+
+// do executes fn for id, unless there is a load of id in flight or
+// a shared result which is still fresh.
+func (g *loadGroup) do(id string, fn func() (*record, error)) (*record, error) {
+	g.mu.Lock()
+	if c, ok := g.calls[id]; ok {
+		if c.expires.IsZero() {
+			g.mu.Unlock()
+			c.wg.Wait()
+			return c.rec, c.err
+		}
+		if time.Now().Before(c.expires) {
+			g.mu.Unlock()
+			return c.rec, c.err
+		}
+		delete(g.calls, id)
+	}
+	c := &loadCall{}
+	c.wg.Add(1)
+	g.calls[id] = c
+	g.mu.Unlock()
+
+	c.rec, c.err = fn()
+	c.wg.Done()
+
+	g.mu.Lock()
+	if g.calls[id] == c {
+		// errors are not shared beyond the in-flight callers
+		if c.err != nil || g.ttl <= 0 {
+			delete(g.calls, id)
+		} else {
+			c.expires = time.Now().Add(g.ttl)
+			time.AfterFunc(g.ttl, func() { g.drop(id, c) })
+		}
+	}
+	g.mu.Unlock()
+	return c.rec, c.err
+}
+
+// forget drops the shared result for id, so the next load reads db.
+func (g *loadGroup) forget(id string) {
+	g.mu.Lock()
+	delete(g.calls, id)
+	g.mu.Unlock()
+}
+
+// drop removes call c of id if it was not replaced.
+func (g *loadGroup) drop(id string, c *loadCall) {
+	g.mu.Lock()
+	if g.calls[id] == c {
+		delete(g.calls, id)
+	}
+	g.mu.Unlock()
+}
+
+// purge drops all shared results.
+func (g *loadGroup) purge() {
+	g.mu.Lock()
+	g.calls = make(map[string]*loadCall)
+	g.mu.Unlock()
+}
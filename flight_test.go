@@ -0,0 +1,45 @@
+package boltstore
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLoadGroup(t *testing.T) {
+	g := newLoadGroup(time.Hour)
+	var calls int32
+	start := make(chan struct{})
+	fn := func() (*record, error) {
+		<-start
+		atomic.AddInt32(&calls, 1)
+		return &record{version: 1}, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if rec, _ := g.do("a", fn); rec == nil || rec.version != 1 {
+				t.Errorf("Expected shared record; Got %v", rec)
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(start)
+	wg.Wait()
+
+	// result is reused within ttl
+	g.do("a", fn)
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("Expected 1 load; Got %d", n)
+	}
+
+	g.forget("a")
+	g.do("a", fn)
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Errorf("Expected 2 loads after forget; Got %d", n)
+	}
+}
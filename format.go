@@ -0,0 +1,125 @@
+package boltstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// RecordFormat is the version of the canonical exported record format,
+// described in FORMAT.md. It is incremented on incompatible changes.
+const RecordFormat = 1
+
+// ExportedRecord is a session record in the canonical cross-language format.
+type ExportedRecord struct {
+	Format     int                    `json:"format"`
+	ID         string                 `json:"id"`
+	Bucket     string                 `json:"bucket"`
+	Serializer string                 `json:"serializer"`
+	Version    uint64                 `json:"version"`
+	ExpiresAt  time.Time              `json:"expires_at"`
+	Data       []byte                 `json:"data"`
+	Values     map[string]interface{} `json:"values,omitempty"`
+}
+
+// SerializerIdentifier is implemented by custom serializers to name their
+// format in exported records.
+type SerializerIdentifier interface {
+	SerializerID() string
+}
+
+// serializerID returns the exported record serializer name.
+func serializerID(ser SessionSerializer) string {
+	switch ser := ser.(type) {
+	case GobSerializer:
+		return "gob"
+	case JSONSerializer:
+		return "json"
+	case SerializerIdentifier:
+		return ser.SerializerID()
+	}
+	return "custom"
+}
+
+// exportRecord returns the canonical record of session id stored in bucket b
+// of spec.
+func (s *BoltStore) exportRecord(spec *bucketSpec, id []byte, b *bolt.Bucket) (*ExportedRecord, error) {
+	data := b.Get(keyValues)
+	expiresAt, _ := decodeExpiry(b.Get(keyExpiredAt))
+	rec := &ExportedRecord{
+		Format:     RecordFormat,
+		ID:         string(id),
+		Bucket:     string(spec.name),
+		Serializer: serializerID(spec.serial),
+		Version:    decodeUint(b.Get(keyVersion)),
+		ExpiresAt:  expiresAt.UTC(),
+		Data:       append([]byte{}, data...),
+	}
+
+	// decoded values are included if they can be represented as JSON object
+	session := s.newSession("", rec.ID)
+	if err := spec.serial.Deserialize(data, session); err != nil {
+		return nil, fmt.Errorf("deserialize session %q error: %w", s.safeID(rec.ID), err)
+	}
+	values := make(map[string]interface{}, len(session.Values))
+	for k, v := range session.Values {
+		ks, ok := k.(string)
+		if !ok {
+			return rec, nil
+		}
+		values[ks] = v
+	}
+	if _, err := json.Marshal(values); err == nil {
+		rec.Values = values
+	}
+	return rec, nil
+}
+
+// Snapshot writes all active sessions to w as a single JSON document:
+// {"format":1,"created_at":...,"records":[...]} with records in the
+// canonical format. Records are read within a single read transaction and
+// streamed, so the snapshot is consistent and not buffered in memory.
+func (s *BoltStore) Snapshot(ctx context.Context, w io.Writer) error {
+	if err := s.enter(); err != nil {
+		return err
+	}
+	defer s.leave()
+	now := time.Now()
+	_, err := fmt.Fprintf(w, `{"format":%d,"created_at":%q,"records":[`, RecordFormat, now.UTC().Format(time.RFC3339))
+	if err != nil {
+		return err
+	}
+	first := true
+	err = s.db.View(func(tx *bolt.Tx) error {
+		return s.eachTx(ctx, tx, func(spec *bucketSpec, id []byte, b *bolt.Bucket) error {
+			if s.activeBucket(tx, id, now) == nil {
+				return nil
+			}
+			rec, err := s.exportRecord(spec, id, b)
+			if err != nil {
+				return err
+			}
+			line, err := json.Marshal(rec)
+			if err != nil {
+				return err
+			}
+			if !first {
+				if _, err := io.WriteString(w, ",\n"); err != nil {
+					return err
+				}
+			}
+			first = false
+			_, err = w.Write(line)
+			return err
+		})
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "]}\n")
+	return err
+}
@@ -0,0 +1,84 @@
+package boltstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestSnapshot(t *testing.T) {
+	store := newTestStore(t, Options{})
+	saveNew(t, store, "session-key", map[interface{}]interface{}{"user": "bob"})
+	saveNew(t, store, "session-key", map[interface{}]interface{}{1: "non-string key"})
+
+	var buf bytes.Buffer
+	if err := store.Snapshot(context.Background(), &buf); err != nil {
+		t.Fatal(err)
+	}
+	var snapshot struct {
+		Format  int               `json:"format"`
+		Records []*ExportedRecord `json:"records"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &snapshot); err != nil {
+		t.Fatalf("Error decoding snapshot: %v\n%s", err, buf.String())
+	}
+	if snapshot.Format != RecordFormat || len(snapshot.Records) != 2 {
+		t.Fatalf("Expected 2 records; Got %s", buf.String())
+	}
+	var withValues int
+	for _, rec := range snapshot.Records {
+		if rec.Serializer != "gob" || rec.Version != 1 || len(rec.Data) == 0 {
+			t.Errorf("Unexpected record %+v", rec)
+		}
+		if rec.Values != nil {
+			withValues++
+			if rec.Values["user"] != "bob" {
+				t.Errorf("Expected decoded values; Got %v", rec.Values)
+			}
+		}
+	}
+	if withValues != 1 {
+		t.Errorf("Expected values of 1 record; Got %d", withValues)
+	}
+}
+
+func TestExport(t *testing.T) {
+	store := newTestStore(t, Options{})
+	saveNew(t, store, "session-key", map[interface{}]interface{}{"user": "bob", "token": "secret"})
+	cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"user": "eve"})
+	_, session := loadCookie(t, store, "session-key", cookie)
+	setExpiry(t, store, session.ID, time.Now().Add(-time.Minute))
+
+	export := func(opts ExportOptions) []*ExportedRecord {
+		var buf bytes.Buffer
+		if err := store.Export(context.Background(), &buf, opts); err != nil {
+			t.Fatal(err)
+		}
+		var recs []*ExportedRecord
+		dec := json.NewDecoder(&buf)
+		for dec.More() {
+			rec := &ExportedRecord{}
+			if err := dec.Decode(rec); err != nil {
+				t.Fatal(err)
+			}
+			recs = append(recs, rec)
+		}
+		return recs
+	}
+
+	recs := export(ExportOptions{Redact: []string{"token"}})
+	if len(recs) != 1 {
+		t.Fatalf("Expected 1 active record; Got %d", len(recs))
+	}
+	if recs[0].Values["token"] != redacted || recs[0].Values["user"] != "bob" || recs[0].Data != nil {
+		t.Errorf("Expected redacted record; Got %+v", recs[0])
+	}
+	if recs = export(ExportOptions{IncludeExpired: true}); len(recs) != 2 {
+		t.Errorf("Expected 2 records with expired; Got %d", len(recs))
+	}
+	if recs = export(ExportOptions{IncludeExpired: true, ExpiresBefore: time.Now()}); len(recs) != 1 || recs[0].Values["user"] != "eve" {
+		t.Errorf("Expected expired record only; Got %v", recs)
+	}
+}
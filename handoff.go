@@ -0,0 +1,110 @@
+package boltstore
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/sessions"
+	bolt "go.etcd.io/bbolt"
+)
+
+// keyHandoffs is the control bucket key of the bucket of handoff codes.
+var keyHandoffs = []byte("handoffs")
+
+// handoff is a stored handoff code.
+type handoff struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// IssueHandoff returns a one-time code valid for ttl which attaches the
+// saved session to another device or browser with RedeemHandoff, for QR
+// login and "continue on phone" flows. Codes are 8 characters long, so
+// redeem attempts should be rate limited; they are removed by the reaper
+// after they expire.
+func (s *BoltStore) IssueHandoff(session *sessions.Session, ttl time.Duration) (string, error) {
+	if session.ID == "" {
+		return "", errors.New("handoff of a session which is not saved")
+	}
+	if err := s.enter(); err != nil {
+		return "", err
+	}
+	defer s.leave()
+	var key [5]byte
+	if _, err := io.ReadFull(rand.Reader, key[:]); err != nil {
+		return "", fmt.Errorf("generate handoff code error: %w", err)
+	}
+	code := base32.StdEncoding.EncodeToString(key[:])
+	b, _ := json.Marshal(handoff{ID: session.ID, Name: session.Name(), ExpiresAt: time.Now().Add(ttl)})
+	err := s.write(func(tx *bolt.Tx) error {
+		handoffs, err := tx.Bucket(controlBucketName(s.options.BucketName)).CreateBucketIfNotExists(keyHandoffs)
+		if err != nil {
+			return fmt.Errorf("create handoffs bucket error: %w", err)
+		}
+		return handoffs.Put([]byte(code), b)
+	})
+	if err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// RedeemHandoff consumes a code of IssueHandoff and sets the cookie of its
+// session on w, so the device of request r shares the session. Returns
+// ErrNotFound if the code is unknown, expired or was already redeemed, or
+// the session is gone.
+func (s *BoltStore) RedeemHandoff(code string, r *http.Request, w http.ResponseWriter) (*sessions.Session, error) {
+	if err := s.enter(); err != nil {
+		return nil, err
+	}
+	defer s.leave()
+	var h handoff
+	err := s.write(func(tx *bolt.Tx) error {
+		handoffs := tx.Bucket(controlBucketName(s.options.BucketName)).Bucket(keyHandoffs)
+		if handoffs == nil {
+			return ErrNotFound
+		}
+		key := []byte(strings.ToUpper(strings.TrimSpace(code)))
+		b := handoffs.Get(key)
+		if b == nil {
+			return ErrNotFound
+		}
+		if err := json.Unmarshal(b, &h); err != nil {
+			return fmt.Errorf("decode handoff error: %w", err)
+		}
+		return handoffs.Delete(key)
+	})
+	if err == nil && !time.Now().Before(h.ExpiresAt) {
+		err = ErrNotFound
+	}
+	if err != nil {
+		return nil, s.traced(r.Context(), err)
+	}
+
+	session := s.newSession(h.Name, h.ID)
+	ok, err := s.load(r.Context(), session)
+	if err == nil && !ok {
+		err = ErrNotFound
+	}
+	if err != nil {
+		return nil, s.traced(r.Context(), err)
+	}
+	if err := s.setCookie(w, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// handoffExpired reports whether the stored handoff v expired at now.
+func handoffExpired(v []byte, now time.Time) bool {
+	var h handoff
+	return json.Unmarshal(v, &h) != nil || !now.Before(h.ExpiresAt)
+}
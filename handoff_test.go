@@ -0,0 +1,49 @@
+package boltstore
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestHandoff(t *testing.T) {
+	store := newTestStore(t, Options{})
+	cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"user": "alice"})
+	_, session := loadCookie(t, store, "session-key", cookie)
+	code, err := store.IssueHandoff(session, time.Minute)
+	if err != nil || len(code) != 8 {
+		t.Fatalf("Expected handoff code; Got %q, %v", code, err)
+	}
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	rsp := NewRecorder()
+	phone, err := store.RedeemHandoff(code, req, rsp)
+	if err != nil || phone.ID != session.ID || phone.Values["user"] != "alice" {
+		t.Fatalf("Expected handed off session; Got %v, %v", phone, err)
+	}
+	if _, got := loadCookie(t, store, "session-key", rsp.Header().Get("Set-Cookie")); got.ID != session.ID {
+		t.Errorf("Expected cookie of the session; Got %q", got.ID)
+	}
+	if _, err := store.RedeemHandoff(code, req, NewRecorder()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected redeemed code rejected; Got %v", err)
+	}
+
+	expired, _ := store.IssueHandoff(session, -time.Second)
+	if _, err := store.RedeemHandoff(expired, req, NewRecorder()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected expired code rejected; Got %v", err)
+	}
+	store.IssueHandoff(session, -time.Second)
+	if _, err := store.Reap(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	store.DB().View(func(tx *bolt.Tx) error {
+		if n := tx.Bucket(controlBucketName(store.options.BucketName)).Bucket(keyHandoffs).Stats().KeyN; n != 0 {
+			t.Errorf("Expected expired codes reaped; Got %d", n)
+		}
+		return nil
+	})
+}
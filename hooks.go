@@ -0,0 +1,116 @@
+package boltstore
+
+import (
+	"context"
+	"sync"
+)
+
+// Event is a kind of session change in the store.
+type Event int
+
+const (
+	EventSave   Event = iota + 1 // session was written
+	EventDelete                  // session was deleted
+	EventExpire                  // session was removed by the reaper
+)
+
+func (e Event) String() string {
+	switch e {
+	case EventSave:
+		return "save"
+	case EventDelete:
+		return "delete"
+	case EventExpire:
+		return "expire"
+	}
+	return "unknown"
+}
+
+// InvalidateFunc is called with the ID of a session changed in the store.
+type InvalidateFunc func(id string, ev Event)
+
+// Change describes a session changed in the store.
+type Change struct {
+	ID      string // redacted with Options.RedactIDs, see RedactID
+	Event   Event
+	TraceID string // trace ID of the operation, see TraceID
+}
+
+// hookList is a concurrency safe list of registered hooks.
+type hookList struct {
+	mu         sync.RWMutex
+	invalidate []InvalidateFunc
+	change     []func(Change)
+	redact     map[string]RedactFunc
+	errs       []func(error)
+	expire     []chan string // Expirations channels
+	decode     []func(DecodeFailure)
+}
+
+// OnInvalidate registers fn to be called after every session write, delete
+// and expiration, so caches of session-derived data can be invalidated.
+// Hooks are called synchronously and must not block.
+func (s *BoltStore) OnInvalidate(fn InvalidateFunc) {
+	s.hooks.mu.Lock()
+	s.hooks.invalidate = append(s.hooks.invalidate, fn)
+	s.hooks.mu.Unlock()
+}
+
+// OnChange registers fn to be called after every session write, delete and
+// expiration, like OnInvalidate, with the trace ID of the operation. With
+// Options.RedactIDs the change carries a redacted ID, use OnInvalidate
+// to get raw IDs.
+// Hooks are called synchronously and must not block.
+func (s *BoltStore) OnChange(fn func(Change)) {
+	s.hooks.mu.Lock()
+	s.hooks.change = append(s.hooks.change, fn)
+	s.hooks.mu.Unlock()
+}
+
+// OnError registers fn to be called with errors the store handled on its own
+// instead of returning them, such as corrupted records dropped with
+// Options.SkipCorrupt. Hooks are called synchronously and must not block.
+func (s *BoltStore) OnError(fn func(error)) {
+	s.hooks.mu.Lock()
+	s.hooks.errs = append(s.hooks.errs, fn)
+	s.hooks.mu.Unlock()
+}
+
+// reportError passes err to error hooks.
+func (s *BoltStore) reportError(err error) {
+	s.hooks.mu.RLock()
+	defer s.hooks.mu.RUnlock()
+	for _, fn := range s.hooks.errs {
+		fn(err)
+	}
+}
+
+// invalidate drops in-memory data of session id and notifies hooks
+// about the change done within ctx.
+func (s *BoltStore) invalidate(ctx context.Context, id string, ev Event) {
+	s.forget(id)
+	s.hooks.mu.RLock()
+	defer s.hooks.mu.RUnlock()
+	for _, fn := range s.hooks.invalidate {
+		fn(id, ev)
+	}
+	if ev == EventExpire {
+		for _, ch := range s.hooks.expire {
+			sendExpired(ch, id)
+		}
+	}
+	if len(s.hooks.change) == 0 {
+		return
+	}
+	c := Change{ID: s.safeID(id), Event: ev, TraceID: s.traceID(ctx)}
+	for _, fn := range s.hooks.change {
+		fn(c)
+	}
+}
+
+// hasInvalidateHooks reports whether any invalidation hooks are registered.
+func (s *BoltStore) hasInvalidateHooks() bool {
+	s.hooks.mu.RLock()
+	defer s.hooks.mu.RUnlock()
+	return len(s.hooks.invalidate) > 0 || len(s.hooks.change) > 0
+}
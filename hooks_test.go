@@ -0,0 +1,50 @@
+package boltstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gorilla/sessions"
+)
+
+func TestOnInvalidate(t *testing.T) {
+	store := newTestStore(t, Options{})
+	var events []Event
+	store.OnInvalidate(func(id string, ev Event) {
+		events = append(events, ev)
+	})
+
+	cookie := saveNew(t, store, "session-key", nil)
+	req, session := loadCookie(t, store, "session-key", cookie)
+	err := store.UpdateByID(context.Background(), session.ID, func(*sessions.Session) error { return nil })
+	if err != nil {
+		t.Fatalf("Error updating session: %v", err)
+	}
+	session.Options.MaxAge = -1
+	if err := store.Save(req, NewRecorder(), session); err != nil {
+		t.Fatalf("Error deleting session: %v", err)
+	}
+
+	want := []Event{EventSave, EventSave, EventDelete}
+	if len(events) != len(want) {
+		t.Fatalf("Expected %v; Got %v", want, events)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("Expected %v; Got %v", want, events)
+		}
+	}
+}
+
+func TestOnNew(t *testing.T) {
+	var calls int
+	store := newTestStore(t, Options{OnNew: func(session *sessions.Session) {
+		calls++
+		session.Values["locale"] = "en"
+	}})
+	cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 1})
+	_, session := loadCookie(t, store, "session-key", cookie)
+	if calls != 1 || session.Values["locale"] != "en" {
+		t.Errorf("Expected seeded stored session and a single call; Got %v after %d calls", session.Values, calls)
+	}
+}
@@ -0,0 +1,105 @@
+package boltstore
+
+import (
+	"bytes"
+	"sync"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// dbHost tracks stores sharing a single bolt.DB.
+type dbHost struct {
+	reapMu sync.Mutex // serializes reaping passes of all stores on the DB
+	stores map[*BoltStore]struct{}
+}
+
+// hosts are all bolt.DB used by stores in the process.
+var hosts = struct {
+	mu sync.Mutex
+	m  map[*bolt.DB]*dbHost
+}{m: make(map[*bolt.DB]*dbHost)}
+
+// bucketNames returns names of all buckets owned by the store.
+func (s *BoltStore) bucketNames() [][]byte {
+	names := [][]byte{controlBucketName(s.options.BucketName), auditBucketName(s.options.BucketName)}
+	for _, spec := range s.buckets {
+		names = append(names, spec.name)
+	}
+	return names
+}
+
+// register adds the store to the host of its DB, checking that no other store
+// on the same DB uses any of its buckets.
+func (s *BoltStore) register() error {
+	hosts.mu.Lock()
+	defer hosts.mu.Unlock()
+	h, ok := hosts.m[s.db]
+	if !ok {
+		h = &dbHost{stores: make(map[*BoltStore]struct{})}
+		hosts.m[s.db] = h
+	}
+	if err := h.conflict(s, s.bucketNames()); err != nil {
+		return err
+	}
+	h.stores[s] = struct{}{}
+	s.host = h
+	return nil
+}
+
+// conflict returns a *BucketConflictError if a store on the DB other than s
+// uses any of buckets names. hosts.mu must be held.
+func (h *dbHost) conflict(s *BoltStore, names [][]byte) error {
+	for other := range h.stores {
+		if other == s {
+			continue
+		}
+		for _, a := range other.bucketNames() {
+			for _, b := range names {
+				if bytes.Equal(a, b) {
+					return &BucketConflictError{Bucket: string(b)}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkBuckets returns a *BucketConflictError if another store on the DB
+// uses any of buckets names.
+func (s *BoltStore) checkBuckets(names [][]byte) error {
+	hosts.mu.Lock()
+	defer hosts.mu.Unlock()
+	h, ok := hosts.m[s.db]
+	if !ok {
+		return nil
+	}
+	return h.conflict(s, names)
+}
+
+// unregister removes the store from the host of its DB.
+// returns true if it was the last store using the DB.
+func (s *BoltStore) unregister() bool {
+	hosts.mu.Lock()
+	defer hosts.mu.Unlock()
+	h, ok := hosts.m[s.db]
+	if !ok {
+		return false
+	}
+	if _, ok := h.stores[s]; !ok {
+		return false
+	}
+	delete(h.stores, s)
+	if len(h.stores) > 0 {
+		return false
+	}
+	delete(hosts.m, s.db)
+	return true
+}
+
+// shared returns true if the db of the store is used by other stores.
+func (s *BoltStore) shared() bool {
+	hosts.mu.Lock()
+	defer hosts.mu.Unlock()
+	_, ok := hosts.m[s.db]
+	return ok
+}
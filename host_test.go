@@ -0,0 +1,49 @@
+package boltstore
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestSharedDB(t *testing.T) {
+	ctx := context.Background()
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "test.db"), 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := Options{KeyPairs: [][]byte{[]byte("secret-key")}}
+
+	a, err := NewStoreWithDB(ctx, db, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var conflict *BucketConflictError
+	if _, err := NewStoreWithDB(ctx, db, opts); !errors.As(err, &conflict) {
+		t.Fatalf("Expected BucketConflictError; Got %v", err)
+	}
+	opts.BucketName = []byte("other")
+	b, err := NewStoreWithDB(ctx, db, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// migrations don't move buckets of other stores
+	if err := a.MigrateBucket(ctx, []byte("sessions"), []byte("other")); !errors.As(err, &conflict) {
+		t.Errorf("Expected BucketConflictError migrating to a bucket of another store; Got %v", err)
+	}
+	if err := a.MigrateBucket(ctx, []byte("other"), []byte("sessions")); !errors.As(err, &conflict) {
+		t.Errorf("Expected BucketConflictError migrating from a bucket of another store; Got %v", err)
+	}
+
+	a.Close()
+	saveNew(t, b, "session-key", nil) // db is still open
+	b.Close()
+	if err := db.View(func(*bolt.Tx) error { return nil }); err == nil {
+		t.Error("Expected db to be closed with the last store")
+	}
+}
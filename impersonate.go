@@ -0,0 +1,101 @@
+package boltstore
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/sessions"
+	bolt "go.etcd.io/bbolt"
+)
+
+// impersonatorKey is the session values key of the admin session an
+// impersonation session was started from, stored JSON encoded.
+const impersonatorKey = "_impersonator"
+
+// impersonator is the admin of an impersonation session.
+type impersonator struct {
+	User    string `json:"user"`
+	Session string `json:"session"`
+}
+
+// Impersonate starts a session of user for the admin logged in to session
+// admin, replacing the admin session cookie on w. The admin session is kept
+// stored and is restored by EndImpersonation; it is not saved again during
+// the request, so its cookie doesn't replace the impersonation one. Requires
+// Options.UserKey, both transitions are recorded in the audit log within
+// their transactions, see AuditLog.
+func (s *BoltStore) Impersonate(r *http.Request, w http.ResponseWriter, admin *sessions.Session, user string) (*sessions.Session, error) {
+	actor := s.userOf(admin)
+	if actor == nil || admin.ID == "" {
+		return nil, errors.New("impersonation requires a saved session of a logged in user")
+	}
+	s.unlockSession(admin)
+	b, _ := json.Marshal(impersonator{User: string(actor), Session: admin.ID})
+	session := s.newSession(admin.Name(), "")
+	session.IsNew = true
+	session.Values[s.options.UserKey] = user
+	session.Values[impersonatorKey] = string(b)
+	setMeta(session, metaAudit, &AuditEntry{Event: "impersonation_start", Actor: string(actor), User: user})
+	if err := s.Save(r, w, session); err != nil {
+		return nil, err
+	}
+	setMeta(admin, metaRetired, true)
+	return session, nil
+}
+
+// EndImpersonation deletes the impersonation session and restores the
+// admin session it was started from, setting its cookie on w. Returns
+// ErrNotFound if the admin session is gone, the admin has to log in again.
+func (s *BoltStore) EndImpersonation(r *http.Request, w http.ResponseWriter, session *sessions.Session) (*sessions.Session, error) {
+	imp, ok := impersonatorOf(session)
+	if !ok {
+		return nil, errors.New("session is not an impersonation")
+	}
+	if err := s.enter(); err != nil {
+		return nil, err
+	}
+	defer s.leave()
+	entry := AuditEntry{Event: "impersonation_end", Actor: imp.User, User: string(s.userOf(session)), Session: session.ID}
+	err := s.write(func(tx *bolt.Tx) error {
+		if bucket, spec := s.findTx(tx, []byte(session.ID), s.bucketOf(session.Name())); bucket != nil {
+			if err := s.deleteTx(tx, bucket, spec, session.ID); err != nil {
+				return err
+			}
+		}
+		return s.auditTx(tx, entry)
+	})
+	s.unlockSession(session)
+	if err != nil {
+		s.forget(session.ID)
+		return nil, s.traced(r.Context(), err)
+	}
+	s.invalidate(r.Context(), session.ID, EventDelete)
+	setMeta(session, metaRetired, true)
+	admin := s.newSession(session.Name(), imp.Session)
+	ok, err = s.load(r.Context(), admin)
+	if err == nil && !ok {
+		err = ErrNotFound
+	}
+	if err != nil {
+		return nil, s.traced(r.Context(), err)
+	}
+	return admin, s.setCookie(w, admin)
+}
+
+// Impersonator returns the admin user who started the impersonation
+// session with Impersonate.
+func Impersonator(session *sessions.Session) (string, bool) {
+	imp, ok := impersonatorOf(session)
+	return imp.User, ok
+}
+
+// impersonatorOf returns the admin of an impersonation session.
+func impersonatorOf(session *sessions.Session) (impersonator, bool) {
+	var imp impersonator
+	s, ok := session.Values[impersonatorKey].(string)
+	if !ok || json.Unmarshal([]byte(s), &imp) != nil {
+		return imp, false
+	}
+	return imp, true
+}
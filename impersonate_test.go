@@ -0,0 +1,73 @@
+package boltstore
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestImpersonate(t *testing.T) {
+	store := newTestStore(t, Options{UserKey: "user"})
+	cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"user": "admin"})
+	req, admin := loadCookie(t, store, "session-key", cookie)
+
+	rsp := NewRecorder()
+	session, err := store.Impersonate(req, rsp, admin, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the replaced admin session is not saved again
+	if err := store.Save(req, rsp, admin); err != nil || len(rsp.Header()["Set-Cookie"]) != 1 {
+		t.Fatalf("Expected only the impersonation cookie; Got %v, %v", rsp.Header()["Set-Cookie"], err)
+	}
+	req, session = loadCookie(t, store, "session-key", rsp.Header().Get("Set-Cookie"))
+	if by, ok := Impersonator(session); !ok || by != "admin" || session.Values["user"] != "alice" {
+		t.Fatalf("Expected impersonation of alice by admin; Got %v", session.Values)
+	}
+
+	rsp = NewRecorder()
+	restored, err := store.EndImpersonation(req, rsp, session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.ID != admin.ID || store.Exists(session.ID) {
+		t.Error("Expected admin session restored and impersonation deleted")
+	}
+	if _, got := loadCookie(t, store, "session-key", rsp.Header().Get("Set-Cookie")); got.Values["user"] != "admin" {
+		t.Errorf("Expected admin session cookie; Got %v", got.Values)
+	}
+
+	var events []string
+	err = store.AuditLog(context.Background(), func(e AuditEntry) error {
+		if e.Actor != "admin" || e.User != "alice" || e.Session != session.ID {
+			t.Errorf("Unexpected audit entry %+v", e)
+		}
+		events = append(events, e.Event)
+		return nil
+	})
+	if err != nil || len(events) != 2 || events[0] != "impersonation_start" || events[1] != "impersonation_end" {
+		t.Errorf("Expected audited transitions; Got %v, %v", events, err)
+	}
+
+	if err := store.DeleteAll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	events = nil
+	store.AuditLog(context.Background(), func(e AuditEntry) error {
+		events = append(events, e.Event)
+		return nil
+	})
+	if len(events) != 2 {
+		t.Errorf("Expected audit log kept by DeleteAll; Got %v", events)
+	}
+
+	cookie = saveNew(t, store, "session-key", map[interface{}]interface{}{"user": "admin"})
+	_, plain := loadCookie(t, store, "session-key", cookie)
+	if _, err := store.EndImpersonation(req, NewRecorder(), plain); err == nil {
+		t.Error("Expected error ending a session which is not an impersonation")
+	}
+	anon, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	if _, err := store.Impersonate(anon, NewRecorder(), store.newSession("session-key", ""), "alice"); err == nil {
+		t.Error("Expected error impersonating without a logged in session")
+	}
+}
@@ -0,0 +1,167 @@
+package boltstore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// importTx stores serialized data b of session id of user with given
+// expiration time into sessions bucket spec within transaction tx, unless the
+// session is already stored. returns false if the session was skipped.
+func (s *BoltStore) importTx(tx *bolt.Tx, spec *bucketSpec, id string, b []byte, expiresAt time.Time, user []byte) (bool, error) {
+	if root, _ := s.findTx(tx, []byte(id), spec); root != nil {
+		return false, nil
+	}
+	root, err := spec.writeTx(tx).CreateBucket([]byte(id))
+	if err != nil {
+		return false, fmt.Errorf("create session bucket error: %w", err)
+	}
+	if err := root.Put(keyVersion, encodeUint(1)); err != nil {
+		return false, err
+	}
+	if err := root.Put(keyValues, b); err != nil {
+		return false, err
+	}
+	if err := root.Put(keyExpiredAt, encodeExpiry(expiresAt)); err != nil {
+		return false, err
+	}
+	if err := root.Put(keyCreatedAt, encodeExpiry(time.Now())); err != nil {
+		return false, err
+	}
+	if err := s.stampTx(tx, root, user, true); err != nil {
+		return false, err
+	}
+	return true, s.addCount(tx, 1)
+}
+
+// replaceTx replaces serialized data, expiration time and user of the
+// session stored in bucket root with b, expiresAt and user within
+// transaction tx, bumping its version.
+func (s *BoltStore) replaceTx(tx *bolt.Tx, root *bolt.Bucket, b []byte, expiresAt time.Time, user []byte) error {
+	if err := root.Put(keyVersion, encodeUint(decodeUint(root.Get(keyVersion))+1)); err != nil {
+		return err
+	}
+	if err := root.Put(keyValues, b); err != nil {
+		return err
+	}
+	if err := root.Put(keyExpiredAt, encodeExpiry(expiresAt)); err != nil {
+		return err
+	}
+	return s.stampTx(tx, root, user, false)
+}
+
+// SessionRecord is a session written by ImportSessions.
+type SessionRecord struct {
+	ID        string                      // session ID, generated if empty
+	Name      string                      // session name, selecting the bucket it is stored in
+	Values    map[interface{}]interface{} // session values
+	Data      []byte                      // values serialized with the serializer of the bucket, used instead of Values, see ExportedRecord
+	ExpiresAt time.Time                   // expiration time, zero - the expire of the bucket from now
+}
+
+// ImportSessions writes sessions consumed from iter until it returns false,
+// in transactions of up to 1000 sessions, for seeding load tests, migrating
+// tenants or restoring exports without saving them one at a time. Sessions
+// already stored or expired are skipped. Records of a failed transaction are
+// not written. returns the number of imported sessions.
+func (s *BoltStore) ImportSessions(ctx context.Context, iter func() (*SessionRecord, bool)) (int, error) {
+	if err := s.enter(); err != nil {
+		return 0, err
+	}
+	defer s.leave()
+	return s.importSessions(ctx, iter, false)
+}
+
+// importSessions writes sessions of ImportSessions, replacing stored ones
+// if replace is set. returns the number of written sessions.
+func (s *BoltStore) importSessions(ctx context.Context, iter func() (*SessionRecord, bool), replace bool) (int, error) {
+	var (
+		n    int
+		done bool
+	)
+	for !done {
+		if err := ctx.Err(); err != nil {
+			return n, err
+		}
+		var batch []*SessionRecord
+		for len(batch) < importBatchSize {
+			rec, ok := iter()
+			if !ok {
+				done = true
+				break
+			}
+			batch = append(batch, rec)
+		}
+		var replaced []string
+		written := 0
+		err := s.write(func(tx *bolt.Tx) error {
+			now := time.Now()
+			replaced, written = replaced[:0], 0
+			for _, rec := range batch {
+				id, ok, err := s.importRecordTx(tx, rec, now, replace)
+				if err != nil {
+					return err
+				}
+				if ok {
+					written++
+				}
+				if ok && id != "" {
+					replaced = append(replaced, id)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return n, s.traced(ctx, err)
+		}
+		n += written
+		for _, id := range replaced {
+			s.forget(id)
+			s.invalidate(ctx, id, EventSave)
+		}
+	}
+	return n, nil
+}
+
+// importRecordTx writes the record imported at time now within transaction
+// tx, replacing the stored session if replace is set. returns false if it
+// was skipped, and the ID of the replaced session.
+func (s *BoltStore) importRecordTx(tx *bolt.Tx, rec *SessionRecord, now time.Time, replace bool) (string, bool, error) {
+	spec := s.bucketOf(rec.Name)
+	expiresAt := rec.ExpiresAt
+	if expiresAt.IsZero() {
+		expiresAt = now.Add(spec.expire)
+	} else if expiresAt.Before(now) {
+		return "", false, nil
+	}
+	id := rec.ID
+	if id == "" {
+		id = newSessionID()
+	}
+	session := s.newSession(rec.Name, id)
+	b := rec.Data
+	if b == nil {
+		for k, v := range rec.Values {
+			session.Values[k] = v
+		}
+		var err error
+		if b, err = encodeSession(spec.serial, session, spec.maxLength); err != nil {
+			return "", false, fmt.Errorf("session %q: %w", s.safeID(id), err)
+		}
+	} else if s.options.UserKey != "" {
+		// decoded for the user only
+		if err := spec.serial.Deserialize(b, session); err != nil {
+			return "", false, fmt.Errorf("deserialize session %q error: %w", s.safeID(id), err)
+		}
+	}
+	if replace {
+		if root, _ := s.findTx(tx, []byte(id), spec); root != nil {
+			return id, true, s.replaceTx(tx, root, b, expiresAt, s.userOf(session))
+		}
+	}
+	ok, err := s.importTx(tx, spec, id, b, expiresAt, s.userOf(session))
+	return "", ok, err
+}
@@ -0,0 +1,83 @@
+package boltstore
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gorilla/securecookie"
+	bolt "go.etcd.io/bbolt"
+)
+
+// ImportFilesystemStore imports sessions of the given name saved by gorilla
+// sessions.FilesystemStore in dir, decoding them with keyPairs the
+// FilesystemStore was created with. Session IDs are kept, so cookies stay
+// valid if this store uses the same key pairs. The expiration time is the
+// file modification time plus SessionExpire, files which fail to decode
+// (e.g. older than the securecookie max age) or already expired are skipped.
+// returns the number of imported sessions.
+func (s *BoltStore) ImportFilesystemStore(ctx context.Context, dir, name string, keyPairs ...[]byte) (int, error) {
+	if err := s.enter(); err != nil {
+		return 0, err
+	}
+	defer s.leave()
+	const prefix = "session_"
+	files, err := filepath.Glob(filepath.Join(dir, prefix+"*"))
+	if err != nil {
+		return 0, err
+	}
+	codecs := securecookie.CodecsFromPairs(keyPairs...)
+	spec := s.bucketOf(name)
+
+	var n int
+	for start := 0; start < len(files); start += importBatchSize {
+		if err := ctx.Err(); err != nil {
+			return n, err
+		}
+		end := start + importBatchSize
+		if end > len(files) {
+			end = len(files)
+		}
+		err := s.writeOnce(func(tx *bolt.Tx) error {
+			now := time.Now()
+			for _, fn := range files[start:end] {
+				fi, err := os.Stat(fn)
+				if err != nil {
+					return err
+				}
+				expiresAt := fi.ModTime().Add(spec.expire)
+				if fi.IsDir() || expiresAt.Before(now) {
+					continue
+				}
+				data, err := os.ReadFile(fn)
+				if err != nil {
+					return err
+				}
+				id := strings.TrimPrefix(filepath.Base(fn), prefix)
+				session := s.newSession(name, id)
+				if err := securecookie.DecodeMulti(name, string(data), &session.Values, codecs...); err != nil {
+					continue
+				}
+				b, err := encodeSession(spec.serial, session, spec.maxLength)
+				if err != nil {
+					return fmt.Errorf("session %q: %w", s.safeID(id), err)
+				}
+				ok, err := s.importTx(tx, spec, id, b, expiresAt, nil)
+				if err != nil {
+					return err
+				}
+				if ok {
+					n++
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,85 @@
+package boltstore
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// RedisEntry is a key of a Redis dump or live connection.
+type RedisEntry struct {
+	Key   string
+	Value []byte
+	TTL   time.Duration // remaining time to live, non-positive - no expiry
+}
+
+// ImportRedistore imports sessions saved by github.com/boj/redistore with the
+// gob serializer. Entries are consumed from next until it returns nil entry;
+// it is typically backed by SCAN and GET/PTTL of a live connection or by a
+// parsed dump. Only keys with prefix (redistore default is "session_") are
+// imported, keeping their IDs and remaining TTLs, so cookies issued with the
+// same key pairs stay valid. Keys without TTL get SessionExpire.
+// returns the number of imported sessions.
+func (s *BoltStore) ImportRedistore(ctx context.Context, prefix string, next func() (*RedisEntry, error)) (int, error) {
+	if err := s.enter(); err != nil {
+		return 0, err
+	}
+	defer s.leave()
+	var (
+		n    int
+		done bool
+	)
+	spec := s.buckets[0]
+	for !done {
+		if err := ctx.Err(); err != nil {
+			return n, err
+		}
+		var batch []*RedisEntry
+		for len(batch) < importBatchSize {
+			e, err := next()
+			if err != nil {
+				return n, err
+			}
+			if e == nil {
+				done = true
+				break
+			}
+			if strings.HasPrefix(e.Key, prefix) {
+				batch = append(batch, e)
+			}
+		}
+		err := s.writeOnce(func(tx *bolt.Tx) error {
+			now := time.Now()
+			for _, e := range batch {
+				id := strings.TrimPrefix(e.Key, prefix)
+				ttl := e.TTL
+				if ttl <= 0 {
+					ttl = spec.expire
+				}
+				session := s.newSession("", id)
+				if err := (GobSerializer{}).Deserialize(e.Value, session); err != nil {
+					return fmt.Errorf("decode session %q values error: %w", s.safeID(id), err)
+				}
+				b, err := encodeSession(spec.serial, session, 0)
+				if err != nil {
+					return err
+				}
+				ok, err := s.importTx(tx, spec, id, b, now.Add(ttl), nil)
+				if err != nil {
+					return err
+				}
+				if ok {
+					n++
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,190 @@
+package boltstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/gob"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+	bolt "go.etcd.io/bbolt"
+)
+
+// encodeYosssi frames gob encoded values as yosssi/boltstore shared.Session.
+func encodeYosssi(t *testing.T, values map[interface{}]interface{}, expiresAt time.Time) []byte {
+	t.Helper()
+	var gb bytes.Buffer
+	if err := gob.NewEncoder(&gb).Encode(values); err != nil {
+		t.Fatal(err)
+	}
+	b := binary.AppendUvarint(nil, 1<<3|2)
+	b = binary.AppendUvarint(b, uint64(gb.Len()))
+	b = append(b, gb.Bytes()...)
+	b = binary.AppendUvarint(b, 2<<3)
+	return binary.AppendUvarint(b, uint64(expiresAt.Unix()))
+}
+
+func TestImportYosssi(t *testing.T) {
+	store := newTestStore(t, Options{})
+	ctx := context.Background()
+	err := store.DB().Update(func(tx *bolt.Tx) error {
+		// yosssi and this store use the same default bucket name
+		b := tx.Bucket([]byte("sessions"))
+		if err := b.Put([]byte("LIVE"), encodeYosssi(t, map[interface{}]interface{}{"user": "bob"}, time.Now().Add(time.Hour))); err != nil {
+			return err
+		}
+		return b.Put([]byte("DEAD"), encodeYosssi(t, map[interface{}]interface{}{"user": "eve"}, time.Now().Add(-time.Hour)))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := store.ImportYosssi(ctx, store.DB(), []byte("sessions"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("Expected 1 imported session; Got %d", n)
+	}
+
+	encoded, _ := securecookie.EncodeMulti("session-key", "LIVE", store.Codecs...)
+	_, session := loadCookie(t, store, "session-key", "session-key="+encoded)
+	if session.IsNew || session.Values["user"] != "bob" {
+		t.Errorf("Expected imported session; Got %v", session.Values)
+	}
+	store.DB().View(func(tx *bolt.Tx) error {
+		if v := tx.Bucket([]byte("sessions")).Get([]byte("DEAD")); v != nil {
+			t.Error("Expected flat records to be removed")
+		}
+		return nil
+	})
+}
+
+func TestImportFilesystemStore(t *testing.T) {
+	dir := t.TempDir()
+	keys := [][]byte{[]byte("fs-secret")}
+	fs := sessions.NewFilesystemStore(dir, keys...)
+	req, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	rsp := NewRecorder()
+	session, _ := fs.New(req, "session-key")
+	session.Values["user"] = "bob"
+	if err := fs.Save(req, rsp, session); err != nil {
+		t.Fatal(err)
+	}
+
+	store := newTestStore(t, Options{KeyPairs: keys})
+	n, err := store.ImportFilesystemStore(context.Background(), dir, "session-key", keys...)
+	if err != nil || n != 1 {
+		t.Fatalf("Expected 1 imported session; Got %d, %v", n, err)
+	}
+	_, session = loadCookie(t, store, "session-key", rsp.Header()["Set-Cookie"][0])
+	if session.IsNew || session.Values["user"] != "bob" {
+		t.Errorf("Expected imported session; Got %v", session.Values)
+	}
+}
+
+func TestCookieStoreMigration(t *testing.T) {
+	keys := [][]byte{[]byte("cookie-secret")}
+	cs := sessions.NewCookieStore(keys...)
+	req, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	rsp := NewRecorder()
+	session, _ := cs.New(req, "session-key")
+	session.Values["user"] = "bob"
+	if err := cs.Save(req, rsp, session); err != nil {
+		t.Fatal(err)
+	}
+
+	store := newTestStore(t, Options{CookieStoreKeyPairs: keys})
+	req, session = loadCookie(t, store, "session-key", rsp.Header()["Set-Cookie"][0])
+	if session.IsNew || session.ID != "" || session.Values["user"] != "bob" {
+		t.Fatalf("Expected migrated values; Got %v %q %v", session.IsNew, session.ID, session.Values)
+	}
+	rsp = NewRecorder()
+	if err := store.Save(req, rsp, session); err != nil {
+		t.Fatal(err)
+	}
+	_, session = loadCookie(t, store, "session-key", rsp.Header()["Set-Cookie"][0])
+	if session.IsNew || session.Values["user"] != "bob" {
+		t.Errorf("Expected stored session after migration; Got %v", session.Values)
+	}
+}
+
+func TestImportRedistore(t *testing.T) {
+	store := newTestStore(t, Options{})
+	value, err := GobSerializer{}.Serialize(&sessions.Session{Values: map[interface{}]interface{}{"user": "bob"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := []*RedisEntry{
+		{Key: "session_ABC", Value: value, TTL: time.Minute},
+		{Key: "other", Value: []byte("x")},
+	}
+	n, err := store.ImportRedistore(context.Background(), "session_", func() (*RedisEntry, error) {
+		if len(entries) == 0 {
+			return nil, nil
+		}
+		e := entries[0]
+		entries = entries[1:]
+		return e, nil
+	})
+	if err != nil || n != 1 {
+		t.Fatalf("Expected 1 imported session; Got %d, %v", n, err)
+	}
+	session, err := store.Peek("ABC")
+	if err != nil || session.Values["user"] != "bob" {
+		t.Errorf("Expected imported session; Got %v, %v", session, err)
+	}
+	if ttl, _ := store.TTL("ABC"); ttl > time.Minute {
+		t.Errorf("Expected remaining TTL to be kept; Got %v", ttl)
+	}
+}
+
+func TestImportSessions(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t, Options{UserKey: "user"})
+	data, err := store.options.Serializer.Serialize(&sessions.Session{Values: map[interface{}]interface{}{"user": "carol"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := []*SessionRecord{
+		{ID: "A", Values: map[interface{}]interface{}{"user": "alice"}, ExpiresAt: time.Now().Add(time.Minute)},
+		{ID: "B", Data: data},
+		{ID: "C", Values: map[interface{}]interface{}{"n": 1}, ExpiresAt: time.Now().Add(-time.Minute)},
+		{ID: "A", Values: map[interface{}]interface{}{"user": "mallory"}},
+	}
+	for i := 0; i < importBatchSize; i++ {
+		records = append(records, &SessionRecord{Values: map[interface{}]interface{}{"n": i}})
+	}
+	n, err := store.ImportSessions(ctx, func() (*SessionRecord, bool) {
+		if len(records) == 0 {
+			return nil, false
+		}
+		rec := records[0]
+		records = records[1:]
+		return rec, true
+	})
+	if err != nil || n != importBatchSize+2 {
+		t.Fatalf("Expected %d imported sessions; Got %d, %v", importBatchSize+2, n, err)
+	}
+	if session, err := store.Peek("A"); err != nil || session.Values["user"] != "alice" {
+		t.Errorf("Expected imported session kept; Got %v, %v", session, err)
+	}
+	if ttl, _ := store.TTL("A"); ttl > time.Minute {
+		t.Errorf("Expected the imported expiry; Got %v", ttl)
+	}
+	if store.Exists("C") {
+		t.Error("Expected expired record skipped")
+	}
+
+	// imported users are revoked
+	if err := store.RevokeUser(ctx, "carol"); err != nil {
+		t.Fatal(err)
+	}
+	if store.Exists("B") {
+		t.Error("Expected the session of the revoked user inactive")
+	}
+}
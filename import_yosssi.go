@@ -0,0 +1,149 @@
+package boltstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// importBatchSize is the number of records imported in a single transaction.
+const importBatchSize = 1000
+
+// ImportYosssi imports sessions stored by github.com/yosssi/boltstore in the
+// flat bucket of src db: keys are session IDs, values are protobuf framed
+// gob encoded session values with expiration time. Records are rewritten with
+// the configured serializer keeping their IDs and expiry, so cookies issued
+// with the same key pairs stay valid. Expired records and IDs already stored
+// are skipped. If src is the store db, imported records are removed from the
+// flat bucket, which may then be the store's own bucket.
+// returns the number of imported sessions.
+func (s *BoltStore) ImportYosssi(ctx context.Context, src *bolt.DB, bucket []byte) (int, error) {
+	if err := s.enter(); err != nil {
+		return 0, err
+	}
+	defer s.leave()
+	var (
+		n    int
+		last []byte
+	)
+	same := src == s.db
+	for {
+		if err := ctx.Err(); err != nil {
+			return n, err
+		}
+		// read a batch of flat records
+		type item struct {
+			id        string
+			values    []byte
+			expiresAt time.Time
+		}
+		var batch []item
+		err := src.View(func(tx *bolt.Tx) error {
+			b := tx.Bucket(bucket)
+			if b == nil {
+				return nil
+			}
+			c := b.Cursor()
+			k, v := c.First()
+			if last != nil {
+				k, v = c.Seek(last)
+				if bytes.Equal(k, last) {
+					k, v = c.Next()
+				}
+			}
+			for ; k != nil && len(batch) < importBatchSize; k, v = c.Next() {
+				if v == nil {
+					// nested bucket, not a yosssi record
+					continue
+				}
+				values, expiresAt, err := decodeYosssi(v)
+				if err != nil {
+					return fmt.Errorf("decode record %q error: %w", s.safeID(string(k)), err)
+				}
+				batch = append(batch, item{
+					id:        string(k),
+					values:    append([]byte{}, values...),
+					expiresAt: time.Unix(expiresAt, 0),
+				})
+				last = append(last[:0], k...)
+			}
+			return nil
+		})
+		if err != nil || len(batch) == 0 {
+			return n, err
+		}
+
+		now := time.Now()
+		err = s.writeOnce(func(tx *bolt.Tx) error {
+			for _, it := range batch {
+				if same {
+					if err := tx.Bucket(bucket).Delete([]byte(it.id)); err != nil {
+						return err
+					}
+				}
+				if it.expiresAt.Before(now) {
+					continue
+				}
+				session := s.newSession("", it.id)
+				if err := (GobSerializer{}).Deserialize(it.values, session); err != nil {
+					return fmt.Errorf("decode session %q values error: %w", s.safeID(it.id), err)
+				}
+				b, err := encodeSession(s.buckets[0].serial, session, 0)
+				if err != nil {
+					return err
+				}
+				ok, err := s.importTx(tx, s.buckets[0], it.id, b, it.expiresAt, nil)
+				if err != nil {
+					return err
+				}
+				if ok {
+					n++
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return n, err
+		}
+	}
+}
+
+// decodeYosssi decodes yosssi/boltstore shared.Session protobuf message:
+// field 1 - gob encoded values (bytes), field 2 - expiration unix time (varint).
+func decodeYosssi(b []byte) ([]byte, int64, error) {
+	var (
+		values    []byte
+		expiresAt int64
+	)
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		if n <= 0 {
+			return nil, 0, errors.New("malformed field tag")
+		}
+		b = b[n:]
+		switch field, wire := tag>>3, tag&7; {
+		case field == 1 && wire == 2:
+			l, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < l {
+				return nil, 0, errors.New("malformed values field")
+			}
+			values = b[n : n+int(l)]
+			b = b[n+int(l):]
+		case field == 2 && wire == 0:
+			v, n := binary.Uvarint(b)
+			if n <= 0 {
+				return nil, 0, errors.New("malformed expiresAt field")
+			}
+			expiresAt = int64(v)
+			b = b[n:]
+		default:
+			return nil, 0, fmt.Errorf("unexpected field %d wire type %d", field, wire)
+		}
+	}
+	return values, expiresAt, nil
+}
@@ -0,0 +1,58 @@
+package boltstore
+
+import (
+	"context"
+
+	"github.com/gorilla/sessions"
+	bolt "go.etcd.io/bbolt"
+)
+
+// eachTx calls fn with the bucket of every stored session within transaction
+// tx, in ID order within each sessions bucket, until fn returns an error or
+// ctx is done.
+func (s *BoltStore) eachTx(ctx context.Context, tx *bolt.Tx, fn func(spec *bucketSpec, id []byte, b *bolt.Bucket) error) error {
+	for _, spec := range s.buckets {
+		root := tx.Bucket(spec.name)
+		c := root.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if v != nil {
+				// not a session bucket
+				continue
+			}
+			if err := fn(spec, k, root.Bucket(k)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ForEach calls fn for every active session in the store, in ID order
+// within each sessions bucket.
+// Sessions are read within a single read transaction, so fn sees a consistent
+// snapshot and must not write to the store. Iteration stops on the first
+// error returned by fn or when ctx is done.
+func (s *BoltStore) ForEach(ctx context.Context, fn func(id string, session *sessions.Session) error) error {
+	if err := s.enter(); err != nil {
+		return err
+	}
+	defer s.leave()
+	return s.db.View(func(tx *bolt.Tx) error {
+		return s.eachTx(ctx, tx, func(spec *bucketSpec, k []byte, _ *bolt.Bucket) error {
+			id := string(k)
+			session := s.newSession(s.sessionName(spec), id)
+			rec, err := s.readTx(tx, session)
+			if err != nil {
+				return err
+			}
+			if rec == nil || rec.expired(s.expiryNow()) {
+				return nil
+			}
+			rec.apply(session)
+			return fn(id, session)
+		})
+	})
+}
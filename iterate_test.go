@@ -0,0 +1,62 @@
+package boltstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gorilla/sessions"
+)
+
+func TestForEach(t *testing.T) {
+	store := newTestStore(t, Options{})
+	for i := 0; i < 3; i++ {
+		saveNew(t, store, "session-key", map[interface{}]interface{}{"n": i})
+	}
+
+	sum := 0
+	err := store.ForEach(context.Background(), func(id string, s *sessions.Session) error {
+		if id != s.ID {
+			t.Errorf("Expected id %q; Got %q", s.ID, id)
+		}
+		sum += s.Values["n"].(int)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum != 3 {
+		t.Errorf("Expected sum 3; Got %d", sum)
+	}
+
+	errStop := errors.New("stop")
+	calls := 0
+	err = store.ForEach(context.Background(), func(string, *sessions.Session) error {
+		calls++
+		return errStop
+	})
+	if !errors.Is(err, errStop) || calls != 1 {
+		t.Errorf("Expected iteration to stop; Got %v after %d calls", err, calls)
+	}
+}
+
+func TestPeek(t *testing.T) {
+	store := newTestStore(t, Options{})
+	cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 1})
+	_, session := loadCookie(t, store, "session-key", cookie)
+	ttl, _ := store.TTL(session.ID)
+
+	peeked, err := store.Peek(session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if peeked.Values["n"] != 1 || Version(peeked) != Version(session) {
+		t.Errorf("Expected peeked session; Got %v", peeked.Values)
+	}
+	if after, _ := store.TTL(session.ID); after > ttl {
+		t.Errorf("Expected Peek not to refresh expiry; Got %v > %v", after, ttl)
+	}
+	if _, err := store.Peek("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound; Got %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package boltstore
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gorilla/sessions"
+)
+
+// keyExpiryKey is the session values key of value expiration times, stored
+// as a JSON object of unix milliseconds by key, so it needs no gob
+// registration with any serializer.
+const keyExpiryKey = "_key_expiry"
+
+// SetExpiring sets the session value of key valid for ttl, for example an
+// OTP challenge: it is dropped when the session is loaded or saved after it
+// expired, independently of the session expiry. Setting the value again
+// with SetExpiring extends it, deleting it drops its expiration time.
+func SetExpiring(session *sessions.Session, key string, value interface{}, ttl time.Duration) {
+	deadlines := keyDeadlines(session)
+	deadlines[key] = time.Now().Add(ttl).UnixMilli()
+	session.Values[key] = value
+	setKeyDeadlines(session, deadlines)
+}
+
+// KeyExpiry returns the expiration time of the session value of key set
+// with SetExpiring.
+func KeyExpiry(session *sessions.Session, key string) (time.Time, bool) {
+	ms, ok := keyDeadlines(session)[key]
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(ms), true
+}
+
+// keyDeadlines returns the value expiration times of the session.
+func keyDeadlines(session *sessions.Session) map[string]int64 {
+	deadlines := make(map[string]int64)
+	if s, ok := session.Values[keyExpiryKey].(string); ok {
+		json.Unmarshal([]byte(s), &deadlines)
+	}
+	return deadlines
+}
+
+// setKeyDeadlines stores the value expiration times in the session.
+func setKeyDeadlines(session *sessions.Session, deadlines map[string]int64) {
+	if len(deadlines) == 0 {
+		delete(session.Values, keyExpiryKey)
+		return
+	}
+	b, _ := json.Marshal(deadlines)
+	session.Values[keyExpiryKey] = string(b)
+}
+
+// expireKeys drops the session values expired at time now, and expiration
+// times of deleted values.
+func expireKeys(session *sessions.Session, now time.Time) {
+	if _, ok := session.Values[keyExpiryKey]; !ok {
+		return
+	}
+	deadlines := keyDeadlines(session)
+	for key, ms := range deadlines {
+		if _, ok := session.Values[key]; !ok {
+			delete(deadlines, key)
+		} else if ms <= now.UnixMilli() {
+			delete(session.Values, key)
+			delete(deadlines, key)
+		}
+	}
+	setKeyDeadlines(session, deadlines)
+}
@@ -0,0 +1,46 @@
+package boltstore
+
+import (
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestSetExpiring(t *testing.T) {
+	store := newTestStore(t, Options{})
+	cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"user": "bob"})
+	req, session := loadCookie(t, store, "session-key", cookie)
+	SetExpiring(session, "otp", "123456", time.Minute)
+	SetExpiring(session, "challenge", "abc", -time.Second)
+	if at, ok := KeyExpiry(session, "otp"); !ok || time.Until(at) <= 0 {
+		t.Errorf("Expected otp expiry; Got %v, %v", at, ok)
+	}
+	if err := store.Save(req, NewRecorder(), session); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := session.Values["challenge"]; ok {
+		t.Error("Expected expired value dropped on save")
+	}
+	if _, ok := KeyExpiry(session, "challenge"); ok {
+		t.Error("Expected expiry of dropped value removed")
+	}
+
+	_, session = loadCookie(t, store, "session-key", cookie)
+	if session.Values["otp"] != "123456" || session.Values["user"] != "bob" {
+		t.Fatalf("Expected unexpired values loaded; Got %v", session.Values)
+	}
+
+	// store the otp expired, saveTx doesn't drop it
+	SetExpiring(session, "otp", "123456", -time.Second)
+	if err := store.db.Update(func(tx *bolt.Tx) error {
+		_, err := store.saveTx(tx, session)
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+	_, session = loadCookie(t, store, "session-key", cookie)
+	if _, ok := session.Values["otp"]; ok || session.Values["user"] != "bob" {
+		t.Errorf("Expected expired value dropped on load; Got %v", session.Values)
+	}
+}
@@ -0,0 +1,105 @@
+package boltstore
+
+import (
+	"context"
+	"fmt"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// keyLayout is the control bucket key of the on-disk layout version of the
+// sessions buckets.
+var keyLayout = []byte("layout")
+
+// layoutMigrations migrate the on-disk layout written by older versions of
+// the package, migration i moves it from layout version i to i+1 within the
+// transaction. Databases stamped before versioning are layout 0.
+var layoutMigrations = []func(s *BoltStore, tx *bolt.Tx) error{
+	0: (*BoltStore).migrateBinaryExpiry,
+}
+
+// layoutVersion returns the on-disk layout version written by the package.
+func layoutVersion() uint64 {
+	return uint64(len(layoutMigrations))
+}
+
+// layoutTx stamps the layout version within transaction tx, running the
+// migrations the stored layout needs unless Options.NoMigrate is set.
+// Empty stores are stamped without migrations.
+func (s *BoltStore) layoutTx(tx *bolt.Tx) error {
+	control := tx.Bucket(controlBucketName(s.options.BucketName))
+	v := decodeUint(control.Get(keyLayout))
+	switch {
+	case v == layoutVersion():
+		return nil
+	case v > layoutVersion():
+		return &LayoutError{Stored: v, Current: layoutVersion()}
+	case decodeUint(control.Get(keyCount)) == 0:
+		// nothing to migrate
+	case s.options.NoMigrate:
+		return &LayoutError{Stored: v, Current: layoutVersion()}
+	default:
+		for ; v < layoutVersion(); v++ {
+			if err := layoutMigrations[v](s, tx); err != nil {
+				return fmt.Errorf("migrate layout %d error: %w", v, err)
+			}
+		}
+	}
+	return control.Put(keyLayout, encodeUint(layoutVersion()))
+}
+
+// MigrateLayout runs the on-disk layout migrations needed by the store with
+// options opts on db within a single transaction, for stores opened with
+// Options.NoMigrate, for example from a maintenance job before a rolling
+// deploy. Stores without sessions buckets are left as is.
+func MigrateLayout(ctx context.Context, db *bolt.DB, opts Options) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	opts = setOptions(opts)
+	opts.NoMigrate = false
+	s := &BoltStore{db: db, options: opts}
+	s.buckets, s.named = newBucketSpecs(opts)
+	return s.writeOnce(func(tx *bolt.Tx) error {
+		if tx.Bucket(opts.BucketName) == nil {
+			return nil
+		}
+		return s.createBuckets(tx)
+	})
+}
+
+// migrateBinaryExpiry rewrites times stored by older versions as decimal
+// unix seconds in the binary format, so they compare as bytes.
+func (s *BoltStore) migrateBinaryExpiry(tx *bolt.Tx) error {
+	for _, spec := range s.buckets {
+		root := tx.Bucket(spec.name)
+		var ids [][]byte
+		err := root.ForEach(func(k, v []byte) error {
+			if v == nil {
+				ids = append(ids, k)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		// session buckets are changed after the iteration of their parent
+		for _, id := range ids {
+			b := root.Bucket(id)
+			for _, key := range [][]byte{keyExpiredAt, keyDeletedUntil, keyLastAccess, keyCreatedAt} {
+				v := b.Get(key)
+				if v == nil || isBinaryExpiry(v) {
+					continue
+				}
+				at, ok := decodeExpiry(v)
+				if !ok {
+					continue
+				}
+				if err := b.Put(key, encodeExpiry(at)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package boltstore
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestLayoutMigration(t *testing.T) {
+	ctx := context.Background()
+	fn := filepath.Join(t.TempDir(), "test.db")
+	opts := Options{KeyPairs: [][]byte{[]byte("secret-key")}, NoReaper: true}
+	store, err := NewStore(ctx, fn, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 1})
+	_, session := loadCookie(t, store, "session-key", cookie)
+
+	// written by a version before layout versioning
+	at := time.Now().Add(time.Hour).Truncate(time.Second)
+	err = store.DB().Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(controlBucketName(store.options.BucketName)).Delete(keyLayout); err != nil {
+			return err
+		}
+		return tx.Bucket(store.options.BucketName).Bucket([]byte(session.ID)).Put(keyExpiredAt, []byte(strconv.FormatInt(at.Unix(), 10)))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	opts.NoMigrate = true
+	var lerr *LayoutError
+	if _, err := NewStore(ctx, fn, opts); !errors.As(err, &lerr) || lerr.Stored != 0 || lerr.Current != layoutVersion() {
+		t.Fatalf("Expected layout error with NoMigrate; Got %v", err)
+	}
+	db, err := bolt.Open(fn, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := MigrateLayout(ctx, db, opts); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	store, err = NewStore(ctx, fn, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	store.DB().View(func(tx *bolt.Tx) error {
+		if v := tx.Bucket(store.options.BucketName).Bucket([]byte(session.ID)).Get(keyExpiredAt); !isBinaryExpiry(v) {
+			t.Errorf("Expected migrated binary expiry; Got %q", v)
+		}
+		return nil
+	})
+	if ttl, err := store.TTL(session.ID); err != nil || ttl <= 59*time.Minute {
+		t.Errorf("Expected the expiry kept; Got %v, %v", ttl, err)
+	}
+	store.Close()
+
+	// layouts of newer versions are not opened
+	db, _ = bolt.Open(fn, 0600, nil)
+	db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(controlBucketName(store.options.BucketName)).Put(keyLayout, encodeUint(layoutVersion()+1))
+	})
+	db.Close()
+	if _, err := NewStore(ctx, fn, Options{KeyPairs: opts.KeyPairs}); !errors.As(err, &lerr) {
+		t.Errorf("Expected layout error of a newer layout; Got %v", err)
+	}
+}
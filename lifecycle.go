@@ -0,0 +1,101 @@
+package boltstore
+
+import "sync"
+
+// store lifecycle states, the zero value is open.
+const (
+	stateOpen = iota
+	stateClosing
+	stateClosed
+)
+
+// lifecycle tracks the store state and operations in progress, so Close
+// waits for them and later operations fail with ErrStoreClosed.
+type lifecycle struct {
+	mu      sync.Mutex
+	state   int
+	n       int           // operations in progress
+	drained chan struct{} // closed when the last operation leaves a closing store
+	closed  chan struct{} // closed when a closing store is closed
+}
+
+// enter starts an operation, returns false unless the store is open.
+func (l *lifecycle) enter() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.state != stateOpen {
+		return false
+	}
+	l.n++
+	return true
+}
+
+// leave ends an operation started by enter.
+func (l *lifecycle) leave() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.n--
+	if l.n == 0 && l.drained != nil {
+		close(l.drained)
+		l.drained = nil
+	}
+}
+
+// close moves an open store to closing and waits for operations in
+// progress. returns false if the store is not open, after waiting for a
+// closing store to be closed.
+func (l *lifecycle) close() bool {
+	l.mu.Lock()
+	if l.state != stateOpen {
+		closed := l.closed
+		l.mu.Unlock()
+		if closed != nil {
+			<-closed
+		}
+		return false
+	}
+	l.state = stateClosing
+	l.closed = make(chan struct{})
+	var drained chan struct{}
+	if l.n > 0 {
+		drained = make(chan struct{})
+		l.drained = drained
+	}
+	l.mu.Unlock()
+	if drained != nil {
+		<-drained
+	}
+	return true
+}
+
+// set moves the store to state.
+func (l *lifecycle) set(state int) {
+	l.mu.Lock()
+	l.state = state
+	if l.closed != nil && state != stateClosing {
+		close(l.closed)
+		l.closed = nil
+	}
+	l.mu.Unlock()
+}
+
+// is returns true if the store is in state.
+func (l *lifecycle) is(state int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.state == state
+}
+
+// enter starts a public operation of the store, returns ErrStoreClosed
+// unless the store is open. The operation must be ended with leave.
+func (s *BoltStore) enter() error {
+	if !s.life.enter() {
+		return ErrStoreClosed
+	}
+	return nil
+}
+
+// leave ends an operation started by enter.
+func (s *BoltStore) leave() {
+	s.life.leave()
+}
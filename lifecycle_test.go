@@ -0,0 +1,152 @@
+package boltstore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gorilla/sessions"
+)
+
+func TestStoreContext(t *testing.T) {
+	parent, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store, err := NewStore(parent, filepath.Join(t.TempDir(), "test.db"), Options{
+		KeyPairs:          [][]byte{[]byte("secret-key")},
+		ReapCheckInterval: time.Millisecond,
+		NoSync:            true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.ctx.Err() != nil {
+		t.Fatal("Expected running store context")
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if store.ctx.Err() == nil {
+		t.Error("Expected store context canceled on Close")
+	}
+	if parent.Err() != nil {
+		t.Error("Expected parent context left alone")
+	}
+}
+
+func TestReopen(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t, Options{CacheSize: 10})
+	cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 1})
+	if err := store.Reopen(ctx); err == nil {
+		t.Error("Expected error reopening open store")
+	}
+
+	// a restored file with another session replaces the closed one
+	other := newTestStore(t, Options{})
+	restored := saveNew(t, other, "session-key", map[interface{}]interface{}{"n": 2})
+	src := other.DB().Path()
+	other.Close()
+	path := store.DB().Path()
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(src, path); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Reopen(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, session := loadCookie(t, store, "session-key", cookie); !session.IsNew {
+		t.Errorf("Expected session of replaced file gone; Got %v", session.Values)
+	}
+	if _, session := loadCookie(t, store, "session-key", restored); session.Values["n"] != 2 {
+		t.Errorf("Expected restored session; Got %v", session.Values)
+	}
+	saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 3})
+}
+
+func TestStoreClosed(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t, Options{})
+	cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 1})
+	req, session := loadCookie(t, store, "session-key", cookie)
+
+	// Close waits for operations in progress and rejects new ones
+	entered, release := make(chan struct{}), make(chan struct{})
+	go store.UpdateByID(ctx, session.ID, func(*sessions.Session) error {
+		close(entered)
+		<-release
+		return nil
+	})
+	<-entered
+	closed := make(chan error)
+	go func() { closed <- store.Close() }()
+	for store.life.is(stateOpen) {
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := store.Count(ctx); err != ErrStoreClosed {
+		t.Errorf("Expected ErrStoreClosed while closing; Got %v", err)
+	}
+	select {
+	case <-closed:
+		t.Fatal("Expected Close to wait for UpdateByID")
+	case <-time.After(10 * time.Millisecond):
+	}
+	close(release)
+	if err := <-closed; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Save(req, NewRecorder(), session); err != ErrStoreClosed {
+		t.Errorf("Expected ErrStoreClosed on Save; Got %v", err)
+	}
+	if s, err := store.New(req, "session-key"); err != ErrStoreClosed || s == nil {
+		t.Errorf("Expected new session and ErrStoreClosed on New; Got %v %v", s, err)
+	}
+	if err := store.Delete(ctx, session.ID); err != ErrStoreClosed {
+		t.Errorf("Expected ErrStoreClosed on Delete; Got %v", err)
+	}
+	if store.Exists(session.ID) {
+		t.Error("Expected no sessions in closed store")
+	}
+	if err := store.Close(); err != nil {
+		t.Errorf("Expected no error closing again; Got %v", err)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	store := newTestStore(t, Options{CountAccesses: true, NoSync: true})
+	cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 1})
+	_, session := loadCookie(t, store, "session-key", cookie)
+
+	// an operation in progress outlasts the deadline
+	entered, release := make(chan struct{}), make(chan struct{})
+	go store.UpdateByID(context.Background(), session.ID, func(*sessions.Session) error {
+		close(entered)
+		<-release
+		return nil
+	})
+	<-entered
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := store.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected deadline exceeded; Got %v", err)
+	}
+	close(release)
+
+	if err := store.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	path := store.path
+	store, err := NewStore(context.Background(), path, Options{KeyPairs: [][]byte{[]byte("secret-key")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if info, err := store.Info(session.ID); err != nil || info.Accesses != 1 {
+		t.Errorf("Expected flushed access count; Got %v %v", info, err)
+	}
+}
@@ -0,0 +1,101 @@
+package boltstore
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// writeLimiter is a semaphore of concurrent writes, nil is unlimited.
+type writeLimiter chan struct{}
+
+// newWriteLimiter returns a limiter of n concurrent writes, nil if n is 0.
+func newWriteLimiter(n int) writeLimiter {
+	if n <= 0 {
+		return nil
+	}
+	return make(writeLimiter, n)
+}
+
+// acquire takes a write slot without waiting. returns false if all slots are
+// taken, or with shed if half of them are, rounded up so a single slot
+// limiter still admits refreshes while idle.
+func (l writeLimiter) acquire(shed bool) bool {
+	if l == nil {
+		return true
+	}
+	if shed && len(l) >= (cap(l)+1)/2 {
+		return false
+	}
+	select {
+	case l <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// release returns a slot taken by acquire.
+func (l writeLimiter) release() {
+	if l != nil {
+		<-l
+	}
+}
+
+// writeStats are counters of the write path, see WriteStats.
+type writeStats struct {
+	queued     atomic.Int64
+	committed  atomic.Uint64
+	overloaded atomic.Uint64
+	shed       atomic.Uint64
+	latency    atomic.Int64 // total of committed writes, ns
+	maxLatency atomic.Int64 // ns
+}
+
+// begin counts a write entering the queue, returns its start time.
+func (ws *writeStats) begin() time.Time {
+	ws.queued.Add(1)
+	return time.Now()
+}
+
+// end counts a write started at start leaving the queue.
+func (ws *writeStats) end(start time.Time, err error) {
+	ws.queued.Add(-1)
+	if err != nil {
+		return
+	}
+	d := int64(time.Since(start))
+	ws.committed.Add(1)
+	ws.latency.Add(d)
+	for {
+		max := ws.maxLatency.Load()
+		if d <= max || ws.maxLatency.CompareAndSwap(max, d) {
+			return
+		}
+	}
+}
+
+// WriteStats are metrics of the store write path, to detect when batched
+// saves fall behind.
+type WriteStats struct {
+	Queued     int           // writes waiting for their commit
+	Committed  uint64        // committed writes
+	Overloaded uint64        // writes failed with ErrOverloaded
+	Shed       uint64        // access refreshes skipped under Options.MaxWriters load
+	AvgLatency time.Duration // mean time from write start to commit
+	MaxLatency time.Duration // max time from write start to commit
+}
+
+// stats returns a snapshot of the counters.
+func (ws *writeStats) stats() WriteStats {
+	st := WriteStats{
+		Queued:     int(ws.queued.Load()),
+		Committed:  ws.committed.Load(),
+		Overloaded: ws.overloaded.Load(),
+		Shed:       ws.shed.Load(),
+		MaxLatency: time.Duration(ws.maxLatency.Load()),
+	}
+	if st.Committed > 0 {
+		st.AvgLatency = time.Duration(ws.latency.Load() / int64(st.Committed))
+	}
+	return st
+}
@@ -0,0 +1,66 @@
+package boltstore
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/sessions"
+)
+
+func TestWriteLimiter(t *testing.T) {
+	l := newWriteLimiter(4)
+	if !l.acquire(true) || !l.acquire(false) {
+		t.Fatal("Expected free slots")
+	}
+	if l.acquire(true) {
+		t.Error("Expected refresh shed at half")
+	}
+	if !l.acquire(false) || !l.acquire(false) || l.acquire(false) {
+		t.Error("Expected 4 slots")
+	}
+	l.release()
+	if !l.acquire(false) {
+		t.Error("Expected released slot")
+	}
+	l = newWriteLimiter(1)
+	if !l.acquire(true) {
+		t.Error("Expected refresh of an idle single slot limiter")
+	}
+	if l.acquire(true) || l.acquire(false) {
+		t.Error("Expected single slot taken")
+	}
+	if newWriteLimiter(0) != nil || !writeLimiter(nil).acquire(true) {
+		t.Error("Expected unlimited writes")
+	}
+}
+
+func TestMaxWriters(t *testing.T) {
+	store := newTestStore(t, Options{MaxWriters: 2, Durability: ProfileBalanced, MaxBatchDelay: 50 * time.Millisecond})
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 1})
+		}()
+	}
+	for len(store.limit) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	req, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	session, _ := store.New(req, "session-key")
+	if err := store.Save(req, NewRecorder(), session); !errors.Is(err, ErrOverloaded) {
+		t.Errorf("Expected ErrOverloaded; Got %v", err)
+	}
+	if err := store.UpdateByID(context.Background(), "id", func(*sessions.Session) error { return nil }); !errors.Is(err, ErrOverloaded) {
+		t.Errorf("Expected ErrOverloaded of an update; Got %v", err)
+	}
+	wg.Wait()
+	if w := store.wstats.stats(); w.Overloaded != 2 || w.Committed != 2 {
+		t.Errorf("Expected overloaded write counted; Got %+v", w)
+	}
+}
@@ -1,34 +1,198 @@
 package boltstore
 
 import (
-	"fmt"
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
 
 	"github.com/gorilla/sessions"
 	bolt "go.etcd.io/bbolt"
 )
 
+// record is the session data read from db.
+type record struct {
+	values    map[interface{}]interface{}
+	version   uint64
+	size      int // serialized data length
+	expiresAt time.Time
+	accessed  atomic.Int64 // last access unix seconds, 0 - unknown
+	single    bool         // deleted by the first load, never cached
+	promoted  bool         // provisional until the first load, never cached, see Options.ProvisionalExpire
+	renewed   bool         // expiry was extended by the save
+	evicted   []string     // sessions deleted by the save, see Options.MaxUserSessions
+}
+
+// expired reports whether the record expired at time now.
+func (rec *record) expired(now time.Time) bool {
+	return rec.expiresAt.Before(now)
+}
+
+// apply copies record values into the session.
+// Values are copied shallowly, nested values are shared between all
+// sessions the record was applied to.
+func (rec *record) apply(session *sessions.Session) {
+	for k, v := range rec.values {
+		session.Values[k] = v
+	}
+	setMeta(session, metaVersion, rec.version)
+}
+
+// adopt moves record values into the session, without copying: the session
+// values map is replaced with the record one. Only records which are not
+// shared with other sessions can be adopted.
+func (rec *record) adopt(session *sessions.Session) {
+	for k, v := range session.Values {
+		rec.values[k] = v
+	}
+	session.Values = rec.values
+	rec.values = nil
+	setMeta(session, metaVersion, rec.version)
+}
+
+// applyExpired copies values of the expired record into the session and
+// flags it as expired, see Expired.
+func (rec *record) applyExpired(session *sessions.Session) {
+	for k, v := range rec.values {
+		session.Values[k] = v
+	}
+	setMeta(session, metaExpired, rec.expiresAt)
+}
+
+// Expired returns the time the session data expired at, if the session
+// carries values of an expired session returned within Options.ExpiredGrace.
+// Such sessions are new and are saved under a new ID.
+func Expired(session *sessions.Session) (time.Time, bool) {
+	v, ok := getMeta(session, metaExpired)
+	if !ok {
+		return time.Time{}, false
+	}
+	return v.(time.Time), true
+}
+
 // load reads the session from db.
 // returns true if there is a sessoin data in DB
-func (s *BoltStore) load(session *sessions.Session) (bool, error) {
-	// exists represents whether a session data exists or not.
-	var exists bool
-	err := s.db.View(func(tx *bolt.Tx) error {
-		id := []byte(session.ID)
-		bucket := tx.Bucket(s.options.BucketName).Bucket(id)
-		if bucket == nil {
-			return fmt.Errorf("invalid session bucket %s/%s", string(s.options.BucketName), session.ID)
-		}
-		// Get the session data.
-		data := bucket.Get(keyValues)
-		if data == nil {
-			return nil
+func (s *BoltStore) load(ctx context.Context, session *sessions.Session) (bool, error) {
+	if s.cache != nil {
+		if rec, ok := s.cache.get(session.ID); ok && !rec.expired(s.expiryNow()) {
+			s.touch(ctx, session.ID, rec)
+			s.countAccess(session.ID)
+			rec.apply(session)
+			return true, nil
 		}
+	}
 
-		if err := s.options.Serializer.Deserialize(data, session); err != nil {
+	read := func() (*record, error) {
+		var rec *record
+		err := s.db.View(func(tx *bolt.Tx) error {
+			var err error
+			rec, err = s.readTx(tx, session)
 			return err
+		})
+		return rec, err
+	}
+
+	var (
+		rec *record
+		err error
+	)
+	if s.loads != nil {
+		rec, err = s.loads.do(session.ID, read)
+	} else {
+		rec, err = read()
+	}
+	if err != nil && s.options.SkipCorrupt && errors.As(err, new(*CorruptRecordError)) {
+		s.dropCorrupt(ctx, session.ID, err)
+		return false, nil
+	}
+	if err != nil || rec == nil {
+		return false, err
+	}
+	if rec.expired(s.expiryNow()) {
+		rec.applyExpired(session)
+		return false, nil
+	}
+	if rec.single {
+		if ok, err := s.consume(ctx, session, rec.version); err != nil || !ok {
+			return false, err
+		}
+	} else {
+		if !rec.promoted {
+			if err := s.promote(ctx, session); err != nil {
+				return false, err
+			}
+		}
+		s.touch(ctx, session.ID, rec)
+		s.countAccess(session.ID)
+	}
+	switch {
+	case s.cache != nil && !rec.single && rec.promoted:
+		s.cache.put(session.ID, rec)
+		rec.apply(session)
+	case s.loads != nil, s.cache != nil:
+		rec.apply(session)
+	default:
+		rec.adopt(session)
+	}
+	return true, nil
+}
+
+// loadTx reads the session within transaction tx.
+func (s *BoltStore) loadTx(tx *bolt.Tx, session *sessions.Session) (bool, error) {
+	rec, err := s.readTx(tx, session)
+	if err != nil || rec == nil || rec.expired(s.expiryNow()) {
+		return false, err
+	}
+	rec.adopt(session)
+	return true, nil
+}
+
+// readTx reads and deserializes the record of the session within transaction tx.
+// returns nil record if there is no session data or it expired.
+func (s *BoltStore) readTx(tx *bolt.Tx, session *sessions.Session) (*record, error) {
+	id := []byte(session.ID)
+	bucket, spec := s.findTx(tx, id, s.bucketOf(session.Name()))
+	if bucket == nil || bucket.Get(keyDeletedUntil) != nil || s.revokedTx(tx, bucket) || s.outlived(bucket, time.Now()) {
+		// reaped, deleted, revoked or past the max lifetime, with no grace
+		return nil, nil
+	}
+	// Get the session data.
+	data := bucket.Get(keyValues)
+	if data == nil {
+		return nil, nil
+	}
+	// Expired but not reaped yet, expired records are returned within grace period.
+	expiresAt, ok := decodeExpiry(bucket.Get(keyExpiredAt))
+	if !ok || expiresAt.Before(s.expiryNow().Add(-s.options.ExpiredGrace)) {
+		return nil, nil
+	}
+
+	// values are decoded into a fresh map straight from the mmap
+	tmp := *session
+	tmp.Values = make(map[interface{}]interface{})
+	if err := spec.serial.Deserialize(data, &tmp); err != nil {
+		err = typeError(err)
+		if _, ok := err.(*UnregisteredTypeError); ok {
+			// a missing registration, not bad data
+			return nil, err
+		}
+		return nil, &CorruptRecordError{ID: s.safeID(session.ID), Err: err}
+	}
+	if v := decodeUint(bucket.Get(keySchema)); v < s.schemaVersion() {
+		if err := s.upgrade(tmp.Values, v); err != nil {
+			return nil, err
 		}
-		exists = true
-		return nil
-	})
-	return exists, err
+	}
+	rec := &record{
+		values:    tmp.Values,
+		version:   decodeUint(bucket.Get(keyVersion)),
+		size:      len(data),
+		expiresAt: expiresAt,
+		single:    bucket.Get(keySingleUse) != nil,
+		promoted:  bucket.Get(keyProvisional) == nil,
+	}
+	if at, ok := decodeExpiry(bucket.Get(keyLastAccess)); ok {
+		rec.accessed.Store(at.Unix())
+	}
+	return rec, nil
 }
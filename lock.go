@@ -0,0 +1,114 @@
+package boltstore
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+
+	"github.com/gorilla/sessions"
+)
+
+// lockShards is the number of shards of the keyed session locker.
+const lockShards = 32
+
+// keyedLocker provides in-process mutual exclusion by session ID.
+type keyedLocker struct {
+	shards [lockShards]lockShard
+}
+
+type lockShard struct {
+	mu    sync.Mutex
+	locks map[string]*keyLock
+}
+
+// keyLock is a single ID lock, ch holds a token while locked.
+type keyLock struct {
+	ch   chan struct{}
+	refs int
+}
+
+func newKeyedLocker() *keyedLocker {
+	l := &keyedLocker{}
+	for i := range l.shards {
+		l.shards[i].locks = make(map[string]*keyLock)
+	}
+	return l
+}
+
+func (l *keyedLocker) shard(key string) *lockShard {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return &l.shards[h.Sum32()%lockShards]
+}
+
+// lock acquires the lock for key, waiting until it is released or ctx is done.
+// The returned release func is idempotent, the lock is also released once ctx
+// is done so a session which is never saved doesn't keep it forever.
+func (l *keyedLocker) lock(ctx context.Context, key string) (func(), error) {
+	sh := l.shard(key)
+	sh.mu.Lock()
+	kl, ok := sh.locks[key]
+	if !ok {
+		kl = &keyLock{ch: make(chan struct{}, 1)}
+		sh.locks[key] = kl
+	}
+	kl.refs++
+	sh.mu.Unlock()
+
+	drop := func() {
+		sh.mu.Lock()
+		kl.refs--
+		if kl.refs == 0 {
+			delete(sh.locks, key)
+		}
+		sh.mu.Unlock()
+	}
+
+	select {
+	case kl.ch <- struct{}{}:
+	case <-ctx.Done():
+		drop()
+		return nil, ctx.Err()
+	}
+
+	var once sync.Once
+	done := make(chan struct{})
+	release := func() {
+		once.Do(func() {
+			close(done)
+			<-kl.ch
+			drop()
+		})
+	}
+	go func() {
+		select {
+		case <-ctx.Done():
+			release()
+		case <-done:
+		}
+	}()
+	return release, nil
+}
+
+// lockSession acquires the per-session lock if Options.LockSessions is set.
+func (s *BoltStore) lockSession(ctx context.Context, session *sessions.Session) error {
+	if s.locker == nil {
+		return nil
+	}
+	release, err := s.locker.lock(ctx, session.ID)
+	if err != nil {
+		return err
+	}
+	setMeta(session, metaUnlock, release)
+	return nil
+}
+
+// unlockSession releases the per-session lock acquired on load, if any.
+func (s *BoltStore) unlockSession(session *sessions.Session) {
+	v, ok := getMeta(session, metaUnlock)
+	if !ok {
+		return
+	}
+	delete(session.Values, metaUnlock)
+	v.(func())()
+}
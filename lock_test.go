@@ -0,0 +1,68 @@
+package boltstore
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestKeyedLocker(t *testing.T) {
+	l := newKeyedLocker()
+	release, err := l.lock(context.Background(), "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// other keys are not blocked
+	releaseB, err := l.lock(context.Background(), "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	releaseB()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := l.lock(ctx, "a"); err == nil {
+		t.Fatal("expected lock timeout, got nil")
+	}
+
+	release()
+	release() // idempotent
+	release, err = l.lock(context.Background(), "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+
+	if n := len(l.shard("a").locks); n != 0 {
+		t.Errorf("Expected released locks to be dropped; Got %d", n)
+	}
+}
+
+func TestLockSessions(t *testing.T) {
+	store := newTestStore(t, Options{LockSessions: true})
+	cookie := saveNew(t, store, "session-key", nil)
+
+	reqA, a := loadCookie(t, store, "session-key", cookie)
+
+	loaded := make(chan struct{})
+	go func() {
+		loadCookie(t, store, "session-key", cookie)
+		close(loaded)
+	}()
+
+	select {
+	case <-loaded:
+		t.Fatal("second load is not serialized")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	if err := store.Save(reqA, NewRecorder(), a); err != nil {
+		t.Fatalf("Error saving session: %v", err)
+	}
+	select {
+	case <-loaded:
+	case <-time.After(time.Second):
+		t.Fatal("second load is not released after save")
+	}
+}
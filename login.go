@@ -0,0 +1,160 @@
+package boltstore
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/sessions"
+)
+
+// defaultSessionName is the session name of Login and Logout by default.
+const defaultSessionName = "session"
+
+// deviceKey is the session values key of the device a user logged in
+// from, stored JSON encoded so it needs no gob registration.
+const deviceKey = "_device"
+
+// LoginOptions configure Login.
+type LoginOptions struct {
+	Name     string                       // session name, "session" by default
+	Elevate  time.Duration                // elevate the session for this long, see Elevate (0 - not elevated)
+	ClientIP func(r *http.Request) string // IP address of the client, Options.ClientIP by default
+	Values   map[interface{}]interface{}  // values set in the session
+}
+
+// Device is the client a session was logged in from, see Login.
+type Device struct {
+	UserAgent   string    `json:"user_agent,omitempty"` // only recorded without Options.Fingerprint
+	Fingerprint []byte    `json:"fingerprint"`
+	IP          string    `json:"ip"`
+	LoginAt     time.Time `json:"login_at"`
+}
+
+// Login logs userID in: the session of the request gets a new ID, so an ID
+// set before login can't be used to hijack it, the user is stored under
+// Options.UserKey for RevokeUser, and the device is recorded, see
+// DeviceOf. The session is saved and its cookie set on w. Values of the
+// session set before login are kept.
+func Login(r *http.Request, w http.ResponseWriter, store *BoltStore, userID string, opts LoginOptions) (*sessions.Session, error) {
+	if store.options.UserKey == "" {
+		return nil, errors.New("login requires Options.UserKey")
+	}
+	if opts.Name == "" {
+		opts.Name = defaultSessionName
+	}
+	session, err := store.Get(r, opts.Name)
+	if err != nil && session == nil {
+		return nil, err
+	}
+	if old := session.ID; old != "" {
+		if store.Exists(old) {
+			if err := store.Delete(r.Context(), old); err != nil {
+				store.unlockSession(session)
+				return nil, err
+			}
+		}
+		session.ID = ""
+	}
+	session.IsNew = true
+	delete(session.Values, metaVersion)
+
+	for k, v := range opts.Values {
+		session.Values[k] = v
+	}
+	session.Values[store.options.UserKey] = userID
+	ip := store.clientIP(r)
+	if opts.ClientIP != nil {
+		ip = opts.ClientIP(r)
+	}
+	d := Device{Fingerprint: store.fingerprint(r), IP: ip, LoginAt: time.Now().UTC()}
+	if store.options.Fingerprint == nil {
+		d.UserAgent = r.UserAgent()
+	}
+	b, _ := json.Marshal(d)
+	session.Values[deviceKey] = string(b)
+	if opts.Elevate > 0 {
+		Elevate(session, opts.Elevate)
+	}
+	if err := store.Save(r, w, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// Logout deletes the stored session of the request and clears its cookie.
+//
+// A single variadic argument is accepted, and it is optional: it defines
+// the session name. If not defined "session" is used by default.
+func Logout(r *http.Request, w http.ResponseWriter, store *BoltStore, names ...string) error {
+	name := defaultSessionName
+	if len(names) > 0 {
+		name = names[0]
+	}
+	session, err := store.Get(r, name)
+	if err != nil && session == nil {
+		return err
+	}
+	for k := range session.Values {
+		if _, ok := k.(metaKey); !ok {
+			delete(session.Values, k)
+		}
+	}
+	session.Options.MaxAge = -1
+	if session.ID == "" {
+		// nothing stored, only clear the cookie
+		clearCookie(w, store.options, session)
+		return nil
+	}
+	return store.Save(r, w, session)
+}
+
+// DeviceOf returns the device the session was logged in from with Login.
+func DeviceOf(session *sessions.Session) (*Device, bool) {
+	s, ok := session.Values[deviceKey].(string)
+	if !ok {
+		return nil, false
+	}
+	var d Device
+	if err := json.Unmarshal([]byte(s), &d); err != nil {
+		return nil, false
+	}
+	return &d, true
+}
+
+// SameDevice reports whether the request comes from the device the session
+// was logged in from, comparing request fingerprints, see
+// Options.Fingerprint. Sessions not logged in with Login are never from the
+// same device.
+func SameDevice(r *http.Request, store *BoltStore, session *sessions.Session) bool {
+	d, ok := DeviceOf(session)
+	if !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare(d.Fingerprint, store.fingerprint(r)) == 1
+}
+
+// fingerprint returns the fingerprint of the request, see
+// Options.Fingerprint.
+func (s *BoltStore) fingerprint(r *http.Request) []byte {
+	if s.options.Fingerprint != nil {
+		return s.options.Fingerprint(r)
+	}
+	return []byte(r.UserAgent())
+}
+
+// clientIP returns the IP address of the client of the request, see
+// Options.ClientIP.
+func (s *BoltStore) clientIP(r *http.Request) string {
+	if s.options.ClientIP != nil {
+		return s.options.ClientIP(r)
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,74 @@
+package boltstore
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestLogin(t *testing.T) {
+	store := newTestStore(t, Options{UserKey: "user", LockSessions: true})
+	anon := saveNew(t, store, "session", map[interface{}]interface{}{"cart": 2})
+	req, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	req.Header.Add("Cookie", anon)
+	req.Header.Set("User-Agent", "test-agent")
+	req.RemoteAddr = "192.0.2.1:1234"
+	_, old := loadCookie(t, store, "session", anon)
+	store.unlockSession(old)
+
+	rsp := NewRecorder()
+	session, err := Login(req, rsp, store, "alice", LoginOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.ID == old.ID || store.Exists(old.ID) {
+		t.Error("Expected a new session ID and the old session deleted")
+	}
+	cookie := rsp.Header().Get("Set-Cookie")
+	_, session = loadCookie(t, store, "session", cookie)
+	if session.Values["user"] != "alice" || session.Values["cart"] != 2 {
+		t.Errorf("Expected logged in session keeping values; Got %v", session.Values)
+	}
+	if d, ok := DeviceOf(session); !ok || d.UserAgent != "test-agent" || d.IP != "192.0.2.1" {
+		t.Errorf("Expected device of the login; Got %+v", d)
+	}
+	id := session.ID
+	store.unlockSession(session)
+
+	req, _ = http.NewRequest("GET", "http://localhost:8080/", nil)
+	req.Header.Add("Cookie", cookie)
+	rsp = NewRecorder()
+	if err := Logout(req, rsp, store); err != nil {
+		t.Fatal(err)
+	}
+	if store.Exists(id) || !strings.Contains(rsp.Header().Get("Set-Cookie"), "Max-Age=0") {
+		t.Errorf("Expected deleted session and cleared cookie; Got %q", rsp.Header().Get("Set-Cookie"))
+	}
+
+	if _, err := Login(req, NewRecorder(), newTestStore(t, Options{}), "alice", LoginOptions{}); err == nil {
+		t.Error("Expected error without UserKey")
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	store := newTestStore(t, Options{UserKey: "user", Fingerprint: func(r *http.Request) []byte {
+		return []byte(r.Header.Get("X-Device"))
+	}})
+	req, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	req.Header.Set("User-Agent", "test-agent")
+	req.Header.Set("X-Device", "device-1")
+	session, err := Login(req, NewRecorder(), store, "alice", LoginOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d, _ := DeviceOf(session); d.UserAgent != "" || string(d.Fingerprint) != "device-1" {
+		t.Errorf("Expected only the fingerprint recorded; Got %+v", d)
+	}
+	if !SameDevice(req, store, session) {
+		t.Error("Expected the same device")
+	}
+	req.Header.Set("X-Device", "device-2")
+	if SameDevice(req, store, session) {
+		t.Error("Expected another device")
+	}
+}
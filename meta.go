@@ -0,0 +1,49 @@
+package boltstore
+
+import (
+	"github.com/gorilla/sessions"
+)
+
+// metaKey is the type of session.Values keys used by the store to keep
+// per-session control data. The type is unexported so the keys can't collide
+// with application keys, and such entries are never serialized.
+type metaKey int
+
+const (
+	metaVersion metaKey = iota // record version observed on load/save
+	metaUnlock                 // release func of the per-session lock
+	metaExpired                // expiration time of session data returned in grace mode
+	metaCookie                 // *sentCookie the session was loaded with
+	metaLoaded                 // duration of session load, see Options.ServerTiming
+	metaOnce                   // saved as single-use, see SingleUse
+	metaClient                 // IP address of the client saving a new session, see Options.MaxNewPerIP
+	metaPhases                 // *SaveHooks of the save, see SaveTwoPhase
+	metaAudit                  // *AuditEntry written by the save transaction, see Impersonate
+	metaRetired                // session replaced during the request, never saved again
+)
+
+// setMeta stores control value v in the session.
+func setMeta(session *sessions.Session, k metaKey, v interface{}) {
+	session.Values[k] = v
+}
+
+// getMeta returns control value of the session.
+func getMeta(session *sessions.Session, k metaKey) (interface{}, bool) {
+	v, ok := session.Values[k]
+	return v, ok
+}
+
+// stripMeta returns a shallow copy of the session without control values,
+// suitable for passing to the serializer.
+func stripMeta(session *sessions.Session) *sessions.Session {
+	values := make(map[interface{}]interface{}, len(session.Values))
+	for k, v := range session.Values {
+		if _, ok := k.(metaKey); ok {
+			continue
+		}
+		values[k] = v
+	}
+	clone := *session
+	clone.Values = values
+	return &clone
+}
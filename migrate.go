@@ -0,0 +1,125 @@
+package boltstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// MigrateBucket moves all sessions stored under bucket oldName (including
+// buckets of configured session names) to bucket newName within a single
+// transaction and removes the old buckets, so Options.BucketName can be
+// changed without losing live sessions. Sessions already stored under
+// newName are kept. Migrating away from the store's own bucket leaves the
+// store with empty buckets, recreated in the same transaction. Buckets of
+// other stores on the same db are not touched: migrating from or to them
+// fails with a *BucketConflictError.
+func (s *BoltStore) MigrateBucket(ctx context.Context, oldName, newName []byte) error {
+	if err := s.enter(); err != nil {
+		return err
+	}
+	defer s.leave()
+	if bytes.Equal(oldName, newName) {
+		return errors.New("migrate bucket to itself")
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	pairs := [][2][]byte{{oldName, newName}}
+	for name := range s.named {
+		pairs = append(pairs, [2][]byte{nameBucketName(oldName, name), nameBucketName(newName, name)})
+	}
+	names := [][]byte{controlBucketName(oldName), controlBucketName(newName), auditBucketName(oldName), auditBucketName(newName)}
+	for _, p := range pairs {
+		names = append(names, p[0], p[1])
+	}
+	if err := s.checkBuckets(names); err != nil {
+		return err
+	}
+	err := s.writeOnce(func(tx *bolt.Tx) error {
+		for _, p := range pairs {
+			src := tx.Bucket(p[0])
+			if src == nil {
+				continue
+			}
+			dst, err := tx.CreateBucketIfNotExists(p[1])
+			if err != nil {
+				return fmt.Errorf("create bucket %q error: %w", string(p[1]), err)
+			}
+			if err := copyBucket(ctx, dst, src); err != nil {
+				return fmt.Errorf("copy bucket %q error: %w", string(p[0]), err)
+			}
+			if err := tx.DeleteBucket(p[0]); err != nil {
+				return fmt.Errorf("delete bucket %q error: %w", string(p[0]), err)
+			}
+		}
+		// revocation epochs and the audit log move with the sessions, the
+		// count is derived and recounted for the new bucket
+		if old := tx.Bucket(controlBucketName(oldName)); old != nil {
+			control, err := tx.CreateBucketIfNotExists(controlBucketName(newName))
+			if err != nil {
+				return err
+			}
+			if err := copyBucket(ctx, control, old); err != nil {
+				return fmt.Errorf("copy control bucket error: %w", err)
+			}
+			if err := tx.DeleteBucket(controlBucketName(oldName)); err != nil {
+				return err
+			}
+		}
+		if old := tx.Bucket(auditBucketName(oldName)); old != nil {
+			audit, err := tx.CreateBucketIfNotExists(auditBucketName(newName))
+			if err != nil {
+				return err
+			}
+			if err := copyBucket(ctx, audit, old); err != nil {
+				return fmt.Errorf("copy audit bucket error: %w", err)
+			}
+			if err := tx.DeleteBucket(auditBucketName(oldName)); err != nil {
+				return err
+			}
+		}
+		if control := tx.Bucket(controlBucketName(newName)); control != nil {
+			if err := control.Delete(keyCount); err != nil {
+				return err
+			}
+		}
+		if bytes.Equal(newName, s.options.BucketName) || bytes.Equal(oldName, s.options.BucketName) {
+			return s.createBuckets(tx)
+		}
+		return nil
+	})
+	if s.loads != nil {
+		s.loads.purge()
+	}
+	if s.cache != nil {
+		s.cache.purge()
+	}
+	return err
+}
+
+// copyBucket copies nested buckets and keys of src which are absent in dst.
+func copyBucket(ctx context.Context, dst, src *bolt.Bucket) error {
+	return src.ForEach(func(k, v []byte) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if v != nil {
+			if dst.Get(k) != nil {
+				return nil
+			}
+			return dst.Put(k, v)
+		}
+		if dst.Bucket(k) != nil {
+			return nil
+		}
+		b, err := dst.CreateBucket(k)
+		if err != nil {
+			return err
+		}
+		return copyBucket(ctx, b, src.Bucket(k))
+	})
+}
@@ -0,0 +1,66 @@
+package boltstore
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/gorilla/sessions"
+)
+
+// namespacePrefix starts the session values keys of namespaces.
+const namespacePrefix = "_ns:"
+
+// NamespaceView is a key space of a session isolated from the session keys
+// and other namespaces, so independent features share the session cookie
+// and stored record without key collisions. Values are saved with the
+// session.
+type NamespaceView struct {
+	session *sessions.Session
+	prefix  string
+}
+
+// Namespace returns the view of the session key space named name, which
+// must not contain ':'.
+func Namespace(session *sessions.Session, name string) *NamespaceView {
+	return &NamespaceView{session: session, prefix: namespacePrefix + name + ":"}
+}
+
+// Get returns the value of key, nil if it is not set.
+func (ns *NamespaceView) Get(key string) interface{} {
+	return ns.session.Values[ns.prefix+key]
+}
+
+// Lookup returns the value of key and whether it is set.
+func (ns *NamespaceView) Lookup(key string) (interface{}, bool) {
+	v, ok := ns.session.Values[ns.prefix+key]
+	return v, ok
+}
+
+// Set sets the value of key.
+func (ns *NamespaceView) Set(key string, value interface{}) {
+	ns.session.Values[ns.prefix+key] = value
+}
+
+// Delete removes the value of key.
+func (ns *NamespaceView) Delete(key string) {
+	delete(ns.session.Values, ns.prefix+key)
+}
+
+// Keys returns the sorted keys set in the namespace.
+func (ns *NamespaceView) Keys() []string {
+	var keys []string
+	for k := range ns.session.Values {
+		if s, ok := k.(string); ok && strings.HasPrefix(s, ns.prefix) {
+			keys = append(keys, s[len(ns.prefix):])
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Clear removes all values of the namespace.
+func (ns *NamespaceView) Clear() {
+	for _, key := range ns.Keys() {
+		ns.Delete(key)
+	}
+}
@@ -0,0 +1,35 @@
+package boltstore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNamespace(t *testing.T) {
+	store := newTestStore(t, Options{})
+	req, session := loadCookie(t, store, "session-key", "")
+	session.Values["step"] = "root"
+	Namespace(session, "checkout").Set("step", "payment")
+	Namespace(session, "checkout").Set("cart", 3)
+	Namespace(session, "wizard").Set("step", 2)
+	rsp := NewRecorder()
+	if err := store.Save(req, rsp, session); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(rsp.Header()["Set-Cookie"]); n != 1 {
+		t.Errorf("Expected a single cookie; Got %d", n)
+	}
+
+	_, session = loadCookie(t, store, "session-key", rsp.Header().Get("Set-Cookie"))
+	checkout := Namespace(session, "checkout")
+	if session.Values["step"] != "root" || checkout.Get("step") != "payment" || Namespace(session, "wizard").Get("step") != 2 {
+		t.Errorf("Expected isolated key spaces; Got %v", session.Values)
+	}
+	if keys := checkout.Keys(); !reflect.DeepEqual(keys, []string{"cart", "step"}) {
+		t.Errorf("Expected checkout keys; Got %v", keys)
+	}
+	checkout.Clear()
+	if _, ok := checkout.Lookup("cart"); ok || Namespace(session, "wizard").Get("step") != 2 {
+		t.Errorf("Expected only checkout cleared; Got %v", session.Values)
+	}
+}
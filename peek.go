@@ -0,0 +1,38 @@
+package boltstore
+
+import (
+	"github.com/gorilla/sessions"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Peek returns the session with given id read-only: it bypasses locks and
+// caches and never writes to the store, so the expiry isn't refreshed and
+// access metadata isn't updated. Returns ErrNotFound if there is no active
+// session.
+func (s *BoltStore) Peek(id string) (*sessions.Session, error) {
+	if err := s.enter(); err != nil {
+		return nil, err
+	}
+	defer s.leave()
+	var session *sessions.Session
+	err := s.db.View(func(tx *bolt.Tx) error {
+		_, spec := s.findTx(tx, []byte(id), s.buckets[0])
+		if spec == nil {
+			return ErrNotFound
+		}
+		session = s.newSession(s.sessionName(spec), id)
+		rec, err := s.readTx(tx, session)
+		if err != nil {
+			return err
+		}
+		if rec == nil || rec.expired(s.expiryNow()) {
+			return ErrNotFound
+		}
+		rec.apply(session)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
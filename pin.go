@@ -0,0 +1,61 @@
+package boltstore
+
+import (
+	"context"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Pin marks the active session with given id as pinned: eviction policies
+// such as Options.IdleTimeout skip it, e.g. for service accounts or kiosk
+// devices. Pinned sessions still expire and can be deleted.
+// Returns ErrNotFound if there is no active session.
+func (s *BoltStore) Pin(id string) error {
+	if err := s.enter(); err != nil {
+		return err
+	}
+	defer s.leave()
+	return s.setPinned(id, true)
+}
+
+// Unpin removes the pin of the session with given id, see Pin.
+func (s *BoltStore) Unpin(id string) error {
+	if err := s.enter(); err != nil {
+		return err
+	}
+	defer s.leave()
+	return s.setPinned(id, false)
+}
+
+func (s *BoltStore) setPinned(id string, pinned bool) error {
+	return s.write(func(tx *bolt.Tx) error {
+		b := s.activeBucket(tx, []byte(id), time.Now())
+		if b == nil {
+			return ErrNotFound
+		}
+		if !pinned {
+			return b.Delete(keyPinned)
+		}
+		return b.Put(keyPinned, []byte{1})
+	})
+}
+
+// Pinned returns IDs of active pinned sessions.
+func (s *BoltStore) Pinned(ctx context.Context) ([]string, error) {
+	if err := s.enter(); err != nil {
+		return nil, err
+	}
+	defer s.leave()
+	var ids []string
+	now := time.Now()
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return s.eachTx(ctx, tx, func(_ *bucketSpec, id []byte, b *bolt.Bucket) error {
+			if b.Get(keyPinned) != nil && s.activeBucket(tx, id, now) != nil {
+				ids = append(ids, string(id))
+			}
+			return nil
+		})
+	})
+	return ids, err
+}
@@ -0,0 +1,43 @@
+package boltstore
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPin(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t, Options{IdleTimeout: time.Hour})
+	cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 1})
+	_, session := loadCookie(t, store, "session-key", cookie)
+	if err := store.Pin(session.ID); err != nil {
+		t.Fatal(err)
+	}
+	if ids, err := store.Pinned(ctx); err != nil || len(ids) != 1 || ids[0] != session.ID {
+		t.Errorf("Expected pinned session; Got %v, %v", ids, err)
+	}
+	if info, _ := store.Info(session.ID); !info.Pinned {
+		t.Error("Expected pinned info")
+	}
+
+	setLastAccess(t, store, session.ID, time.Now().Add(-2*time.Hour))
+	store.reapBucket(store.buckets[0])
+	if !store.Exists(session.ID) {
+		t.Fatal("Expected pinned session not to be evicted")
+	}
+
+	if err := store.Unpin(session.ID); err != nil {
+		t.Fatal(err)
+	}
+	if ids, _ := store.Pinned(ctx); len(ids) != 0 {
+		t.Errorf("Expected no pinned sessions; Got %v", ids)
+	}
+	store.reapBucket(store.buckets[0])
+	if store.Exists(session.ID) {
+		t.Error("Expected unpinned idle session to be evicted")
+	}
+	if err := store.Pin("missing"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound; Got %v", err)
+	}
+}
@@ -0,0 +1,233 @@
+package boltstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/gorilla/sessions"
+	bolt "go.etcd.io/bbolt"
+)
+
+// PipePosition is the last record read by Pipe, which is resumed after it.
+type PipePosition struct {
+	Bucket string `json:"bucket"`
+	ID     string `json:"id"`
+}
+
+// PipeProgress is the progress of Pipe, reported after every written batch.
+type PipeProgress struct {
+	Read    int          // active sessions read
+	Written int          // records written to the sink
+	Dropped int          // records dropped by the transform
+	Last    PipePosition // last record read, Pipe resumes after it with PipeOptions.After
+}
+
+// PipeOptions configure Pipe.
+type PipeOptions struct {
+	// Transform returns the record to write for a read one, for example
+	// with keys stripped or Data serialized with another serializer. A nil
+	// record is dropped, an error stops Pipe. Records are written as read
+	// by default.
+	Transform func(rec *SessionRecord) (*SessionRecord, error)
+	// After is the position to resume a stopped Pipe after, zero - from
+	// the first record.
+	After PipePosition
+	// Progress is called after every written batch.
+	Progress func(PipeProgress)
+}
+
+// PipeSink is the destination of records of Pipe.
+type PipeSink interface {
+	// WriteRecords writes a batch of records. It returns the number of
+	// records written, records skipped by the sink are not counted.
+	WriteRecords(ctx context.Context, recs []*SessionRecord) (int, error)
+}
+
+// Pipe reads every active session, in ID order within each sessions
+// bucket, applies opts.Transform and writes the records to dst in batches
+// of up to 1000, for migrations of serializers, keys or contents. Each
+// batch is read in its own read transaction, so dst may be ReplaceSink of
+// the same store and other writes are not blocked for the whole run;
+// sessions saved during the run may be missed. A stopped Pipe is resumed
+// with the last reported position. Read records have decoded Values and no
+// Data.
+func (s *BoltStore) Pipe(ctx context.Context, dst PipeSink, opts PipeOptions) (PipeProgress, error) {
+	if sink, ok := dst.(storeSink); ok && sink.store == s && !sink.replace {
+		return PipeProgress{}, errors.New("pipe into the same store skips all sessions, use ReplaceSink")
+	}
+	if err := s.enter(); err != nil {
+		return PipeProgress{}, err
+	}
+	defer s.leave()
+	p := PipeProgress{Last: opts.After}
+	for {
+		if err := ctx.Err(); err != nil {
+			return p, err
+		}
+		var batch []*SessionRecord
+		last := p.Last
+		err := s.db.View(func(tx *bolt.Tx) error {
+			var err error
+			batch, err = s.pipeBatchTx(tx, &last)
+			return err
+		})
+		if err != nil {
+			return p, s.traced(ctx, err)
+		}
+		if len(batch) == 0 {
+			return p, nil
+		}
+		p.Read += len(batch)
+		if opts.Transform != nil {
+			recs := batch[:0]
+			for _, in := range batch {
+				rec, err := opts.Transform(in)
+				if err != nil {
+					return p, fmt.Errorf("transform session %q error: %w", s.safeID(in.ID), err)
+				}
+				if rec == nil {
+					p.Dropped++
+					continue
+				}
+				recs = append(recs, rec)
+			}
+			batch = recs
+		}
+		n, err := dst.WriteRecords(ctx, batch)
+		p.Written += n
+		if err != nil {
+			return p, fmt.Errorf("write records error: %w", err)
+		}
+		p.Last = last
+		if opts.Progress != nil {
+			opts.Progress(p)
+		}
+	}
+}
+
+// pipeBatchTx reads a batch of active sessions after position last within
+// transaction tx, moving last to the last one read.
+func (s *BoltStore) pipeBatchTx(tx *bolt.Tx, last *PipePosition) ([]*SessionRecord, error) {
+	var batch []*SessionRecord
+	now := s.expiryNow()
+	started := last.Bucket == ""
+	for _, spec := range s.buckets {
+		if !started {
+			// buckets before the position are done
+			started = string(spec.name) == last.Bucket
+			if !started {
+				continue
+			}
+		}
+		name := s.sessionName(spec)
+		c := tx.Bucket(spec.name).Cursor()
+		k, v := c.First()
+		if string(spec.name) == last.Bucket {
+			if k, v = c.Seek([]byte(last.ID)); bytes.Equal(k, []byte(last.ID)) {
+				k, v = c.Next()
+			}
+		}
+		for ; k != nil; k, v = c.Next() {
+			if v != nil {
+				// not a session bucket
+				continue
+			}
+			*last = PipePosition{Bucket: string(spec.name), ID: string(k)}
+			session := s.newSession(name, string(k))
+			rec, err := s.readTx(tx, session)
+			if err != nil {
+				return nil, err
+			}
+			if rec == nil || rec.expired(now) {
+				continue
+			}
+			batch = append(batch, &SessionRecord{ID: string(k), Name: name, Values: rec.values, ExpiresAt: rec.expiresAt})
+			if len(batch) == importBatchSize {
+				return batch, nil
+			}
+		}
+	}
+	if !started {
+		return nil, fmt.Errorf("no sessions bucket %q to resume after", last.Bucket)
+	}
+	return batch, nil
+}
+
+// StoreSink returns a PipeSink importing records into store, see
+// ImportSessions. Sessions already stored in it are skipped.
+func StoreSink(store *BoltStore) PipeSink {
+	return storeSink{store: store}
+}
+
+// ReplaceSink returns a PipeSink importing records into store like
+// StoreSink, replacing values and expiry of sessions already stored in it,
+// for rewriting sessions of a store in place.
+func ReplaceSink(store *BoltStore) PipeSink {
+	return storeSink{store: store, replace: true}
+}
+
+// storeSink is the PipeSink of StoreSink and ReplaceSink.
+type storeSink struct {
+	store   *BoltStore
+	replace bool
+}
+
+func (sink storeSink) WriteRecords(ctx context.Context, recs []*SessionRecord) (int, error) {
+	if err := sink.store.enter(); err != nil {
+		return 0, err
+	}
+	defer sink.store.leave()
+	return sink.store.importSessions(ctx, func() (*SessionRecord, bool) {
+		if len(recs) == 0 {
+			return nil, false
+		}
+		rec := recs[0]
+		recs = recs[1:]
+		return rec, true
+	}, sink.replace)
+}
+
+// WriterSink returns a PipeSink writing records to w as newline-delimited
+// JSON in the canonical format, like Export of store: records get the
+// buckets of their session names in store, and values of records without
+// Data are serialized with the serializers of the buckets.
+func WriterSink(w io.Writer, store *BoltStore) PipeSink {
+	return writerSink{enc: json.NewEncoder(w), store: store}
+}
+
+// writerSink is the PipeSink of WriterSink.
+type writerSink struct {
+	enc   *json.Encoder
+	store *BoltStore
+}
+
+func (sink writerSink) WriteRecords(ctx context.Context, recs []*SessionRecord) (int, error) {
+	for i, rec := range recs {
+		spec := sink.store.bucketOf(rec.Name)
+		data := rec.Data
+		if data == nil {
+			var err error
+			data, err = spec.serial.Serialize(&sessions.Session{Values: rec.Values})
+			if err != nil {
+				return i, fmt.Errorf("serialize session error: %w", typeError(err))
+			}
+		}
+		out := &ExportedRecord{
+			Format:     RecordFormat,
+			ID:         rec.ID,
+			Bucket:     string(spec.name),
+			Serializer: serializerID(spec.serial),
+			Version:    1,
+			ExpiresAt:  rec.ExpiresAt.UTC(),
+			Data:       data,
+		}
+		if err := sink.enc.Encode(out); err != nil {
+			return i, err
+		}
+	}
+	return len(recs), nil
+}
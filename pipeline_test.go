@@ -0,0 +1,112 @@
+package boltstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/gorilla/sessions"
+)
+
+func TestPipe(t *testing.T) {
+	ctx := context.Background()
+	opts := Options{Names: map[string]NameOptions{"flash": {}}}
+	src := newTestStore(t, opts)
+	for i := 0; i < 3; i++ {
+		saveNew(t, src, "session-key", map[interface{}]interface{}{"n": i, "secret": "s"})
+	}
+	saveNew(t, src, "flash", map[interface{}]interface{}{"n": 3})
+
+	dst := newTestStore(t, Options{Names: opts.Names, Serializer: JSONSerializer{}})
+	transform := func(rec *SessionRecord) (*SessionRecord, error) {
+		if rec.Values["n"] == 0 {
+			return nil, nil
+		}
+		delete(rec.Values, "secret")
+		return rec, nil
+	}
+	var reports []PipeProgress
+	p, err := src.Pipe(ctx, StoreSink(dst), PipeOptions{Transform: transform, Progress: func(p PipeProgress) { reports = append(reports, p) }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Read != 4 || p.Written != 3 || p.Dropped != 1 || len(reports) != 1 {
+		t.Errorf("Expected 4 read, 3 written and 1 dropped records; Got %+v, %d reports", p, len(reports))
+	}
+	if p.Last.Bucket != string(nameBucketName(src.options.BucketName, "flash")) {
+		t.Errorf("Expected the flash session read last; Got %+v", p.Last)
+	}
+	err = src.ForEach(ctx, func(id string, _ *sessions.Session) error {
+		session, err := dst.Peek(id)
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if _, ok := session.Values["secret"]; ok {
+			t.Errorf("Expected stripped values; Got %v", session.Values)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := dst.Count(ctx); n != 3 {
+		t.Errorf("Expected 3 piped sessions; Got %d", n)
+	}
+
+	// resumed after the last record
+	if p, err := src.Pipe(ctx, StoreSink(dst), PipeOptions{After: p.Last}); err != nil || p.Read != 0 {
+		t.Errorf("Expected nothing left after the last record; Got %+v, %v", p, err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := src.Pipe(ctx, WriterSink(&buf, dst), PipeOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	var lines int
+	for dec := json.NewDecoder(&buf); dec.More(); lines++ {
+		var rec ExportedRecord
+		if err := dec.Decode(&rec); err != nil {
+			t.Fatal(err)
+		}
+		if rec.Serializer != "json" {
+			t.Errorf("Expected records serialized for the destination; Got %q", rec.Serializer)
+		}
+	}
+	if lines != 4 {
+		t.Errorf("Expected 4 written records; Got %d", lines)
+	}
+}
+
+func TestPipeInPlace(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t, Options{})
+	cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 1, "secret": "s"})
+	_, session := loadCookie(t, store, "session-key", cookie)
+
+	strip := func(rec *SessionRecord) (*SessionRecord, error) {
+		delete(rec.Values, "secret")
+		return rec, nil
+	}
+	if _, err := store.Pipe(ctx, StoreSink(store), PipeOptions{Transform: strip}); err == nil {
+		t.Error("Expected error piping into the same store without replacing")
+	}
+	p, err := store.Pipe(ctx, ReplaceSink(store), PipeOptions{Transform: strip})
+	if err != nil || p.Written != 1 {
+		t.Fatalf("Expected 1 replaced session; Got %+v, %v", p, err)
+	}
+	got, err := store.Peek(session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := got.Values["secret"]; ok || got.Values["n"] != 1 {
+		t.Errorf("Expected stripped values; Got %v", got.Values)
+	}
+	if n, _ := store.Count(ctx); n != 1 {
+		t.Errorf("Expected 1 session; Got %d", n)
+	}
+}
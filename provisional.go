@@ -0,0 +1,42 @@
+package boltstore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gorilla/sessions"
+	bolt "go.etcd.io/bbolt"
+)
+
+// provisional reports whether new sessions of bucket spec are stored with
+// Options.ProvisionalExpire instead of the expire of the bucket.
+func (s *BoltStore) provisional(spec *bucketSpec) bool {
+	return s.options.ProvisionalExpire > 0 && s.options.ProvisionalExpire < spec.expire
+}
+
+// promote extends the provisional session to the full expire of its
+// bucket, on the first load after the session was created. Concurrent
+// loads may both promote it.
+func (s *BoltStore) promote(ctx context.Context, session *sessions.Session) error {
+	err := s.write(func(tx *bolt.Tx) error {
+		bucket, spec := s.findTx(tx, []byte(session.ID), s.bucketOf(session.Name()))
+		if bucket == nil || bucket.Get(keyDeletedUntil) != nil || bucket.Get(keyProvisional) == nil {
+			return nil
+		}
+		expiresAt := time.Now().Add(spec.expire)
+		if createdAt, ok := decodeExpiry(bucket.Get(keyCreatedAt)); ok && s.options.MaxLifetime > 0 {
+			if end := createdAt.Add(s.options.MaxLifetime); expiresAt.After(end) {
+				expiresAt = end
+			}
+		}
+		if err := bucket.Put(keyExpiredAt, encodeExpiry(expiresAt)); err != nil {
+			return fmt.Errorf("put session expireAt to store error: %w", err)
+		}
+		if err := bucket.Delete(keyProvisional); err != nil {
+			return fmt.Errorf("delete session provisional flag error: %w", err)
+		}
+		return nil
+	})
+	return s.traced(ctx, err)
+}
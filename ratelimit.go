@@ -0,0 +1,48 @@
+package boltstore
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// keyClients is the control bucket key of the bucket of per-client counts
+// of created sessions, see Options.MaxNewPerIP.
+var keyClients = []byte("clients")
+
+// clientWindow is the period new sessions of a client are counted in.
+const clientWindow = time.Minute
+
+// throttleTx counts a session created for client ip within transaction tx.
+// returns ErrRateLimited if the client already created Options.MaxNewPerIP
+// sessions within the current minute.
+func (s *BoltStore) throttleTx(tx *bolt.Tx, ip string) error {
+	clients, err := tx.Bucket(controlBucketName(s.options.BucketName)).CreateBucketIfNotExists(keyClients)
+	if err != nil {
+		return fmt.Errorf("create clients bucket error: %w", err)
+	}
+	// 8 bytes of the window start unix seconds and 8 bytes of the count
+	now := uint64(time.Now().Unix())
+	var start, n uint64
+	if v := clients.Get([]byte(ip)); len(v) == 16 {
+		start, n = binary.BigEndian.Uint64(v), binary.BigEndian.Uint64(v[8:])
+	}
+	if now >= start+uint64(clientWindow/time.Second) {
+		start, n = now, 0
+	}
+	if n >= uint64(s.options.MaxNewPerIP) {
+		return ErrRateLimited
+	}
+	v := make([]byte, 16)
+	binary.BigEndian.PutUint64(v, start)
+	binary.BigEndian.PutUint64(v[8:], n+1)
+	return clients.Put([]byte(ip), v)
+}
+
+// clientExpired reports whether the count window of client count v passed
+// at now.
+func clientExpired(v []byte, now time.Time) bool {
+	return len(v) != 16 || int64(binary.BigEndian.Uint64(v))+int64(clientWindow/time.Second) <= now.Unix()
+}
@@ -0,0 +1,52 @@
+package boltstore
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestMaxNewPerIP(t *testing.T) {
+	store := newTestStore(t, Options{MaxNewPerIP: 2})
+	create := func(addr string) error {
+		req, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+		req.RemoteAddr = addr
+		session, _ := store.New(req, "session-key")
+		return store.Save(req, NewRecorder(), session)
+	}
+	for i := 0; i < 2; i++ {
+		if err := create("192.0.2.1:1000"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := create("192.0.2.1:2000"); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Expected rate limited client; Got %v", err)
+	}
+	if err := create("192.0.2.2:1000"); err != nil {
+		t.Errorf("Expected other clients not limited; Got %v", err)
+	}
+	if n, _ := store.Count(context.Background()); n != 3 {
+		t.Errorf("Expected 3 stored sessions; Got %d", n)
+	}
+
+	// counts of passed windows are reaped
+	store.DB().Update(func(tx *bolt.Tx) error {
+		clients := tx.Bucket(controlBucketName(store.options.BucketName)).Bucket(keyClients)
+		return clients.Put([]byte("192.0.2.1"), make([]byte, 16))
+	})
+	if _, err := store.Reap(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	store.DB().View(func(tx *bolt.Tx) error {
+		if n := tx.Bucket(controlBucketName(store.options.BucketName)).Bucket(keyClients).Stats().KeyN; n != 1 {
+			t.Errorf("Expected the passed window reaped; Got %d counts", n)
+		}
+		return nil
+	})
+	if err := create("192.0.2.1:1000"); err != nil {
+		t.Errorf("Expected client allowed in a new window; Got %v", err)
+	}
+}
@@ -4,13 +4,15 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"strconv"
 	"time"
 
 	bolt "go.etcd.io/bbolt"
 )
 
+// worker reaps expired sessions every ReapCheckInterval until ctx is done.
+// a reaping pass in progress is finished before it returns.
 func (s *BoltStore) worker(ctx context.Context) {
+	defer s.bg.Done()
 
 	// Create a new ticker
 	ticker := time.NewTicker(s.options.ReapCheckInterval)
@@ -23,81 +25,210 @@ func (s *BoltStore) worker(ctx context.Context) {
 			return
 
 		case <-ticker.C: // Check if the ticker fires a signal.
-			// This slice is a buffer to save all expired session keys.
-			expiredSessionKeys := make([][]byte, 0)
+			if _, err := s.reap(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("boltstore: %v", err)
+			}
+			s.flushAccesses()
+			s.pruneDecodes()
+		}
+	}
+}
 
-			// Start a bolt read transaction.
-			err := s.db.View(func(tx *bolt.Tx) error {
+// Reap runs a single reaping pass, as the reaper does every
+// ReapCheckInterval: expired, idle and soft deleted sessions past their undo
+// window are removed and pending access counts are persisted. With
+// Options.NoReaper it is the way to clean up the store from an own scheduler.
+// returns the count of removed sessions.
+func (s *BoltStore) Reap(ctx context.Context) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if err := s.enter(); err != nil {
+		return 0, err
+	}
+	defer s.leave()
+	n, err := s.reap(ctx)
+	s.flushAccesses()
+	return n, s.traced(ctx, err)
+}
 
-				bucket := tx.Bucket(s.options.BucketName)
-				if bucket == nil {
-					return nil
-				}
+// reap removes reapable sessions from all sessions buckets until ctx is done.
+// returns the count of removed sessions and the first error.
+func (s *BoltStore) reap(ctx context.Context) (int, error) {
+	// one reaping pass at a time for stores sharing the db
+	s.host.reapMu.Lock()
+	defer s.host.reapMu.Unlock()
+	var (
+		total    int
+		firstErr error
+	)
+	for _, spec := range s.buckets {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+		n, err := s.reapBucket(spec)
+		total += n
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if err := s.reapControl(ctx, keyHandoffs, handoffExpired); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	if err := s.reapControl(ctx, keyClients, clientExpired); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	return total, firstErr
+}
 
-				var isExpired bool
-				bucket.ForEach(func(k, v []byte) error {
-
-					isExpired = false
-					defer func() {
-						if isExpired {
-							temp := make([]byte, len(k))
-							copy(temp, k)
-							expiredSessionKeys = append(expiredSessionKeys, temp)
-						}
-					}()
-
-					sessionBucket := bucket.Bucket(k)
-					if sessionBucket == nil {
-						return fmt.Errorf("invalid session bucket %s/%s for reap", string(s.options.BucketName), string(k))
-					}
-
-					// expiredAt key
-					ev := sessionBucket.Get(keyExpiredAt)
-					if ev == nil {
-						isExpired = true
-					}
-
-					expiredAt, err := strconv.ParseInt(string(ev), 10, 64)
-					if err != nil {
-						isExpired = true
-					} else {
-						isExpired = time.Unix(expiredAt, 0).Before(time.Now())
-					}
-
-					return nil
-				})
+// reapBucket removes expired sessions from the sessions bucket.
+// returns the count of removed sessions.
+func (s *BoltStore) reapBucket(spec *bucketSpec) (int, error) {
+	// This slice is a buffer to save all expired session keys.
+	expiredSessionKeys := make([][]byte, 0)
 
-				return nil
-			})
+	// Start a bolt read transaction.
+	err := s.db.View(func(tx *bolt.Tx) error {
+
+		bucket := tx.Bucket(spec.name)
+		if bucket == nil {
+			return nil
+		}
+
+		var expired bool
+		bucket.ForEach(func(k, v []byte) error {
+
+			expired = false
+			defer func() {
+				if expired {
+					temp := make([]byte, len(k))
+					copy(temp, k)
+					expiredSessionKeys = append(expiredSessionKeys, temp)
+				}
+			}()
 
-			if err != nil {
-				log.Printf("boltstore: obtain expired sessions error: %v", err)
+			sessionBucket := bucket.Bucket(k)
+			if sessionBucket == nil {
+				return fmt.Errorf("invalid session bucket %s/%s for reap", string(spec.name), s.safeID(string(k)))
 			}
 
-			if len(expiredSessionKeys) > 0 {
-				// Remove the expired sessions from the database
-				err = s.db.Update(func(txu *bolt.Tx) error {
+			expired = s.reapable(sessionBucket, time.Now())
+
+			return nil
+		})
+
+		return nil
+	})
 
-					b := txu.Bucket(s.options.BucketName)
-					if b == nil {
-						return nil
-					}
+	if err != nil {
+		return 0, fmt.Errorf("obtain expired sessions error: %w", err)
+	}
 
-					// Remove all expired sessions in the slice
-					for _, key := range expiredSessionKeys {
-						err = b.DeleteBucket(key)
-						if err != nil {
-							return err
-						}
-					}
+	if len(expiredSessionKeys) > 0 {
+		// Remove the expired sessions from the database
+		err = s.writeOnce(func(txu *bolt.Tx) error {
 
-					return nil
-				})
+			b := txu.Bucket(spec.name)
+			if b == nil {
+				return nil
+			}
 
+			// Remove all expired sessions in the slice
+			for _, key := range expiredSessionKeys {
+				err = b.DeleteBucket(key)
 				if err != nil {
-					log.Printf("boltstore: remove expired sessions error: %v", err)
+					return err
 				}
 			}
+
+			return s.addCount(txu, -int64(len(expiredSessionKeys)))
+		})
+
+		if err != nil {
+			return 0, fmt.Errorf("remove expired sessions error: %w", err)
+		}
+		for _, key := range expiredSessionKeys {
+			s.invalidate(context.Background(), string(key), EventExpire)
+		}
+	}
+	return len(expiredSessionKeys), nil
+}
+
+// reapable reports whether the session stored in bucket b should be removed
+// by the reaper at time now.
+func (s *BoltStore) reapable(b *bolt.Bucket, now time.Time) bool {
+	// soft deleted sessions are kept until the end of undo window
+	if v := b.Get(keyDeletedUntil); v != nil {
+		return isExpired(v, now)
+	}
+	// orphaned buckets without values, e.g. left by older versions
+	if b.Get(keyValues) == nil {
+		return true
+	}
+	// idle sessions, unless pinned; last access is written with AccessResolution precision
+	if s.options.IdleTimeout > 0 && b.Get(keyPinned) == nil {
+		if at, ok := decodeExpiry(b.Get(keyLastAccess)); ok && at.Add(s.options.IdleTimeout).Before(now) {
+			return true
+		}
+	}
+	if s.outlived(b, now) {
+		return true
+	}
+	// expiredAt key
+	return isExpired(b.Get(keyExpiredAt), now.Add(-s.options.ExpiredGrace-s.options.ClockSkew))
+}
+
+// outlived reports whether the session stored in bucket b is older than
+// Options.MaxLifetime at time now. Expiries are capped at the max lifetime on
+// save, this catches sessions saved before the option was set.
+func (s *BoltStore) outlived(b *bolt.Bucket, now time.Time) bool {
+	if s.options.MaxLifetime <= 0 {
+		return false
+	}
+	at, ok := decodeExpiry(b.Get(keyCreatedAt))
+	return ok && !at.Add(s.options.MaxLifetime).After(now)
+}
+
+// reapControl removes entries of the control bucket nested under key which
+// are expired at the time of the pass.
+func (s *BoltStore) reapControl(ctx context.Context, key []byte, expired func(v []byte, now time.Time) bool) error {
+	now := time.Now()
+	var keys [][]byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := controlNested(tx, s.options.BucketName, key)
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			if expired(v, now) {
+				keys = append(keys, append([]byte{}, k...))
+			}
+			return ctx.Err()
+		})
+	})
+	if err != nil || len(keys) == 0 {
+		return err
+	}
+	return s.writeOnce(func(tx *bolt.Tx) error {
+		b := controlNested(tx, s.options.BucketName, key)
+		if b == nil {
+			return nil
+		}
+		for _, k := range keys {
+			if err := b.Delete(k); err != nil {
+				return fmt.Errorf("remove expired %s error: %w", key, err)
+			}
 		}
+		return nil
+	})
+}
+
+// controlNested returns the bucket nested under key in the control bucket of
+// bucket, nil if either doesn't exist.
+func controlNested(tx *bolt.Tx, bucket, key []byte) *bolt.Bucket {
+	control := tx.Bucket(controlBucketName(bucket))
+	if control == nil {
+		return nil
 	}
+	return control.Bucket(key)
 }
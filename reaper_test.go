@@ -0,0 +1,72 @@
+package boltstore
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestReap(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t, Options{NoReaper: true, ReapCheckInterval: time.Millisecond})
+	saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 1})
+	cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 2})
+	_, session := loadCookie(t, store, "session-key", cookie)
+	setExpiry(t, store, session.ID, time.Now().Add(-time.Minute))
+
+	time.Sleep(10 * time.Millisecond)
+	if n, _ := store.Count(ctx); n != 2 {
+		t.Fatalf("Expected no reaping without reaper; Got %d sessions", n)
+	}
+	if n, err := store.Reap(ctx); err != nil || n != 1 {
+		t.Errorf("Expected 1 reaped session; Got %d %v", n, err)
+	}
+	if n, _ := store.Count(ctx); n != 1 {
+		t.Errorf("Expected 1 session; Got %d", n)
+	}
+	store.Close()
+	if _, err := store.Reap(ctx); err != ErrStoreClosed {
+		t.Errorf("Expected ErrStoreClosed; Got %v", err)
+	}
+}
+
+func TestExpirations(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	store := newTestStore(t, Options{NoReaper: true})
+	expired := store.Expirations(ctx)
+	cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 1})
+	_, session := loadCookie(t, store, "session-key", cookie)
+	setExpiry(t, store, session.ID, time.Now().Add(-time.Minute))
+	saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 2})
+
+	if _, err := store.Reap(ctx); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case id := <-expired:
+		if id != session.ID {
+			t.Errorf("Expected expired %q; Got %q", session.ID, id)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected expired session ID")
+	}
+	cancel()
+	if _, ok := <-expired; ok {
+		t.Error("Expected channel closed with ctx")
+	}
+
+	store.Close()
+	if _, ok := <-store.Expirations(context.Background()); ok {
+		t.Error("Expected closed channel of closed store")
+	}
+}
+
+func TestSendExpiredDropsOldest(t *testing.T) {
+	ch := make(chan string, 2)
+	for _, id := range []string{"a", "b", "c"} {
+		sendExpired(ch, id)
+	}
+	if a, b := <-ch, <-ch; a != "b" || b != "c" {
+		t.Errorf("Expected latest IDs; Got %q %q", a, b)
+	}
+}
@@ -0,0 +1,72 @@
+package boltstore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// RedactID returns a short hash of session id, safe to log: the same ID is
+// always redacted the same, so log lines stay matchable, but the ID can't be
+// recovered from it. Applications can use it in their own logs to match
+// store errors with Options.RedactIDs.
+func RedactID(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return "sha256:" + hex.EncodeToString(sum[:6])
+}
+
+// safeID returns session id for errors and hook payloads, redacted if
+// Options.RedactIDs is set.
+func (s *BoltStore) safeID(id string) string {
+	if s.options.RedactIDs {
+		return RedactID(id)
+	}
+	return id
+}
+
+// RedactFunc returns a redacted copy of a session value, safe to leave the
+// store through exports and tooling.
+type RedactFunc func(v interface{}) interface{}
+
+// RegisterRedactor registers fn to redact values of session key whenever
+// sessions are exported or passed through RedactValues, so tokens and PII
+// never leave the store in cleartext through tooling paths. Snapshot is a
+// full-fidelity backup and is not redacted.
+func (s *BoltStore) RegisterRedactor(key string, fn RedactFunc) {
+	s.hooks.mu.Lock()
+	defer s.hooks.mu.Unlock()
+	if s.hooks.redact == nil {
+		s.hooks.redact = make(map[string]RedactFunc)
+	}
+	s.hooks.redact[key] = fn
+}
+
+// RedactValues returns a copy of session values with registered redactors
+// applied, for admin views and logging.
+func (s *BoltStore) RedactValues(values map[interface{}]interface{}) map[interface{}]interface{} {
+	s.hooks.mu.RLock()
+	defer s.hooks.mu.RUnlock()
+	redacted := make(map[interface{}]interface{}, len(values))
+	for k, v := range values {
+		if ks, ok := k.(string); ok && s.hooks.redact[ks] != nil {
+			v = s.hooks.redact[ks](v)
+		}
+		redacted[k] = v
+	}
+	return redacted
+}
+
+// redactRecord applies registered redactors to values of exported record
+// rec. Raw data is dropped if any redactors are registered.
+func (s *BoltStore) redactRecord(rec *ExportedRecord) {
+	s.hooks.mu.RLock()
+	defer s.hooks.mu.RUnlock()
+	if len(s.hooks.redact) == 0 {
+		return
+	}
+	rec.Data = nil
+	for k, fn := range s.hooks.redact {
+		if v, ok := rec.Values[k]; ok {
+			rec.Values[k] = fn(v)
+		}
+	}
+}
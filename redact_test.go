@@ -0,0 +1,64 @@
+package boltstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestRedactIDs(t *testing.T) {
+	store := newTestStore(t, Options{RedactIDs: true})
+	var changes []Change
+	store.OnChange(func(c Change) { changes = append(changes, c) })
+	cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 1})
+	_, session := loadCookie(t, store, "session-key", cookie)
+
+	if len(changes) != 1 || changes[0].ID != RedactID(session.ID) || strings.Contains(changes[0].ID, session.ID) {
+		t.Errorf("Expected redacted change ID; Got %+v", changes)
+	}
+	if RedactID(session.ID) != RedactID(session.ID) || RedactID(session.ID) == RedactID("other") {
+		t.Error("Expected stable distinct redacted IDs")
+	}
+
+	id := session.ID
+	store.DB().Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(store.options.BucketName).Bucket([]byte(id)).Put(keyValues, []byte("garbage"))
+	})
+	req, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	req.Header.Add("Cookie", cookie)
+	_, err := store.New(req, "session-key")
+	if err == nil || strings.Contains(err.Error(), id) || !strings.Contains(err.Error(), RedactID(id)) {
+		t.Errorf("Expected redacted ID in error; Got %v", err)
+	}
+}
+
+func TestRegisterRedactor(t *testing.T) {
+	store := newTestStore(t, Options{})
+	store.RegisterRedactor("email", func(v interface{}) interface{} {
+		s, _ := v.(string)
+		return s[:1] + "***"
+	})
+	saveNew(t, store, "session-key", map[interface{}]interface{}{"user": "bob", "email": "bob@example.com"})
+
+	var buf bytes.Buffer
+	if err := store.Export(context.Background(), &buf, ExportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	var rec ExportedRecord
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Values["email"] != "b***" || rec.Values["user"] != "bob" || rec.Data != nil {
+		t.Errorf("Expected redacted export; Got %+v", rec)
+	}
+
+	values := store.RedactValues(map[interface{}]interface{}{"email": "eve@example.com", 1: "one"})
+	if values["email"] != "e***" || values[1] != "one" {
+		t.Errorf("Expected redacted values; Got %v", values)
+	}
+}
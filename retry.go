@@ -0,0 +1,60 @@
+package boltstore
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// maxRetryBackoff caps the delay between save retries.
+const maxRetryBackoff = time.Second
+
+// transientMessages are messages of unwrapped bolt errors of failed db growth.
+var transientMessages = []string{"mmap allocate error", "file resize error"}
+
+// IsTransient reports whether err is a failure a retry may not hit again:
+// a write rejected with ErrOverloaded, failed growth of the db mmap or file,
+// or an interrupted or unavailable system resource.
+func IsTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrOverloaded) || errors.Is(err, syscall.EAGAIN) || errors.Is(err, syscall.EINTR) ||
+		errors.Is(err, syscall.ENOMEM) || errors.Is(err, syscall.EBUSY) {
+		return true
+	}
+	var temp interface{ Temporary() bool }
+	if errors.As(err, &temp) && temp.Temporary() {
+		return true
+	}
+	msg := err.Error()
+	for _, m := range transientMessages {
+		if strings.Contains(msg, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// retry calls fn and calls it again up to Options.SaveRetries times while it
+// fails with a transient error, with exponential backoff until ctx is done.
+func (s *BoltStore) retry(ctx context.Context, fn func() error) error {
+	err := fn()
+	delay := s.options.SaveRetryBackoff
+	for i := 0; i < s.options.SaveRetries && IsTransient(err); i++ {
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+		if delay *= 2; delay > maxRetryBackoff {
+			delay = maxRetryBackoff
+		}
+		err = fn()
+	}
+	return err
+}
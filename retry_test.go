@@ -0,0 +1,49 @@
+package boltstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"syscall"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestIsTransient(t *testing.T) {
+	for err, want := range map[error]bool{
+		ErrOverloaded: true,
+		fmt.Errorf("write error: %w", syscall.EAGAIN):             true,
+		errors.New("mmap allocate error: cannot allocate memory"): true,
+		bolt.ErrDatabaseNotOpen:                                   false,
+		ErrConflict:                                               false,
+	} {
+		if got := IsTransient(err); got != want {
+			t.Errorf("%v: expected transient %v; Got %v", err, want, got)
+		}
+	}
+}
+
+func TestSaveRetry(t *testing.T) {
+	store := newTestStore(t, Options{SaveRetries: 2, SaveRetryBackoff: time.Millisecond})
+	var calls int
+	err := store.retry(context.Background(), func() error {
+		if calls++; calls < 3 {
+			return ErrOverloaded
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("Expected success on last retry; Got %v after %d calls", err, calls)
+	}
+
+	calls = 0
+	err = store.retry(context.Background(), func() error {
+		calls++
+		return ErrConflict
+	})
+	if err != ErrConflict || calls != 1 {
+		t.Errorf("Expected no retries of permanent error; Got %v after %d calls", err, calls)
+	}
+}
@@ -1,77 +1,265 @@
 package boltstore
 
 import (
+	"bytes"
+	"context"
+	"crypto/rand"
 	"encoding/base32"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
-	"strconv"
-	"strings"
+	"sync"
 	"time"
 
-	"github.com/gorilla/securecookie"
 	"github.com/gorilla/sessions"
 	bolt "go.etcd.io/bbolt"
 )
 
 // Save adds a single session to the response.
 func (s *BoltStore) Save(r *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	defer s.unlockSession(session)
+	if err := s.enter(); err != nil {
+		return err
+	}
+	defer s.leave()
+	defer s.writeTiming(w, session, time.Now())
+	if _, ok := getMeta(session, metaRetired); ok {
+		return nil
+	}
+
+	ctx := context.Background()
+	if r != nil {
+		ctx = r.Context()
+		if s.options.MaxNewPerIP > 0 {
+			setMeta(session, metaClient, s.clientIP(r))
+		}
+	}
+	err := s.saveResponse(ctx, w, session)
+	if err == nil {
+		untrack(r, session)
+	}
+	return s.traced(ctx, err)
+}
+
+// saveResponse saves or deletes the session and sets its cookie.
+func (s *BoltStore) saveResponse(ctx context.Context, w http.ResponseWriter, session *sessions.Session) error {
 	// Marked for deletion.
 	if session.Options.MaxAge <= 0 {
-		if err := s.delete(session); err != nil {
+		if err := s.delete(ctx, session); err != nil {
 			return fmt.Errorf("delete session from store error: %w", err)
 		}
-		http.SetCookie(w, sessions.NewCookie(session.Name(), "", session.Options))
-	} else {
-		// Build an alphanumeric key for the store.
-		if session.ID == "" {
-			session.ID = strings.TrimRight(base32.StdEncoding.EncodeToString(securecookie.GenerateRandomKey(32)), "=")
-		}
-		if err := s.save(session); err != nil {
-			return fmt.Errorf("save session to store error: %w", err)
-		}
-		encoded, err := securecookie.EncodeMulti(session.Name(), session.ID, s.Codecs...)
-		if err != nil {
-			return fmt.Errorf("encode cookie error: %w", err)
+		clearCookie(w, s.options, session)
+		return nil
+	}
+	// Build an alphanumeric key for the store.
+	if session.ID == "" {
+		session.ID = newSessionID()
+	}
+	renewed, err := s.save(ctx, session)
+	if err != nil {
+		return fmt.Errorf("save session to store error: %w", err)
+	}
+	if encoded, ok := reusableCookie(session, reuseAge(s.options)); ok {
+		// the cookie sent by the client is still valid, sent again if renewed
+		if renewed {
+			setCookies(w, cookieName(s.options, session.Name()), encoded, cookieOptions(session, s.options), s.options.CookieScopes)
 		}
-		http.SetCookie(w, sessions.NewCookie(session.Name(), encoded, session.Options))
+		return nil
 	}
-	return nil
+	return s.setCookie(w, session)
 }
 
-// save stores the session in db.
-func (s *BoltStore) save(session *sessions.Session) error {
+// idEncoding encodes session IDs, same as trimmed padded StdEncoding.
+var idEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
 
-	b, err := s.options.Serializer.Serialize(session)
-	if err != nil {
-		return fmt.Errorf("serialize session error: %w", err)
+// idPool holds buffers for session ID generation.
+var idPool = sync.Pool{
+	New: func() interface{} { return new(idBuf) },
+}
+
+// idBuf is random key and its encoding of a session ID.
+type idBuf struct {
+	key [32]byte
+	id  [52]byte
+}
+
+// newSessionID returns a new random alphanumeric session ID.
+func newSessionID() string {
+	buf := idPool.Get().(*idBuf)
+	defer idPool.Put(buf)
+	if _, err := io.ReadFull(rand.Reader, buf.key[:]); err != nil {
+		return ""
+	}
+	idEncoding.Encode(buf.id[:], buf.key[:])
+	return string(buf.id[:])
+}
+
+// save stores the session in db. returns false if its expiry was kept, see
+// Options.RenewBelow.
+func (s *BoltStore) save(ctx context.Context, session *sessions.Session) (bool, error) {
+	expireKeys(session, time.Now())
+	var values map[interface{}]interface{}
+	if s.cache != nil {
+		values = stripMeta(session).Values
 	}
 
-	if s.options.MaxLength != 0 && len(b) > s.options.MaxLength {
-		return errors.New("SessionStore: the value to store is too big")
+	var rec *record
+	saveTx := func(tx *bolt.Tx) error {
+		var err error
+		rec, err = s.saveTx(tx, session)
+		return err
+	}
+	var err error
+	if hooks, ok := getMeta(session, metaPhases); ok {
+		err = s.writePhased(session, hooks.(*SaveHooks), saveTx)
+	} else {
+		err = s.retry(ctx, func() error {
+			return s.write(saveTx)
+		})
 	}
+	if err != nil {
+		s.forget(session.ID)
+		return false, err
+	}
+	s.evicted(ctx, rec.evicted)
+	s.invalidate(ctx, session.ID, EventSave)
+	setMeta(session, metaVersion, rec.version)
+	delete(session.Values, metaAudit)
+	if s.cache != nil && !rec.single {
+		rec.values = values
+		s.cache.put(session.ID, rec)
+	}
+	return rec.renewed, nil
+}
 
-	expiredAt := []byte(strconv.FormatInt(time.Now().Add(time.Duration(s.options.SessionExpire)).Unix(), 10))
+// encodeSession serializes the session values with ser and checks the size
+// limit, 0 maxLength is unlimited.
+func encodeSession(ser SessionSerializer, session *sessions.Session, maxLength int) ([]byte, error) {
+	b, err := ser.Serialize(stripMeta(session))
+	if err != nil {
+		return nil, fmt.Errorf("serialize session error: %w", typeError(err))
+	}
 
-	err = s.db.Update(func(tx *bolt.Tx) error {
+	if maxLength != 0 && len(b) > maxLength {
+		return nil, errors.New("SessionStore: the value to store is too big")
+	}
+	return b, nil
+}
 
-		// session root bucket
-		root, err := tx.Bucket(s.options.BucketName).CreateBucketIfNotExists([]byte(session.ID))
+// saveTx serializes and stores session data within transaction tx, with the
+// settings of the bucket it is stored in. returns the stored record without values.
+func (s *BoltStore) saveTx(tx *bolt.Tx, session *sessions.Session) (*record, error) {
+	// session root bucket
+	root, spec := s.findTx(tx, []byte(session.ID), s.bucketOf(session.Name()))
+	created := root == nil
+	if created {
+		spec = s.bucketOf(session.Name())
+		var err error
+		root, err = spec.writeTx(tx).CreateBucket([]byte(session.ID))
 		if err != nil {
-			return fmt.Errorf("create session bucket error: %w", err)
+			return nil, fmt.Errorf("create session bucket error: %w", err)
 		}
-
-		// store values
-		if err := root.Put(keyValues, b); err != nil {
-			return fmt.Errorf("put session value to store error: %w", err)
+		if err := s.addCount(tx, 1); err != nil {
+			return nil, err
 		}
+		if ip, ok := getMeta(session, metaClient); ok {
+			if err := s.throttleTx(tx, ip.(string)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	b, err := encodeSession(spec.serial, session, spec.maxLength)
+	if err != nil {
+		return nil, err
+	}
+	expiresAt, renewed := time.Now().Add(spec.expire), true
+	// provisional until promoted by a load, saves in the same request keep it
+	provisional := s.provisional(spec) && (created || root.Get(keyProvisional) != nil)
+	if provisional {
+		expiresAt = time.Now().Add(s.options.ProvisionalExpire)
+	} else if !created && s.options.RenewBelow > 0 {
+		// keep the expiry while enough of the lifetime remains
+		kept, ok := decodeExpiry(root.Get(keyExpiredAt))
+		if ok && time.Until(kept) > time.Duration(s.options.RenewBelow*float64(spec.expire)) {
+			expiresAt, renewed = kept, false
+		}
+	}
+	createdAt, ok := decodeExpiry(root.Get(keyCreatedAt))
+	if !ok {
+		createdAt = time.Now()
+		if err := root.Put(keyCreatedAt, encodeExpiry(createdAt)); err != nil {
+			return nil, fmt.Errorf("put session createdAt to store error: %w", err)
+		}
+	}
+	if end := createdAt.Add(s.options.MaxLifetime); s.options.MaxLifetime > 0 && expiresAt.After(end) {
+		expiresAt = end
+	}
+	expiredAt := encodeExpiry(expiresAt)
+
+	// check and bump record version
+	version := decodeUint(root.Get(keyVersion))
+	if s.options.OptimisticLocking && version != Version(session) {
+		return nil, ErrConflict
+	}
+	version++
+	if err := root.Put(keyVersion, encodeUint(version)); err != nil {
+		return nil, fmt.Errorf("put session version to store error: %w", err)
+	}
 
-		// store control data
-		if err := root.Put(keyExpiredAt, expiredAt); err != nil {
-			return fmt.Errorf("put session expireAt to store error: %w", err)
+	// store values
+	if err := root.Put(keyValues, b); err != nil {
+		return nil, fmt.Errorf("put session value to store error: %w", err)
+	}
+
+	// store control data
+	if err := root.Put(keyExpiredAt, expiredAt); err != nil {
+		return nil, fmt.Errorf("put session expireAt to store error: %w", err)
+	}
+	user := s.userOf(session)
+	var evicted []string
+	if user != nil && (created || !bytes.Equal(root.Get(keyUser), user)) {
+		if evicted, err = s.limitUserTx(tx, user, session.ID); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.stampTx(tx, root, user, created); err != nil {
+		return nil, err
+	}
+	rec := &record{version: version, size: len(b), expiresAt: expiresAt, single: spec.singleUse(session), renewed: renewed, evicted: evicted}
+	if rec.single {
+		if err := root.Put(keySingleUse, []byte{1}); err != nil {
+			return nil, fmt.Errorf("put session single-use flag error: %w", err)
+		}
+	} else if err := root.Delete(keySingleUse); err != nil {
+		return nil, fmt.Errorf("delete session single-use flag error: %w", err)
+	}
+	if provisional {
+		if err := root.Put(keyProvisional, []byte{1}); err != nil {
+			return nil, fmt.Errorf("put session provisional flag error: %w", err)
 		}
+	} else if err := root.Delete(keyProvisional); err != nil {
+		return nil, fmt.Errorf("delete session provisional flag error: %w", err)
+	}
+	if v := s.schemaVersion(); v > 0 {
+		if err := root.Put(keySchema, encodeUint(v)); err != nil {
+			return nil, fmt.Errorf("put session schema error: %w", err)
+		}
+	}
+	if e, ok := getMeta(session, metaAudit); ok {
+		entry := *e.(*AuditEntry)
+		entry.Session = session.ID
+		if err := s.auditTx(tx, entry); err != nil {
+			return nil, err
+		}
+	}
 
-		return nil
-	})
-	return err
+	if s.options.AccessResolution > 0 {
+		now := time.Now()
+		if err := root.Put(keyLastAccess, encodeExpiry(now)); err != nil {
+			return nil, fmt.Errorf("put session last access to store error: %w", err)
+		}
+		rec.accessed.Store(now.Unix())
+	}
+	return rec, nil
 }
@@ -0,0 +1,23 @@
+package boltstore
+
+import "fmt"
+
+// UpgradeFunc migrates session values from one schema version to the next
+// in place, see Options.Upgrades.
+type UpgradeFunc func(values map[interface{}]interface{}) error
+
+// schemaVersion returns the current session schema version.
+func (s *BoltStore) schemaVersion() uint64 {
+	return uint64(len(s.options.Upgrades))
+}
+
+// upgrade migrates values stored with schema version from to the current
+// version.
+func (s *BoltStore) upgrade(values map[interface{}]interface{}, from uint64) error {
+	for v := from; v < s.schemaVersion(); v++ {
+		if err := s.options.Upgrades[v](values); err != nil {
+			return fmt.Errorf("upgrade session schema %d to %d error: %w", v, v+1, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package boltstore
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestUpgrades(t *testing.T) {
+	store := newTestStore(t, Options{})
+	cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"name": "bob"})
+
+	var calls int
+	store.options.Upgrades = []UpgradeFunc{func(values map[interface{}]interface{}) error {
+		calls++
+		values["user"] = values["name"]
+		delete(values, "name")
+		return nil
+	}}
+	req, session := loadCookie(t, store, "session-key", cookie)
+	if session.IsNew || session.Values["user"] != "bob" || session.Values["name"] != nil {
+		t.Fatalf("Expected upgraded session; Got %v", session.Values)
+	}
+	if err := store.Save(req, NewRecorder(), session); err != nil {
+		t.Fatal(err)
+	}
+	if _, session = loadCookie(t, store, "session-key", cookie); calls != 1 || session.Values["user"] != "bob" {
+		t.Errorf("Expected saved session not upgraded again; Got %v after %d calls", session.Values, calls)
+	}
+
+	store.options.Upgrades = append(store.options.Upgrades, func(values map[interface{}]interface{}) error {
+		return errors.New("upgrade failed")
+	})
+	req, _ = http.NewRequest("GET", "http://localhost:8080/", nil)
+	req.Header.Add("Cookie", cookie)
+	if _, err := store.New(req, "session-key"); err == nil {
+		t.Error("Expected upgrade error")
+	}
+}
@@ -5,30 +5,123 @@ import (
 	"encoding/gob"
 	"encoding/json"
 	"fmt"
+	"reflect"
+	"sync"
 
 	"github.com/gorilla/sessions"
 )
 
+// bufPool holds buffers reused for session serialization.
+var bufPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// maxPooledBuf is the max capacity of a buffer returned to the pool, so
+// a single huge session doesn't pin memory.
+const maxPooledBuf = 64 << 10
+
+// getBuf returns an empty buffer from the pool.
+func getBuf() *bytes.Buffer {
+	buf := bufPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+// putBuf returns buf to the pool.
+func putBuf(buf *bytes.Buffer) {
+	if buf.Cap() <= maxPooledBuf {
+		bufPool.Put(buf)
+	}
+}
+
 // SessionSerializer provides an interface hook for alternative serializers
 type SessionSerializer interface {
 	Deserialize(d []byte, ss *sessions.Session) error
 	Serialize(ss *sessions.Session) ([]byte, error)
 }
 
+// KeyPolicy defines how JSONSerializer handles non-string session.Values keys.
+type KeyPolicy int
+
+const (
+	KeyError     KeyPolicy = iota // fail serialization with *NonStringKeyError
+	KeySkip                       // drop the keys, reporting them to OnSkippedKey
+	KeyStringify                  // store the keys formatted with fmt.Sprint
+)
+
+// NonStringKeyError is returned by JSONSerializer with the KeyError policy
+// when session values have non-string keys.
+type NonStringKeyError struct {
+	Keys []interface{}
+}
+
+func (e *NonStringKeyError) Error() string {
+	return fmt.Sprintf("non-string key values, cannot serialize session to JSON: %v", e.Keys)
+}
+
 // JSONSerializer encode the session map to JSON.
-type JSONSerializer struct{}
+type JSONSerializer struct {
+	NonStringKeys KeyPolicy         // handling of non-string keys, KeyError by default
+	OnSkippedKey  func(interface{}) // called for each key dropped by KeySkip policy
+
+	// Schema enables strict decoding: stored sessions have to decode into a
+	// value of Schema type (a struct or pointer to struct) without unknown
+	// fields, otherwise *SchemaError is returned.
+	Schema interface{}
+	// Validate is called with decoded values in strict mode, an error rejects
+	// the record with *SchemaError.
+	Validate func(map[string]interface{}) error
+}
 
-// Serialize to JSON. Will err if there are unmarshalable key values
+// SchemaError is returned by JSONSerializer when a stored session doesn't
+// match the schema.
+type SchemaError struct {
+	Err error
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("session doesn't match the schema: %v", e.Err)
+}
+
+func (e *SchemaError) Unwrap() error {
+	return e.Err
+}
+
+// Serialize to JSON. Non-string keys are handled according to NonStringKeys policy.
 func (s JSONSerializer) Serialize(ss *sessions.Session) ([]byte, error) {
 	m := make(map[string]interface{}, len(ss.Values))
+	var bad []interface{}
 	for k, v := range ss.Values {
 		ks, ok := k.(string)
 		if !ok {
-			return nil, fmt.Errorf("non-string key value, cannot serialize session to JSON: %v", k)
+			switch s.NonStringKeys {
+			case KeySkip:
+				if s.OnSkippedKey != nil {
+					s.OnSkippedKey(k)
+				}
+				continue
+			case KeyStringify:
+				ks = fmt.Sprint(k)
+				if _, dup := ss.Values[ks]; dup {
+					return nil, fmt.Errorf("stringified key %q collides with string key, cannot serialize session to JSON", ks)
+				}
+			default:
+				bad = append(bad, k)
+				continue
+			}
 		}
 		m[ks] = v
 	}
-	return json.Marshal(m)
+	if bad != nil {
+		return nil, &NonStringKeyError{Keys: bad}
+	}
+	buf := getBuf()
+	defer putBuf(buf)
+	if err := json.NewEncoder(buf).Encode(m); err != nil {
+		return nil, err
+	}
+	// stored data must not alias the pooled buffer, Encode adds a newline
+	return append([]byte(nil), bytes.TrimSuffix(buf.Bytes(), []byte("\n"))...), nil
 }
 
 // Deserialize back to map[string]interface{}
@@ -38,22 +131,48 @@ func (s JSONSerializer) Deserialize(d []byte, ss *sessions.Session) error {
 	if err != nil {
 		return fmt.Errorf("boltstore.JSONSerializer.deserialize() error: %w", err)
 	}
+	if err = s.check(d, m); err != nil {
+		return err
+	}
 	for k, v := range m {
 		ss.Values[k] = v
 	}
 	return nil
 }
 
+// check validates data d decoded into m against the schema.
+func (s JSONSerializer) check(d []byte, m map[string]interface{}) error {
+	if s.Schema != nil {
+		t := reflect.TypeOf(s.Schema)
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		dec := json.NewDecoder(bytes.NewReader(d))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(reflect.New(t).Interface()); err != nil {
+			return &SchemaError{Err: err}
+		}
+	}
+	if s.Validate != nil {
+		if err := s.Validate(m); err != nil {
+			return &SchemaError{Err: err}
+		}
+	}
+	return nil
+}
+
 // GobSerializer uses gob package to encode the session map
 type GobSerializer struct{}
 
 // Serialize using gob
 func (s GobSerializer) Serialize(ss *sessions.Session) ([]byte, error) {
-	buf := new(bytes.Buffer)
+	buf := getBuf()
+	defer putBuf(buf)
 	enc := gob.NewEncoder(buf)
 	err := enc.Encode(ss.Values)
 	if err == nil {
-		return buf.Bytes(), nil
+		// stored data must not alias the pooled buffer
+		return append([]byte(nil), buf.Bytes()...), nil
 	}
 	return nil, err
 }
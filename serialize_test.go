@@ -0,0 +1,110 @@
+package boltstore
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/sessions"
+)
+
+func TestJSONSerializerKeyPolicy(t *testing.T) {
+	newValues := func() *sessions.Session {
+		ss := sessions.NewSession(nil, "session-key")
+		ss.Values["name"] = "foo"
+		ss.Values[42] = "bar"
+		return ss
+	}
+
+	_, err := JSONSerializer{}.Serialize(newValues())
+	var ke *NonStringKeyError
+	if !errors.As(err, &ke) || len(ke.Keys) != 1 || ke.Keys[0] != 42 {
+		t.Errorf("Expected NonStringKeyError for 42; Got %v", err)
+	}
+
+	var skipped []interface{}
+	ser := JSONSerializer{NonStringKeys: KeySkip, OnSkippedKey: func(k interface{}) { skipped = append(skipped, k) }}
+	b, err := ser.Serialize(newValues())
+	if err != nil || string(b) != `{"name":"foo"}` {
+		t.Errorf("Expected skipped key; Got %s, %v", b, err)
+	}
+	if len(skipped) != 1 || skipped[0] != 42 {
+		t.Errorf("Expected 42 reported; Got %v", skipped)
+	}
+
+	b, err = JSONSerializer{NonStringKeys: KeyStringify}.Serialize(newValues())
+	if err != nil || string(b) != `{"42":"bar","name":"foo"}` {
+		t.Errorf("Expected stringified key; Got %s, %v", b, err)
+	}
+
+	ss := newValues()
+	ss.Values["42"] = "baz"
+	if _, err = (JSONSerializer{NonStringKeys: KeyStringify}).Serialize(ss); err == nil {
+		t.Error("Expected stringified key collision error")
+	}
+}
+
+func TestJSONSerializerSchema(t *testing.T) {
+	type profile struct {
+		Name string `json:"name"`
+		Age  int    `json:"age"`
+	}
+	ser := JSONSerializer{
+		Schema: profile{},
+		Validate: func(m map[string]interface{}) error {
+			if _, ok := m["name"]; !ok {
+				return errors.New("name is required")
+			}
+			return nil
+		},
+	}
+
+	ss := sessions.NewSession(nil, "session-key")
+	if err := ser.Deserialize([]byte(`{"name":"foo","age":3}`), ss); err != nil || ss.Values["name"] != "foo" {
+		t.Errorf("Expected valid session; Got %v, %v", ss.Values, err)
+	}
+
+	var se *SchemaError
+	for _, d := range []string{`{"name":"foo","admin":true}`, `{"name":"foo","age":"old"}`, `{"age":3}`} {
+		if err := ser.Deserialize([]byte(d), sessions.NewSession(nil, "session-key")); !errors.As(err, &se) {
+			t.Errorf("Expected SchemaError for %s; Got %v", d, err)
+		}
+	}
+}
+
+func benchmarkSerialize(b *testing.B, ser SessionSerializer) {
+	ss := sessions.NewSession(nil, "session-key")
+	ss.Values["user"] = "foo@example.com"
+	ss.Values["roles"] = "reader,writer"
+	ss.Values["csrf"] = "6b8f2a0e9c1d4e7f"
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := ser.Serialize(ss); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGobSerialize(b *testing.B)  { benchmarkSerialize(b, GobSerializer{}) }
+func BenchmarkJSONSerialize(b *testing.B) { benchmarkSerialize(b, JSONSerializer{}) }
+
+func TestNewSessionID(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		id := newSessionID()
+		if len(id) != 52 || strings.Trim(id, "ABCDEFGHIJKLMNOPQRSTUVWXYZ234567") != "" {
+			t.Fatalf("Expected 52 base32 chars; Got %q", id)
+		}
+		if seen[id] {
+			t.Fatalf("Duplicate id %q", id)
+		}
+		seen[id] = true
+	}
+}
+
+func BenchmarkNewSessionID(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		newSessionID()
+	}
+}
@@ -0,0 +1,127 @@
+package boltstore
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+
+	"github.com/gorilla/sessions"
+)
+
+// Divergence is a difference between the primary and the secondary store of
+// a Shadow.
+type Divergence struct {
+	Op   string // "save", "delete" or "load"
+	Name string // session name
+	ID   string // session ID, as stored by the primary
+	Err  error  // error of the secondary store, nil if results differ
+}
+
+// ShadowOptions configures a Shadow.
+type ShadowOptions struct {
+	// CompareLoads loads every session also from the secondary store and
+	// reports differences of ID, values or IsNew. The secondary store must
+	// decode cookies of the primary, i.e. use the same keys and cookie names,
+	// and should not lock sessions on load, as its sessions are never saved.
+	CompareLoads bool
+	// OnDivergence is called with every failed secondary operation and load
+	// difference. Called synchronously, must not block.
+	OnDivergence func(Divergence)
+}
+
+// Shadow is a sessions.Store which serves requests from a primary store and
+// mirrors every save and delete to a secondary store, to dry-run a migration
+// to a different backend or layout in production before cutting over.
+// Secondary stores never fail requests and don't set cookies, their errors
+// are reported to ShadowOptions.OnDivergence.
+type Shadow struct {
+	primary   sessions.Store
+	secondary sessions.Store
+	options   ShadowOptions
+}
+
+// NewShadow returns a Shadow of primary writing also to secondary.
+func NewShadow(primary, secondary sessions.Store, opts ShadowOptions) *Shadow {
+	return &Shadow{primary: primary, secondary: secondary, options: opts}
+}
+
+// Get returns a session for the given name after adding it to the registry.
+func (s *Shadow) Get(r *http.Request, name string) (*sessions.Session, error) {
+	return sessions.GetRegistry(r).Get(s, name)
+}
+
+// New returns a session of the primary store for the given name without
+// adding it to the registry, comparing it with the secondary store with
+// ShadowOptions.CompareLoads.
+func (s *Shadow) New(r *http.Request, name string) (*sessions.Session, error) {
+	session, err := s.primary.New(r, name)
+	if session == nil {
+		return nil, err
+	}
+	if err == nil && s.options.CompareLoads {
+		s.compare(r, session)
+	}
+	return session, err
+}
+
+// Save saves the session in the primary store and then in the secondary
+// store, or deletes it from both if its MaxAge is not positive.
+func (s *Shadow) Save(r *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	if err := s.primary.Save(r, w, session); err != nil {
+		return err
+	}
+	op := "save"
+	if session.Options != nil && session.Options.MaxAge <= 0 {
+		op = "delete"
+	}
+	if err := s.secondary.Save(r, discardHeaders{}, s.mirror(session)); err != nil {
+		s.diverged(Divergence{Op: op, Name: session.Name(), ID: session.ID, Err: err})
+	}
+	return nil
+}
+
+// compare loads the session of request r from the secondary store and
+// reports a divergence from session of the primary.
+func (s *Shadow) compare(r *http.Request, session *sessions.Session) {
+	d := Divergence{Op: "load", Name: session.Name(), ID: session.ID}
+	other, err := s.secondary.New(r, session.Name())
+	switch {
+	case other == nil || err != nil:
+		if err == nil {
+			err = errors.New("no session returned")
+		}
+		d.Err = err
+	case other.ID != session.ID || other.IsNew != session.IsNew:
+	case !reflect.DeepEqual(stripMeta(other).Values, stripMeta(session).Values):
+	default:
+		return
+	}
+	s.diverged(d)
+}
+
+// mirror returns a copy of session for the secondary store, without control
+// values of the primary.
+func (s *Shadow) mirror(session *sessions.Session) *sessions.Session {
+	m := sessions.NewSession(s.secondary, session.Name())
+	m.ID = session.ID
+	m.IsNew = session.IsNew
+	m.Values = stripMeta(session).Values
+	if session.Options != nil {
+		opts := *session.Options
+		m.Options = &opts
+	}
+	return m
+}
+
+func (s *Shadow) diverged(d Divergence) {
+	if s.options.OnDivergence != nil {
+		s.options.OnDivergence(d)
+	}
+}
+
+// discardHeaders is a ResponseWriter dropping the cookies of the secondary store.
+type discardHeaders struct{}
+
+func (discardHeaders) Header() http.Header         { return http.Header{} }
+func (discardHeaders) Write(b []byte) (int, error) { return len(b), nil }
+func (discardHeaders) WriteHeader(int)             {}
@@ -0,0 +1,55 @@
+package boltstore
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestShadow(t *testing.T) {
+	primary := newTestStore(t, Options{})
+	secondary := newTestStore(t, Options{})
+	var divergences []Divergence
+	shadow := NewShadow(primary, secondary, ShadowOptions{
+		CompareLoads: true,
+		OnDivergence: func(d Divergence) { divergences = append(divergences, d) },
+	})
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	rsp := NewRecorder()
+	session, _ := shadow.New(req, "session-key")
+	session.Values["n"] = 1
+	if err := shadow.Save(req, rsp, session); err != nil {
+		t.Fatal(err)
+	}
+	if cookies := rsp.Header()["Set-Cookie"]; len(cookies) != 1 {
+		t.Fatalf("Expected only the primary cookie; Got %v", cookies)
+	}
+	if !secondary.Exists(session.ID) {
+		t.Fatal("Expected session mirrored to the secondary store")
+	}
+
+	req.Header.Add("Cookie", rsp.Header().Get("Set-Cookie"))
+	loaded, err := shadow.New(req, "session-key")
+	if err != nil || loaded.Values["n"] != 1 {
+		t.Fatalf("Expected loaded session; Got %v, %v", loaded.Values, err)
+	}
+	if len(divergences) != 0 {
+		t.Fatalf("Expected no divergences; Got %+v", divergences)
+	}
+
+	// change the primary behind the shadow
+	loaded.Values["n"] = 2
+	primary.Save(req, NewRecorder(), loaded)
+	shadow.New(req, "session-key")
+	if len(divergences) != 1 || divergences[0].Op != "load" || divergences[0].ID != session.ID {
+		t.Fatalf("Expected load divergence; Got %+v", divergences)
+	}
+
+	loaded.Options.MaxAge = -1
+	if err := shadow.Save(req, NewRecorder(), loaded); err != nil {
+		t.Fatal(err)
+	}
+	if secondary.Exists(session.ID) {
+		t.Error("Expected session deleted from the secondary store")
+	}
+}
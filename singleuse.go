@@ -0,0 +1,50 @@
+package boltstore
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gorilla/sessions"
+	bolt "go.etcd.io/bbolt"
+)
+
+// SingleUse marks the session as single-use when it is saved: the stored
+// record is deleted by the first load, so its cookie can't be replayed, for
+// example for OAuth state or download-once links. Sessions of names with
+// NameOptions.SingleUse are always single-use. Saving the loaded session
+// stores it again.
+func SingleUse(session *sessions.Session) {
+	setMeta(session, metaOnce, true)
+}
+
+// singleUse reports whether the session is saved as single-use.
+func (spec *bucketSpec) singleUse(session *sessions.Session) bool {
+	_, ok := getMeta(session, metaOnce)
+	return ok || spec.single
+}
+
+// consume deletes the single-use session id loaded with record version, in
+// a write transaction so concurrent loads of the session don't both get it.
+// returns false if the session was consumed or changed since it was read.
+func (s *BoltStore) consume(ctx context.Context, session *sessions.Session, version uint64) (bool, error) {
+	var ok bool
+	err := s.write(func(tx *bolt.Tx) error {
+		// reset for reruns by Batch after a rollback
+		ok = false
+		bucket, spec := s.findTx(tx, []byte(session.ID), s.bucketOf(session.Name()))
+		if bucket == nil || bucket.Get(keyDeletedUntil) != nil || decodeUint(bucket.Get(keyVersion)) != version {
+			return nil
+		}
+		if err := tx.Bucket(spec.name).DeleteBucket([]byte(session.ID)); err != nil {
+			return fmt.Errorf("delete single-use session bucket error: %w", err)
+		}
+		ok = true
+		return s.addCount(tx, -1)
+	})
+	s.forget(session.ID)
+	if err != nil || !ok {
+		return false, err
+	}
+	s.invalidate(ctx, session.ID, EventDelete)
+	return true, nil
+}
@@ -0,0 +1,56 @@
+package boltstore
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSingleUse(t *testing.T) {
+	store := newTestStore(t, Options{ShareLoads: true, CacheSize: 10, Names: map[string]NameOptions{
+		"oauth_state": {SingleUse: true},
+	}})
+	req, session := loadCookie(t, store, "download", "")
+	session.Values["file"] = "report.pdf"
+	SingleUse(session)
+	rsp := NewRecorder()
+	if err := store.Save(req, rsp, session); err != nil {
+		t.Fatal(err)
+	}
+	cookie := rsp.Header().Get("Set-Cookie")
+
+	var got atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+			req.Header.Add("Cookie", cookie)
+			session, err := store.New(req, "download")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if !session.IsNew && session.Values["file"] == "report.pdf" {
+				got.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := got.Load(); n != 1 {
+		t.Errorf("Expected a single load of the session; Got %d", n)
+	}
+	if store.Exists(session.ID) {
+		t.Error("Expected consumed session deleted")
+	}
+
+	cookie = saveNew(t, store, "oauth_state", map[interface{}]interface{}{"state": "xyz"})
+	if _, session = loadCookie(t, store, "oauth_state", cookie); session.Values["state"] != "xyz" {
+		t.Fatalf("Expected stored state; Got %v", session.Values)
+	}
+	if _, session = loadCookie(t, store, "oauth_state", cookie); !session.IsNew {
+		t.Error("Expected replayed state cookie to start a new session")
+	}
+}
@@ -0,0 +1,46 @@
+package boltstore
+
+import "os"
+
+const (
+	// sessionOverhead is the approximate size of the keys and headers of a
+	// session bucket: expiry, version, access data, their elements and
+	// the bucket header.
+	sessionOverhead = 256
+	// defaultAverageSize is the assumed average serialized session size.
+	defaultAverageSize = 1 << 10
+	// maxMmapStep is the step of mmap sizes above it, as bolt grows the mmap.
+	maxMmapStep = 1 << 30
+	// maxPageSize is the max page size picked for big sessions.
+	maxPageSize = 64 << 10
+)
+
+// mmapSize returns the initial mmap size and page size suited for n stored
+// sessions of average serialized size avg, so the db doesn't remap while it
+// grows to n. Zero n leaves bolt defaults.
+func mmapSize(n, avg int) (initial, pageSize int) {
+	if n <= 0 {
+		return 0, 0
+	}
+	if avg <= 0 {
+		avg = defaultAverageSize
+	}
+	// bolt inlines buckets up to a quarter of a page, bigger ones take own
+	// pages: pick a page holding at least four sessions
+	pageSize = os.Getpagesize()
+	for pageSize < 4*(avg+sessionOverhead) && pageSize < maxPageSize {
+		pageSize *= 2
+	}
+	// pages are split half full with random IDs, and freed pages are reused
+	// only after commits, so twice the data
+	need := int64(n) * int64(avg+sessionOverhead) * 2
+	// bolt doubles the mmap up to 1GB and grows by 1GB steps after it
+	size := int64(32 << 10)
+	for size < need && size < maxMmapStep {
+		size *= 2
+	}
+	if size < need {
+		size = (need + maxMmapStep - 1) / maxMmapStep * maxMmapStep
+	}
+	return int(size), pageSize
+}
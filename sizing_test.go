@@ -0,0 +1,30 @@
+package boltstore
+
+import (
+	"os"
+	"testing"
+)
+
+func TestMmapSize(t *testing.T) {
+	if initial, page := mmapSize(0, 0); initial != 0 || page != 0 {
+		t.Errorf("Expected bolt defaults; Got %d %d", initial, page)
+	}
+	// 100k sessions of 1KB
+	initial, page := mmapSize(100000, 0)
+	if initial != 256<<20 || page < os.Getpagesize() || page < 4*(defaultAverageSize+sessionOverhead) {
+		t.Errorf("Unexpected sizes %d %d", initial, page)
+	}
+	// 10M sessions of 1KB, 1GB steps
+	if initial, _ = mmapSize(10000000, 0); initial%(1<<30) != 0 || initial < 10000000*(defaultAverageSize+sessionOverhead)*2 {
+		t.Errorf("Expected 1GB steps; Got %d", initial)
+	}
+	if _, page = mmapSize(10, 1<<20); page != maxPageSize {
+		t.Errorf("Expected max page size; Got %d", page)
+	}
+
+	store := newTestStore(t, Options{ExpectedSessions: 1000, AverageSize: 512})
+	saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 1})
+	if _, page = mmapSize(1000, 512); store.DB().Info().PageSize != page {
+		t.Errorf("Expected page size %d; Got %d", page, store.DB().Info().PageSize)
+	}
+}
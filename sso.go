@@ -0,0 +1,77 @@
+package boltstore
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/sessions"
+)
+
+// SSO shares one session between subdomains of a parent domain served by
+// apps using the same store: the session cookie is set for the parent
+// domain, the logged in user is the shared auth state and each subdomain
+// keeps its own values in a namespace of the session.
+type SSO struct {
+	store  *BoltStore
+	domain string
+	name   string
+}
+
+// NewSSO returns SSO helpers of sessions of name shared by subdomains of
+// domain, such as "example.com". The logged in user is kept under
+// Options.UserKey, see Login.
+func NewSSO(store *BoltStore, domain, name string) *SSO {
+	if name == "" {
+		name = defaultSessionName
+	}
+	return &SSO{store: store, domain: strings.TrimPrefix(domain, "."), name: name}
+}
+
+// Session returns the shared session of the request, with its cookie set
+// for the parent domain.
+func (sso *SSO) Session(r *http.Request) (*sessions.Session, error) {
+	session, err := sso.store.Get(r, sso.name)
+	if session != nil {
+		session.Options.Domain = sso.domain
+	}
+	return session, err
+}
+
+// User returns the user logged in to the shared session.
+func (sso *SSO) User(session *sessions.Session) (string, bool) {
+	user := sso.store.userOf(session)
+	return string(user), user != nil
+}
+
+// Login logs userID in to the shared session of the request, see Login.
+func (sso *SSO) Login(r *http.Request, w http.ResponseWriter, userID string, opts LoginOptions) (*sessions.Session, error) {
+	sso.Session(r) // the parent domain is kept by the registry session
+	opts.Name = sso.name
+	return Login(r, w, sso.store, userID, opts)
+}
+
+// Logout deletes the shared session of the request, logging the user out
+// of all subdomains.
+func (sso *SSO) Logout(r *http.Request, w http.ResponseWriter) error {
+	sso.Session(r) // the parent domain is kept by the registry session
+	return Logout(r, w, sso.store, sso.name)
+}
+
+// App returns the namespace of the subdomain of the request within the
+// shared session, "" for the parent domain itself.
+func (sso *SSO) App(r *http.Request, session *sessions.Session) *NamespaceView {
+	return Namespace(session, sso.subdomain(r.Host))
+}
+
+// subdomain returns the subdomain of host below the parent domain.
+func (sso *SSO) subdomain(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+	if host == sso.domain {
+		return ""
+	}
+	return strings.TrimSuffix(host, "."+sso.domain)
+}
@@ -0,0 +1,52 @@
+package boltstore
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestSSO(t *testing.T) {
+	store := newTestStore(t, Options{UserKey: "user"})
+	sso := NewSSO(store, ".example.com", "")
+
+	req, _ := http.NewRequest("GET", "http://login.example.com/", nil)
+	rsp := NewRecorder()
+	if _, err := sso.Login(req, rsp, "alice", LoginOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	cookies := (&http.Response{Header: rsp.Header()}).Cookies()
+	if len(cookies) != 1 || cookies[0].Domain != "example.com" {
+		t.Fatalf("Expected a parent domain cookie; Got %v", rsp.Header()["Set-Cookie"])
+	}
+
+	// another subdomain sees the logged in user and keeps own values
+	req, _ = http.NewRequest("GET", "http://shop.example.com:8080/", nil)
+	req.AddCookie(cookies[0])
+	session, err := sso.Session(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user, ok := sso.User(session); !ok || user != "alice" {
+		t.Errorf("Expected shared user; Got %q, %v", user, ok)
+	}
+	sso.App(req, session).Set("cart", 3)
+	if err := session.Save(req, NewRecorder()); err != nil {
+		t.Fatal(err)
+	}
+	if keys := Namespace(session, "shop").Keys(); len(keys) != 1 || keys[0] != "cart" {
+		t.Errorf("Expected values in the subdomain namespace; Got %v", session.Values)
+	}
+
+	rsp = NewRecorder()
+	req, _ = http.NewRequest("GET", "http://shop.example.com/", nil)
+	req.AddCookie(cookies[0])
+	if err := sso.Logout(req, rsp); err != nil {
+		t.Fatal(err)
+	}
+	if store.Exists(session.ID) {
+		t.Error("Expected shared session deleted on logout")
+	}
+	if c := (&http.Response{Header: rsp.Header()}).Cookies(); len(c) != 1 || c[0].Domain != "example.com" || c[0].MaxAge >= 0 {
+		t.Errorf("Expected parent domain cookie cleared; Got %v", rsp.Header()["Set-Cookie"])
+	}
+}
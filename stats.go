@@ -0,0 +1,149 @@
+package boltstore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// initCount stores the number of sessions into control bucket, if it is not
+// maintained yet (db created by older versions).
+func (s *BoltStore) initCount(tx *bolt.Tx) error {
+	control := tx.Bucket(controlBucketName(s.options.BucketName))
+	if control.Get(keyCount) != nil {
+		return nil
+	}
+	var n uint64
+	for _, spec := range s.buckets {
+		err := tx.Bucket(spec.name).ForEach(func(k, v []byte) error {
+			if v == nil {
+				n++
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return control.Put(keyCount, encodeUint(n))
+}
+
+// addCount adjusts the stored number of sessions by delta within transaction tx.
+func (s *BoltStore) addCount(tx *bolt.Tx, delta int64) error {
+	control := tx.Bucket(controlBucketName(s.options.BucketName))
+	n := int64(decodeUint(control.Get(keyCount))) + delta
+	if n < 0 {
+		n = 0
+	}
+	if err := control.Put(keyCount, encodeUint(uint64(n))); err != nil {
+		return fmt.Errorf("put sessions count error: %w", err)
+	}
+	return nil
+}
+
+// Count returns the number of stored sessions, including expired and deleted
+// ones the reaper has not removed yet. The value is maintained incrementally
+// and costs a single key read.
+func (s *BoltStore) Count(ctx context.Context) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if err := s.enter(); err != nil {
+		return 0, err
+	}
+	defer s.leave()
+	var n uint64
+	err := s.db.View(func(tx *bolt.Tx) error {
+		n = decodeUint(tx.Bucket(controlBucketName(s.options.BucketName)).Get(keyCount))
+		return nil
+	})
+	return int(n), err
+}
+
+// CountActive returns the number of stored sessions which are neither expired
+// nor deleted. It scans session control keys but doesn't deserialize values.
+func (s *BoltStore) CountActive(ctx context.Context) (int, error) {
+	if err := s.enter(); err != nil {
+		return 0, err
+	}
+	defer s.leave()
+	var n int
+	now := time.Now()
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return s.eachTx(ctx, tx, func(_ *bucketSpec, k []byte, _ *bolt.Bucket) error {
+			if s.activeBucket(tx, k, now) != nil {
+				n++
+			}
+			return nil
+		})
+	})
+	return n, err
+}
+
+// Stats are db space utilization metrics, to judge whether compaction is
+// worthwhile, and write path metrics.
+type Stats struct {
+	PageSize      int // db page size
+	FreePages     int // free pages on the freelist
+	PendingPages  int // pages freed but still used by open read transactions
+	FreeAlloc     int // bytes allocated in free pages
+	FreelistInuse int // bytes used by the freelist
+	Buckets       []BucketStats
+	Writes        WriteStats  // write path metrics since the store was created
+	Cookies       CookieStats // cookie decode failures since the store was created
+}
+
+// BucketStats are page metrics of a sessions bucket, including nested
+// session buckets.
+type BucketStats struct {
+	Name           string
+	Sessions       int // session buckets
+	InlineSessions int // session buckets stored inline in their parent page
+	BranchPages    int // branch pages, including overflow
+	LeafPages      int // leaf pages, including overflow
+	LeafAlloc      int // bytes allocated for leaf pages
+	LeafInuse      int // bytes actually used in leaf pages
+}
+
+// Stats returns freelist and page utilization metrics of the db and the
+// store buckets. Bucket metrics walk all pages of the store.
+func (s *BoltStore) Stats(ctx context.Context) (*Stats, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := s.enter(); err != nil {
+		return nil, err
+	}
+	defer s.leave()
+	dbs := s.db.Stats()
+	st := &Stats{
+		PageSize:      s.db.Info().PageSize,
+		FreePages:     dbs.FreePageN,
+		PendingPages:  dbs.PendingPageN,
+		FreeAlloc:     dbs.FreeAlloc,
+		FreelistInuse: dbs.FreelistInuse,
+		Writes:        s.wstats.stats(),
+		Cookies:       s.cookieStats(),
+	}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		for _, spec := range s.buckets {
+			bs := tx.Bucket(spec.name).Stats()
+			st.Buckets = append(st.Buckets, BucketStats{
+				Name:           string(spec.name),
+				Sessions:       bs.BucketN - 1, // without the sessions bucket itself
+				InlineSessions: bs.InlineBucketN,
+				BranchPages:    bs.BranchPageN + bs.BranchOverflowN,
+				LeafPages:      bs.LeafPageN + bs.LeafOverflowN,
+				LeafAlloc:      bs.LeafAlloc,
+				LeafInuse:      bs.LeafInuse,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return st, nil
+}
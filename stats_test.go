@@ -0,0 +1,85 @@
+package boltstore
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCount(t *testing.T) {
+	store := newTestStore(t, Options{})
+	ctx := context.Background()
+	var cookie string
+	for i := 0; i < 3; i++ {
+		cookie = saveNew(t, store, "session-key", nil)
+	}
+	req, session := loadCookie(t, store, "session-key", cookie)
+	if err := store.Save(req, NewRecorder(), session); err != nil {
+		t.Fatalf("Error saving session: %v", err)
+	}
+	setExpiry(t, store, session.ID, time.Now().Add(-time.Minute))
+
+	if n, err := store.Count(ctx); err != nil || n != 3 {
+		t.Errorf("Expected 3 sessions; Got %d, %v", n, err)
+	}
+	if n, err := store.CountActive(ctx); err != nil || n != 2 {
+		t.Errorf("Expected 2 active sessions; Got %d, %v", n, err)
+	}
+}
+
+func TestTTL(t *testing.T) {
+	store := newTestStore(t, Options{SessionExpire: time.Hour})
+	cookie := saveNew(t, store, "session-key", nil)
+	_, session := loadCookie(t, store, "session-key", cookie)
+	id := session.ID
+
+	if !store.Exists(id) || store.Exists("missing") {
+		t.Fatal("Unexpected Exists result")
+	}
+	if ttl, err := store.TTL(id); err != nil || ttl <= 59*time.Minute || ttl > time.Hour {
+		t.Errorf("Expected TTL about an hour; Got %v, %v", ttl, err)
+	}
+	if err := store.Expire(id, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if ttl, err := store.TTL(id); err != nil || ttl > time.Minute {
+		t.Errorf("Expected TTL up to a minute; Got %v, %v", ttl, err)
+	}
+	if err := store.Expire(id, 0); err != nil {
+		t.Fatal(err)
+	}
+	if store.Exists(id) {
+		t.Error("Expected expired session not to exist")
+	}
+}
+
+func TestStats(t *testing.T) {
+	store := newTestStore(t, Options{Names: map[string]NameOptions{"flash": {}}})
+	for i := 0; i < 3; i++ {
+		saveNew(t, store, "session-key", map[interface{}]interface{}{"n": i})
+	}
+	st, err := store.Stats(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.PageSize == 0 || len(st.Buckets) != 2 || st.Buckets[0].Name != "sessions" || st.Buckets[1].Name != "sessions.flash" {
+		t.Fatalf("Unexpected stats %+v", st)
+	}
+	if b := st.Buckets[0]; b.Sessions != 3 || b.InlineSessions != 3 || b.LeafPages == 0 || b.LeafInuse == 0 {
+		t.Errorf("Unexpected sessions bucket stats %+v", b)
+	}
+}
+
+func TestWriteStats(t *testing.T) {
+	store := newTestStore(t, Options{Durability: ProfileBalanced})
+	for i := 0; i < 3; i++ {
+		saveNew(t, store, "session-key", map[interface{}]interface{}{"n": i})
+	}
+	st, err := store.Stats(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := st.Writes; w.Committed != 3 || w.Queued != 0 || w.AvgLatency <= 0 || w.MaxLatency < w.AvgLatency {
+		t.Errorf("Expected 3 committed writes; Got %+v", w)
+	}
+}
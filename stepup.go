@@ -0,0 +1,45 @@
+package boltstore
+
+import (
+	"time"
+
+	"github.com/gorilla/sessions"
+)
+
+// elevatedKey is the session values key of the step-up authentication time,
+// an RFC 3339 string so it decodes the same with any serializer.
+const elevatedKey = "_auth_time"
+
+// Elevate marks the session as elevated for d after the user authenticated
+// again, for sudo-mode flows. The marker expires like values set with
+// SetExpiring: the store drops it on load and save after d.
+func Elevate(session *sessions.Session, d time.Duration) {
+	SetExpiring(session, elevatedKey, time.Now().UTC().Format(time.RFC3339Nano), d)
+}
+
+// IsElevated reports whether the session was elevated with Elevate and the
+// elevation has not expired yet.
+func IsElevated(session *sessions.Session) bool {
+	until, ok := KeyExpiry(session, elevatedKey)
+	if !ok || !time.Now().Before(until) {
+		return false
+	}
+	_, ok = session.Values[elevatedKey]
+	return ok
+}
+
+// AuthTime returns the time the session was elevated at, if it is elevated.
+func AuthTime(session *sessions.Session) (time.Time, bool) {
+	if !IsElevated(session) {
+		return time.Time{}, false
+	}
+	s, _ := session.Values[elevatedKey].(string)
+	at, err := time.Parse(time.RFC3339Nano, s)
+	return at, err == nil
+}
+
+// Demote drops the elevation of the session.
+func Demote(session *sessions.Session) {
+	delete(session.Values, elevatedKey)
+	expireKeys(session, time.Now())
+}
@@ -0,0 +1,38 @@
+package boltstore
+
+import (
+	"testing"
+	"time"
+)
+
+func TestElevate(t *testing.T) {
+	store := newTestStore(t, Options{Serializer: JSONSerializer{}})
+	req, session := loadCookie(t, store, "session-key", "")
+	if IsElevated(session) {
+		t.Fatal("Expected new session not elevated")
+	}
+	Elevate(session, time.Minute)
+	rsp := NewRecorder()
+	if err := store.Save(req, rsp, session); err != nil {
+		t.Fatal(err)
+	}
+	req, session = loadCookie(t, store, "session-key", rsp.Header().Get("Set-Cookie"))
+	if at, ok := AuthTime(session); !IsElevated(session) || !ok || time.Since(at) > time.Minute {
+		t.Errorf("Expected elevated session; Got %v, %v", at, ok)
+	}
+
+	Demote(session)
+	if IsElevated(session) {
+		t.Error("Expected demoted session")
+	}
+	Elevate(session, -time.Second)
+	if IsElevated(session) {
+		t.Error("Expected expired elevation")
+	}
+	if err := store.Save(req, NewRecorder(), session); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := session.Values[elevatedKey]; ok {
+		t.Error("Expected expired marker dropped on save")
+	}
+}
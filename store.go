@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/gorilla/securecookie"
@@ -15,6 +16,23 @@ import (
 var (
 	keyValues    = []byte("values")
 	keyExpiredAt = []byte("expired_at")
+	keyVersion   = []byte("version")
+
+	keyDeletedUntil = []byte("deleted_until")
+	keyLastAccess   = []byte("last_access_at")
+	keyAccessCount  = []byte("access_count")
+	keyPinned       = []byte("pinned")
+	keyEpoch        = []byte("epoch") // also control bucket: current revocation epoch
+	keyUser         = []byte("user")
+	keySchema       = []byte("schema")
+	keySingleUse    = []byte("single_use")
+	keyCreatedAt    = []byte("created_at")
+	keyProvisional  = []byte("provisional")
+	keyBlobs        = []byte("blobs") // nested bucket of blobs, see AttachBlob
+
+	keyCount   = []byte("count")   // control bucket: number of stored sessions
+	keyRevoked = []byte("revoked") // control bucket: epoch all older sessions are revoked before
+	keyUsers   = []byte("users")   // control bucket: bucket of epochs sessions of a user are revoked before
 )
 
 type Options struct {
@@ -25,6 +43,98 @@ type Options struct {
 	Serializer        SessionSerializer
 	MaxLength         int // max length of session data (0 - unlimited with caution)
 	ReapCheckInterval time.Duration
+	OptimisticLocking bool          // fail Save with ErrConflict if the session was changed since it was loaded
+	LockSessions      bool          // serialize concurrent requests of the same session from load until save
+	ShareLoads        bool          // collapse concurrent loads of the same session into a single db read
+	ShareLoadsTTL     time.Duration // keep a shared load result for reuse this long after it completes
+	CacheSize         int           // max count of deserialized sessions cached in memory (0 - cache disabled)
+	CacheBytes        int           // max total serialized size of cached sessions (0 - unlimited)
+	CacheTTL          time.Duration // max time a session is served from cache (0 - until evicted)
+	ExpiredGrace      time.Duration // return values of sessions expired within this period as new sessions, see Expired
+	SoftDelete        time.Duration // keep deleted sessions this long before permanent removal, see Undelete
+	AccessResolution  time.Duration // track last access time, persisted when it drifted this much (0 - disabled), see Info
+	CountAccesses     bool          // count session loads, persisted in batches by the reaper worker, see Info
+	IdleTimeout       time.Duration // reap sessions not accessed this long, regardless of expiry (0 - disabled)
+	ServerTiming      bool          // add Server-Timing durations of session load and save on Save
+	RedactIDs         bool          // replace session IDs in errors and change hooks with RedactID
+	Durability        Profile       // durability and write throughput trade-off, ProfileDurable by default
+	NoSync            bool          // don't fsync commits, the db is synced every SyncInterval and on Close
+	SyncInterval      time.Duration // db sync period with NoSync, 1s by default
+	MaxBatchSize      int           // max saves per batched commit of non-durable profiles (0 - bolt default)
+	MaxBatchDelay     time.Duration // max wait of a save for its batch to fill (0 - bolt default)
+	ExpectedSessions  int           // expected count of stored sessions, sizes the initial mmap of NewStore
+	AverageSize       int           // expected average serialized session size, 1KB by default
+	WarmUp            bool          // pre-read stored sessions in background on start, see WarmUpProgress
+	SkipCorrupt       bool          // delete records failing to deserialize on load and start a new session, see OnError
+	SaveRetries       int           // retries of saves failing with transient errors, see IsTransient
+	SaveRetryBackoff  time.Duration // delay of the first save retry, doubled on each one up to 1s, 10ms by default
+	MaxWriters        int           // max concurrent writes, more fail with ErrOverloaded, access refreshes are shed at half (0 - unlimited)
+	UserKey           string        // session values key of the user ID, enables RevokeUser and ListUserSessions
+	MaxDecodeFailures int           // cookie decode failures per client per minute before its cookies are ignored for BlockDuration (0 - never)
+	BlockDuration     time.Duration // time cookies of clients over MaxDecodeFailures are ignored, 5m by default
+	MaxNewPerIP       int           // max sessions a client IP creates per minute, more fail Save with ErrRateLimited (0 - unlimited)
+	MaxUserSessions   int           // max active sessions per UserKey user, applied as UserLimit (0 - unlimited)
+	UserLimit         LimitPolicy   // sessions saved for a user at MaxUserSessions evict the oldest one or are rejected
+	NoMigrate         bool          // fail on open instead of migrating an older on-disk layout, see MigrateLayout
+	NoReaper          bool          // don't start the reaper goroutine, run Reap from an own scheduler instead
+	FillPercent       float64       // fill of split sessions bucket pages, lower leaves room for random inserts at the cost of size (0 - bolt default 0.5)
+	CookieMode        CookieMode    // session ID cookie encoding, CookieEncrypted by default
+	ProvisionalExpire time.Duration // expire of new sessions until the client returns with the cookie, then the full expire applies (0 - disabled)
+	MaxLifetime       time.Duration // sessions are invalid this long after creation regardless of renewals (0 - unlimited)
+	ClockSkew         time.Duration // tolerance of expiry checks for sessions written by hosts with drifting clocks
+	RenewBelow        float64       // renew expiry and cookie on save only when less than this fraction of the lifetime remains (0 - every save)
+	CookieLifetime    time.Duration // browser lifetime of saved session cookies, the session MaxAge by default (negative - until the browser closes)
+	CookieScopes      []CookieScope // more domains and paths session cookies are set for with the same value, e.g. during a domain migration
+	CookieMaxAge      time.Duration // max age of securecookie timestamps, SessionExpire by default (negative - unchecked)
+	CookieMinAge      time.Duration // min age of securecookie timestamps (0 - unchecked)
+	CookieMaxLength   int           // max length of securecookie values, 4096 by default (negative - unlimited)
+	MaxBlobSize       int           // max size of a blob stored by AttachBlob, 16MB by default (negative - unlimited)
+
+	// TraceIDFunc returns the request or trace ID of a context, to be included
+	// in errors and change hooks. Nil uses IDs set by WithTraceID.
+	TraceIDFunc func(ctx context.Context) string
+
+	// OnNew is called with sessions New creates because the request has no
+	// stored session, to seed default values such as a locale or a CSRF token.
+	OnNew func(session *sessions.Session)
+
+	// Upgrades migrate values of sessions saved with older schemas on load:
+	// Upgrades[i] upgrades values of schema version i to i+1, sessions saved
+	// before any upgrade was registered have version 0. Sessions are saved
+	// with the current version, len(Upgrades), so upgrades are only appended.
+	Upgrades []UpgradeFunc
+
+	// CookieSerializer encodes session IDs in securecookie values, gob by
+	// default.
+	CookieSerializer securecookie.Serializer
+
+	// ClientIP returns the IP address of the client of a request, for
+	// Options.MaxNewPerIP and Login. The RemoteAddr host is used by default,
+	// behind proxies it should be read from a trusted header instead.
+	ClientIP func(r *http.Request) string
+
+	// Fingerprint returns what identifies the client device of a request,
+	// recorded by Login and compared by SameDevice. The User-Agent header is
+	// used by default; set it to hash or omit client details when they must
+	// not be stored, or to read them from headers of a trusted proxy.
+	Fingerprint func(r *http.Request) []byte
+
+	// CookieName maps session names to the names of their cookies, for
+	// example prefixing them with the environment so parallel environments
+	// on sibling domains don't clobber each other's cookies. Cookie values
+	// are signed with the mapped name.
+	CookieName func(name string) string
+
+	// CookieCodec encodes session IDs in cookies instead of securecookie,
+	// CookieMode and the securecookie options are ignored with it.
+	CookieCodec CookieCodec
+
+	Names map[string]NameOptions // session names stored in own buckets with overridden options
+
+	// CookieStoreKeyPairs are key pairs of a gorilla CookieStore being migrated
+	// from: cookies which are not store session IDs are decoded as CookieStore
+	// payloads and their values are saved server-side under a new ID.
+	CookieStoreKeyPairs [][]byte
 }
 
 func setOptions(o Options) Options {
@@ -40,74 +150,255 @@ func setOptions(o Options) Options {
 	if o.Serializer == nil {
 		o.Serializer = GobSerializer{}
 	}
+	if o.CookieMaxAge == 0 {
+		// cookies are valid as long as the sessions they carry
+		o.CookieMaxAge = o.SessionExpire
+	}
 	if o.ReapCheckInterval == 0 {
 		o.ReapCheckInterval = time.Minute
 	}
+	if o.Durability == ProfileThroughput {
+		o.NoSync = true
+	}
+	if o.SyncInterval == 0 {
+		o.SyncInterval = time.Second
+	}
+	if o.MaxDecodeFailures > 0 && o.BlockDuration == 0 {
+		o.BlockDuration = 5 * time.Minute
+	}
+	if o.SaveRetries > 0 && o.SaveRetryBackoff == 0 {
+		o.SaveRetryBackoff = 10 * time.Millisecond
+	}
+	if o.MaxBlobSize == 0 {
+		// blobs are buffered in memory before they are written
+		o.MaxBlobSize = 16 << 20
+	}
+	if o.IdleTimeout > 0 && o.AccessResolution == 0 {
+		// idleness is known with the precision of last access time
+		o.AccessResolution = o.IdleTimeout / 10
+	}
 	return o
 }
 
+// controlBucketName returns name of the bucket keeping store control data
+// for sessions bucket name.
+func controlBucketName(name []byte) []byte {
+	return append(append([]byte{}, name...), "_control"...)
+}
+
+// auditBucketName returns name of the audit log bucket, kept apart from the
+// control bucket so DeleteAll doesn't erase it.
+func auditBucketName(name []byte) []byte {
+	return append(append([]byte{}, name...), "_audit"...)
+}
+
 // boltstore stores sessions in a boltdb backend.
 type BoltStore struct {
 	db      *bolt.DB
 	Codecs  []securecookie.Codec
 	Options *sessions.Options // default session configuration
 	options Options           // store options
+	locker  *keyedLocker      // per-session locks, nil if disabled
+	loads   *loadGroup        // shared loads, nil if disabled
+	cache   *sessionCache     // read-through cache, nil if disabled
+	hooks   hookList          // registered callbacks
+	buckets []*bucketSpec     // sessions buckets, the main one first
+	named   map[string]*bucketSpec
+	host    *dbHost              // stores sharing the db
+	legacy  []securecookie.Codec // CookieStore codecs for migration
+	types   typeRegistry         // gob types registered by RegisterTypes
+	counts  *accessCounter       // pending access counts, nil if disabled
+	warm    warmUp               // warm-up progress
+	ctx     context.Context      // store lifetime, a child of the constructor ctx
+	cancel  context.CancelFunc   // cancels ctx on Close
+	bg      sync.WaitGroup       // background goroutines running until ctx is done
+	life    lifecycle            // open, closing or closed state
+	path    string               // db file path, reopened by Reopen
+	limit   writeLimiter         // concurrent writes, nil if unlimited
+	wstats  writeStats           // write path counters
+	decodes decodeFailures       // cookie decode failures by client
 }
 
-// NewStoreWithDB returns a new BoltStore.
+// NewStoreWithDB returns a new BoltStore. The reaper and other background
+// goroutines run with a context derived from ctx until Close, so ctx only
+// needs to outlive the store if its values or cancellation are wanted.
 func NewStoreWithDB(ctx context.Context, db *bolt.DB, opts Options) (*BoltStore, error) {
 	opts = setOptions(opts)
 
-	if opts.KeyPairs == nil {
+	if opts.KeyPairs == nil && opts.CookieMode != CookieRaw && opts.CookieCodec == nil {
 		return nil, errors.New("store secret key is absent")
 	}
 
-	// Create buckets
-	err := db.Update(func(tx *bolt.Tx) error {
-		// main bucket
-		if _, err := tx.CreateBucketIfNotExists(opts.BucketName); err != nil {
-			return err
-		}
-		// values bucket
-		// if _, err := b.CreateBucketIfNotExists(bucketValues); err != nil {
-		// 	return err
-		// }
-		// // control bucket
-		// if _, err := b.CreateBucketIfNotExists(bucketControl); err != nil {
-		// 	return err
-		// }
-		return nil
-	})
-	if err != nil {
-		db.Close()
-		return nil, fmt.Errorf("create sessions buckets %q error: %w", string(opts.BucketName), err)
-	}
-
 	bs := &BoltStore{
 		db:     db,
-		Codecs: securecookie.CodecsFromPairs(opts.KeyPairs...),
+		Codecs: newCodecs(opts),
 		Options: &sessions.Options{
 			Path:   "/",
 			MaxAge: int(opts.SessionExpire / time.Second),
 		},
 		options: opts,
 	}
+	bs.buckets, bs.named = newBucketSpecs(opts)
+	if opts.CookieStoreKeyPairs != nil {
+		bs.legacy = securecookie.CodecsFromPairs(opts.CookieStoreKeyPairs...)
+	}
+	if opts.LockSessions {
+		bs.locker = newKeyedLocker()
+	}
+	if opts.ShareLoads {
+		bs.loads = newLoadGroup(opts.ShareLoadsTTL)
+	}
+	if opts.CacheSize > 0 {
+		bs.cache = newSessionCache(opts.CacheSize, opts.CacheBytes, opts.CacheTTL)
+	}
+	if opts.CountAccesses {
+		bs.counts = newAccessCounter()
+	}
+	bs.limit = newWriteLimiter(opts.MaxWriters)
+	if err := bs.open(ctx, db); err != nil {
+		return nil, err
+	}
+	return bs, nil
+}
 
-	go bs.worker(ctx)
+// open starts using db: registers the store on it, creates missing buckets
+// and starts background goroutines with a context derived from ctx.
+func (s *BoltStore) open(ctx context.Context, db *bolt.DB) error {
+	s.db = db
+	s.path = db.Path()
+	if err := s.register(); err != nil {
+		return err
+	}
+
+	// Create buckets
+	err := db.Update(s.createBuckets)
+	if err != nil {
+		if s.unregister() {
+			db.Close()
+		}
+		return fmt.Errorf("create sessions buckets %q error: %w", string(s.options.BucketName), err)
+	}
+	// batch settings are db wide, the last store configuring them wins
+	if s.options.MaxBatchSize > 0 {
+		db.MaxBatchSize = s.options.MaxBatchSize
+	}
+	if s.options.MaxBatchDelay > 0 {
+		db.MaxBatchDelay = s.options.MaxBatchDelay
+	}
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	if s.options.NoSync {
+		db.NoSync = true
+		s.bg.Add(1)
+		go s.syncer(s.ctx, s.options.SyncInterval)
+	}
 
-	return bs, err
+	if s.options.WarmUp {
+		s.warm.running.Store(true)
+		s.bg.Add(1)
+		go s.warmUp(s.ctx)
+	}
+
+	if !s.options.NoReaper {
+		s.bg.Add(1)
+		go s.worker(s.ctx)
+	}
+
+	return nil
 }
 
 func NewStore(ctx context.Context, fn string, o Options) (*BoltStore, error) {
-	db, err := bolt.Open(fn, 0600, &bolt.Options{Timeout: 3 * time.Second})
+	db, err := openDB(fn, o)
 	if err != nil {
-		return nil, fmt.Errorf("open bolt store %q error: %w", fn, err)
+		return nil, err
 	}
 	return NewStoreWithDB(ctx, db, o)
 }
 
+// openDB opens the bolt db file fn with settings for store options o.
+func openDB(fn string, o Options) (*bolt.DB, error) {
+	bo := o.Durability.boltOptions(&bolt.Options{Timeout: 3 * time.Second})
+	bo.InitialMmapSize, bo.PageSize = mmapSize(o.ExpectedSessions, o.AverageSize)
+	db, err := bolt.Open(fn, 0600, bo)
+	if err != nil {
+		return nil, fmt.Errorf("open bolt store %q error: %w", fn, err)
+	}
+	return db, nil
+}
+
+// Reopen starts using a closed store again, keeping its codecs, options and
+// hooks. The db is shared again if other stores still use it, otherwise the
+// db file the store used is opened anew, so it can be replaced while the
+// store is closed, e.g. by a restore or an offline compaction. The cache is
+// emptied. Reopen must not be called concurrently with Close.
+func (s *BoltStore) Reopen(ctx context.Context) error {
+	if !s.life.is(stateClosed) {
+		return errors.New("store is not closed")
+	}
+	db := s.db
+	if !s.shared() {
+		var err error
+		if db, err = openDB(s.path, s.options); err != nil {
+			return err
+		}
+	}
+	if s.loads != nil {
+		s.loads.purge()
+	}
+	if s.cache != nil {
+		s.cache.purge()
+	}
+	s.warm.read.Store(0)
+	s.warm.total.Store(0)
+	if err := s.open(ctx, db); err != nil {
+		return err
+	}
+	s.life.set(stateOpen)
+	return nil
+}
+
+// Close stops accepting operations, waits for operations in progress, stops
+// the reaper and other background goroutines and closes the store. The db is
+// closed with the last store using it. Methods of a closed store return
+// ErrStoreClosed, closing it again waits for the store to be closed.
 func (s *BoltStore) Close() error {
-	return s.db.Close()
+	if !s.life.close() {
+		return nil
+	}
+	defer s.life.set(stateClosed)
+	s.cancel()
+	s.bg.Wait()
+	s.flushAccesses()
+	var err error
+	if s.options.NoSync {
+		if err = s.db.Sync(); err != nil {
+			err = fmt.Errorf("sync bolt store error: %w", err)
+		}
+	}
+	if !s.unregister() {
+		return err
+	}
+	if errClose := s.db.Close(); err == nil {
+		err = errClose
+	}
+	return err
+}
+
+// Shutdown closes the store like Close, waiting for it until ctx is done:
+// new operations are rejected, operations in progress finish, pending access
+// counts are flushed and a NoSync db is synced before the db is closed. If ctx
+// is done first, its error is returned and closing goes on in background.
+// It fits http.Server.RegisterOnShutdown:
+//
+//	srv.RegisterOnShutdown(func() { store.Shutdown(ctx) })
+func (s *BoltStore) Shutdown(ctx context.Context) error {
+	closed := make(chan error, 1)
+	go func() { closed <- s.Close() }()
+	select {
+	case err := <-closed:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 func (s *BoltStore) DB() *bolt.DB {
@@ -115,10 +406,15 @@ func (s *BoltStore) DB() *bolt.DB {
 }
 
 // Get returns a session for the given name after adding it to the registry.
+// Within AutoSave the session is saved with the response.
 //
 // See gorilla/sessions FilesystemStore.Get().
 func (s *BoltStore) Get(r *http.Request, name string) (*sessions.Session, error) {
-	return sessions.GetRegistry(r).Get(s, name)
+	session, err := sessions.GetRegistry(r).Get(s, name)
+	if session != nil {
+		track(r, session)
+	}
+	return session, err
 }
 
 // New returns a session for the given name without adding it to the registry.
@@ -129,32 +425,101 @@ func (s *BoltStore) New(r *http.Request, name string) (*sessions.Session, error)
 		err error
 		ok  bool
 	)
-	session := sessions.NewSession(s, name)
-	// make a copy
-	options := *s.Options
-	session.Options = &options
+	session := s.newSession(name, "")
 	session.IsNew = true
-	if c, errCookie := r.Cookie(name); errCookie == nil {
-		err = securecookie.DecodeMulti(name, c.Value, &session.ID, s.Codecs...)
+	if err = s.enter(); err != nil {
+		return session, err
+	}
+	defer s.leave()
+	cname := cookieName(s.options, name)
+	if c, errCookie := r.Cookie(cname); errCookie == nil && !s.blockedClient(r) {
+		switch {
+		case s.options.CookieCodec != nil:
+			var id string
+			if id, err = s.options.CookieCodec.Decode(cname, c.Value); err == nil {
+				session.ID = id
+			}
+		case s.Codecs[0].Decode(cname, c.Value, &session.ID) == nil:
+			rememberCookie(session, c.Value)
+		default:
+			err = securecookie.DecodeMulti(cname, c.Value, &session.ID, s.Codecs...)
+		}
+		if err != nil && s.legacy != nil {
+			// CookieStore payload, values are saved under a new ID
+			if securecookie.DecodeMulti(cname, c.Value, &session.Values, s.legacy...) == nil {
+				session.ID = ""
+				session.IsNew = false
+				return session, nil
+			}
+		}
+		if err != nil {
+			s.decodeFailed(r, name)
+		}
 		if err == nil {
-			ok, err = s.load(session)
+			err = s.lockSession(r.Context(), session)
+		}
+		if err == nil {
+			start := time.Now()
+			ok, err = s.load(r.Context(), session)
+			s.timeLoad(session, start)
+			if err == nil && ok {
+				expireKeys(session, time.Now())
+			}
 			session.IsNew = !(err == nil && ok) // not new if no error and data available
+			if err == nil && !ok {
+				// stale cookie, a new ID is issued on save
+				session.ID = ""
+				delete(session.Values, metaCookie)
+			}
+			if err != nil {
+				s.unlockSession(session)
+			}
 		}
 	}
-	return session, err
+	if session.IsNew && s.options.OnNew != nil {
+		s.options.OnNew(session)
+	}
+	return session, s.traced(r.Context(), err)
 }
 
-// delete removes keys
-func (s *BoltStore) delete(session *sessions.Session) error {
-	err := s.db.Update(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket(s.options.BucketName).Bucket([]byte(session.ID))
-		if bucket == nil {
-			return fmt.Errorf("invalid session bucket %s/%s", string(s.options.BucketName), session.ID)
+// delete removes the session bucket, or marks it deleted with SoftDelete.
+// Deleting a session which is not stored is not an error.
+func (s *BoltStore) delete(ctx context.Context, session *sessions.Session) error {
+	var missing bool
+	err := s.write(func(tx *bolt.Tx) error {
+		bucket, spec := s.findTx(tx, []byte(session.ID), s.bucketOf(session.Name()))
+		missing = bucket == nil
+		if missing {
+			return nil
 		}
-		return bucket.Delete([]byte(session.ID))
+		return s.deleteTx(tx, bucket, spec, session.ID)
 	})
-	if err != nil {
+	if err != nil || missing {
+		s.forget(session.ID)
 		return err
 	}
+	s.invalidate(ctx, session.ID, EventDelete)
 	return nil
 }
+
+// deleteTx removes the bucket b of session id stored in bucket spec, or
+// marks it deleted with SoftDelete, within transaction tx.
+func (s *BoltStore) deleteTx(tx *bolt.Tx, b *bolt.Bucket, spec *bucketSpec, id string) error {
+	if s.options.SoftDelete > 0 {
+		return b.Put(keyDeletedUntil, encodeExpiry(time.Now().Add(s.options.SoftDelete)))
+	}
+	if err := tx.Bucket(spec.name).DeleteBucket([]byte(id)); err != nil {
+		return fmt.Errorf("delete session bucket error: %w", err)
+	}
+	return s.addCount(tx, -1)
+}
+
+// forget drops data of the session id kept in memory, after it was changed in db.
+func (s *BoltStore) forget(id string) {
+	if s.loads != nil {
+		s.loads.forget(id)
+	}
+	if s.cache != nil {
+		s.cache.remove(id)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/gorilla/sessions"
@@ -392,3 +393,51 @@ func ExampleBoltStore() {
 func init() {
 	gob.Register(FlashMessage{})
 }
+
+// newTestStore opens a store on a temporary file, closed on test cleanup.
+func newTestStore(t testing.TB, opts Options) *BoltStore {
+	t.Helper()
+	if opts.KeyPairs == nil {
+		opts.KeyPairs = [][]byte{[]byte("secret-key")}
+	}
+	store, err := NewStore(context.Background(), filepath.Join(t.TempDir(), "test.db"), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// saveNew saves a new session with given values and returns its cookie.
+func saveNew(t testing.TB, store *BoltStore, name string, values map[interface{}]interface{}) string {
+	t.Helper()
+	req, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	rsp := NewRecorder()
+	session, err := store.New(req, name)
+	if err != nil {
+		t.Fatalf("Error getting session: %v", err)
+	}
+	for k, v := range values {
+		session.Values[k] = v
+	}
+	if err = store.Save(req, rsp, session); err != nil {
+		t.Fatalf("Error saving session: %v", err)
+	}
+	cookies, ok := rsp.Header()["Set-Cookie"]
+	if !ok || len(cookies) != 1 {
+		t.Fatalf("No cookies. Header: %s", rsp.Header())
+	}
+	return cookies[0]
+}
+
+// loadCookie returns the session referenced by cookie.
+func loadCookie(t *testing.T, store *BoltStore, name, cookie string) (*http.Request, *sessions.Session) {
+	t.Helper()
+	req, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	req.Header.Add("Cookie", cookie)
+	session, err := store.New(req, name)
+	if err != nil {
+		t.Fatalf("Error getting session: %v", err)
+	}
+	return req, session
+}
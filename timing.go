@@ -0,0 +1,34 @@
+package boltstore
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/sessions"
+)
+
+// timeLoad records duration of the session load started at start.
+func (s *BoltStore) timeLoad(session *sessions.Session, start time.Time) {
+	if s.options.ServerTiming {
+		setMeta(session, metaLoaded, time.Since(start))
+	}
+}
+
+// writeTiming adds Server-Timing entries of the session load, if it was
+// loaded, and of the save started at start to the response.
+func (s *BoltStore) writeTiming(w http.ResponseWriter, session *sessions.Session, start time.Time) {
+	if !s.options.ServerTiming || w == nil {
+		return
+	}
+	if v, ok := getMeta(session, metaLoaded); ok {
+		w.Header().Add("Server-Timing", serverTiming("load", v.(time.Duration)))
+		delete(session.Values, metaLoaded) // reported once
+	}
+	w.Header().Add("Server-Timing", serverTiming("save", time.Since(start)))
+}
+
+// serverTiming formats a Server-Timing metric of duration d in milliseconds.
+func serverTiming(desc string, d time.Duration) string {
+	return `boltstore;desc="` + desc + `";dur=` + strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 3, 64)
+}
@@ -0,0 +1,20 @@
+package boltstore
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestServerTiming(t *testing.T) {
+	store := newTestStore(t, Options{ServerTiming: true})
+	cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 1})
+	req, session := loadCookie(t, store, "session-key", cookie)
+	rsp := NewRecorder()
+	if err := store.Save(req, rsp, session); err != nil {
+		t.Fatal(err)
+	}
+	timing := rsp.Header()["Server-Timing"]
+	if len(timing) != 2 || !strings.HasPrefix(timing[0], `boltstore;desc="load";dur=`) || !strings.HasPrefix(timing[1], `boltstore;desc="save";dur=`) {
+		t.Errorf("Expected load and save timing; Got %v", timing)
+	}
+}
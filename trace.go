@@ -0,0 +1,53 @@
+package boltstore
+
+import "context"
+
+// traceKey is the context key of the trace ID set by WithTraceID.
+type traceKey struct{}
+
+// WithTraceID returns a copy of ctx carrying request or trace id, which the
+// store includes in errors and change hooks of operations done with ctx,
+// including requests with the context.
+func WithTraceID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, traceKey{}, id)
+}
+
+// TraceID returns the trace ID set by WithTraceID, "" if none.
+func TraceID(ctx context.Context) string {
+	id, _ := ctx.Value(traceKey{}).(string)
+	return id
+}
+
+// traceID returns the trace ID of ctx with Options.TraceIDFunc, or TraceID.
+func (s *BoltStore) traceID(ctx context.Context) string {
+	if s.options.TraceIDFunc != nil {
+		return s.options.TraceIDFunc(ctx)
+	}
+	return TraceID(ctx)
+}
+
+// TraceError wraps errors of operations done with a trace ID.
+type TraceError struct {
+	TraceID string
+	Err     error
+}
+
+func (e *TraceError) Error() string {
+	return e.Err.Error() + " (trace " + e.TraceID + ")"
+}
+
+func (e *TraceError) Unwrap() error {
+	return e.Err
+}
+
+// traced wraps err of an operation done with ctx with its trace ID, if any.
+func (s *BoltStore) traced(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	id := s.traceID(ctx)
+	if _, ok := err.(*TraceError); ok || id == "" {
+		return err
+	}
+	return &TraceError{TraceID: id, Err: err}
+}
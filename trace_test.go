@@ -0,0 +1,37 @@
+package boltstore
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestTraceID(t *testing.T) {
+	store := newTestStore(t, Options{OptimisticLocking: true})
+	var changes []Change
+	store.OnChange(func(c Change) { changes = append(changes, c) })
+
+	cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 1})
+	req, session := loadCookie(t, store, "session-key", cookie)
+	req = req.WithContext(WithTraceID(req.Context(), "req-42"))
+	if err := store.Save(req, NewRecorder(), session); err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 || changes[0].TraceID != "" || changes[1].TraceID != "req-42" || changes[1].ID != session.ID {
+		t.Errorf("Expected traced change; Got %+v", changes)
+	}
+
+	// stale version
+	setMeta(session, metaVersion, uint64(1))
+	err := store.Save(req, NewRecorder(), session)
+	var te *TraceError
+	if !errors.As(err, &te) || te.TraceID != "req-42" || !errors.Is(err, ErrConflict) || !strings.HasSuffix(err.Error(), "(trace req-42)") {
+		t.Errorf("Expected traced conflict; Got %v", err)
+	}
+
+	store.options.TraceIDFunc = func(context.Context) string { return "custom" }
+	if err := store.Undelete(context.Background(), "missing"); !errors.As(err, &te) || te.TraceID != "custom" {
+		t.Errorf("Expected custom trace ID; Got %v", err)
+	}
+}
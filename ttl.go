@@ -0,0 +1,77 @@
+package boltstore
+
+import (
+	"context"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// activeBucket returns the bucket of session id if the session is stored,
+// not deleted, not revoked and not expired at time now, nil otherwise.
+func (s *BoltStore) activeBucket(tx *bolt.Tx, id []byte, now time.Time) *bolt.Bucket {
+	b, _ := s.findTx(tx, id, s.buckets[0])
+	if b == nil || b.Get(keyValues) == nil || b.Get(keyDeletedUntil) != nil || isExpired(b.Get(keyExpiredAt), now.Add(-s.options.ClockSkew)) || s.revokedTx(tx, b) || s.outlived(b, now) {
+		return nil
+	}
+	return b
+}
+
+// Exists reports whether an active session with given id is stored.
+func (s *BoltStore) Exists(id string) bool {
+	if s.enter() != nil {
+		return false
+	}
+	defer s.leave()
+	var ok bool
+	s.db.View(func(tx *bolt.Tx) error {
+		ok = s.activeBucket(tx, []byte(id), time.Now()) != nil
+		return nil
+	})
+	return ok
+}
+
+// TTL returns the remaining time to live of the session with given id.
+func (s *BoltStore) TTL(id string) (time.Duration, error) {
+	if err := s.enter(); err != nil {
+		return 0, err
+	}
+	defer s.leave()
+	var ttl time.Duration
+	err := s.db.View(func(tx *bolt.Tx) error {
+		now := time.Now()
+		b := s.activeBucket(tx, []byte(id), now)
+		if b == nil {
+			return ErrNotFound
+		}
+		expiresAt, _ := decodeExpiry(b.Get(keyExpiredAt))
+		ttl = expiresAt.Sub(now)
+		return nil
+	})
+	return ttl, err
+}
+
+// Expire sets the session with given id to expire after d, regardless of its
+// current expiry. Non-positive d expires the session immediately.
+func (s *BoltStore) Expire(id string, d time.Duration) error {
+	if err := s.enter(); err != nil {
+		return err
+	}
+	defer s.leave()
+	if d < 0 {
+		d = 0
+	}
+	err := s.write(func(tx *bolt.Tx) error {
+		now := time.Now()
+		b := s.activeBucket(tx, []byte(id), now)
+		if b == nil {
+			return ErrNotFound
+		}
+		return b.Put(keyExpiredAt, encodeExpiry(now.Add(d)))
+	})
+	if err != nil {
+		return err
+	}
+	s.invalidate(context.Background(), id, EventSave)
+	return nil
+}
@@ -0,0 +1,65 @@
+package boltstore
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/sessions"
+	bolt "go.etcd.io/bbolt"
+)
+
+// SaveHooks coordinate a session save with writes to another store, see
+// SaveTwoPhase.
+type SaveHooks struct {
+	// Prepare is called with the session serialized and staged in the open
+	// bolt transaction, to validate it and stage external writes. An error
+	// rolls the save back.
+	Prepare func(session *sessions.Session) error
+	// Commit commits the external writes. The bolt transaction is committed
+	// only after it succeeds, an error rolls the save back.
+	Commit func() error
+	// Rollback is called with the error if the save fails after Prepare
+	// succeeded, including a failed bolt commit after Commit succeeded, so
+	// the external writes can be undone.
+	Rollback func(err error)
+}
+
+// SaveTwoPhase saves the session like Save, calling hooks within the bolt
+// write transaction so the session and external records, such as an SQL
+// row, are committed together. The transaction is held open while the hooks
+// run, blocking other writes of the store, and the save is not retried, see
+// Options.SaveRetries. Sessions marked for deletion are deleted without
+// calling the hooks.
+func (s *BoltStore) SaveTwoPhase(r *http.Request, w http.ResponseWriter, session *sessions.Session, hooks SaveHooks) error {
+	setMeta(session, metaPhases, &hooks)
+	defer delete(session.Values, metaPhases)
+	return s.Save(r, w, session)
+}
+
+// writePhased runs fn in a write transaction followed by the save hooks of
+// session.
+func (s *BoltStore) writePhased(session *sessions.Session, hooks *SaveHooks, fn func(*bolt.Tx) error) error {
+	prepared := false
+	// not batched, transactions may be run again by Batch
+	err := s.writeOnce(func(tx *bolt.Tx) error {
+		if err := fn(tx); err != nil {
+			return err
+		}
+		if hooks.Prepare != nil {
+			if err := hooks.Prepare(session); err != nil {
+				return fmt.Errorf("prepare save error: %w", err)
+			}
+		}
+		prepared = true
+		if hooks.Commit != nil {
+			if err := hooks.Commit(); err != nil {
+				return fmt.Errorf("commit save error: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil && prepared && hooks.Rollback != nil {
+		hooks.Rollback(err)
+	}
+	return err
+}
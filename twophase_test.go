@@ -0,0 +1,62 @@
+package boltstore
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gorilla/sessions"
+)
+
+func TestSaveTwoPhase(t *testing.T) {
+	store := newTestStore(t, Options{})
+	var calls []string
+	hooks := SaveHooks{
+		Prepare: func(session *sessions.Session) error {
+			calls = append(calls, "prepare")
+			return nil
+		},
+		Commit: func() error {
+			calls = append(calls, "commit")
+			return nil
+		},
+		Rollback: func(err error) { calls = append(calls, "rollback") },
+	}
+	req, session := loadCookie(t, store, "session-key", "")
+	session.Values["n"] = 1
+	rsp := NewRecorder()
+	if err := store.SaveTwoPhase(req, rsp, session, hooks); err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 2 || calls[0] != "prepare" || calls[1] != "commit" {
+		t.Errorf("Expected prepare and commit; Got %v", calls)
+	}
+	if _, ok := getMeta(session, metaPhases); ok || !store.Exists(session.ID) {
+		t.Error("Expected saved session without the hooks")
+	}
+
+	// a failed external commit rolls the save back
+	calls = nil
+	failed := errors.New("external commit failed")
+	hooks.Commit = func() error { return failed }
+	_, session = loadCookie(t, store, "session-key", rsp.Header().Get("Set-Cookie"))
+	session.Values["n"] = 2
+	if err := store.SaveTwoPhase(req, NewRecorder(), session, hooks); !errors.Is(err, failed) {
+		t.Errorf("Expected the commit error; Got %v", err)
+	}
+	if len(calls) != 2 || calls[0] != "prepare" || calls[1] != "rollback" {
+		t.Errorf("Expected prepare and rollback; Got %v", calls)
+	}
+	if _, session = loadCookie(t, store, "session-key", rsp.Header().Get("Set-Cookie")); session.Values["n"] != 1 {
+		t.Errorf("Expected the stored session unchanged; Got %v", session.Values["n"])
+	}
+
+	// a failed prepare isn't rolled back by the application
+	calls = nil
+	hooks.Prepare = func(*sessions.Session) error { return failed }
+	if err := store.SaveTwoPhase(req, NewRecorder(), session, hooks); !errors.Is(err, failed) {
+		t.Errorf("Expected the prepare error; Got %v", err)
+	}
+	if len(calls) != 0 {
+		t.Errorf("Expected no hooks after a failed prepare; Got %v", calls)
+	}
+}
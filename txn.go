@@ -0,0 +1,121 @@
+package boltstore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gorilla/sessions"
+	bolt "go.etcd.io/bbolt"
+)
+
+// StoreTxn reads and writes sessions by ID within a single write
+// transaction, see Txn. It must not be used after the Txn function returns.
+type StoreTxn struct {
+	s       *BoltStore
+	tx      *bolt.Tx
+	saved   map[string]*txnSave
+	deleted []string
+	evicted []string
+}
+
+// txnSave is a session saved by a StoreTxn, with the saved record version.
+type txnSave struct {
+	session *sessions.Session
+	version uint64
+}
+
+// Txn calls fn to read, save and delete sessions of any IDs within a single
+// write transaction, so the changes are applied atomically, for example to
+// move values between sessions of merged accounts. If fn returns an error,
+// nothing is changed and the error is returned as is. Other writes of the
+// store wait for fn to return.
+func (s *BoltStore) Txn(ctx context.Context, fn func(txn *StoreTxn) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := s.enter(); err != nil {
+		return err
+	}
+	defer s.leave()
+	txn := &StoreTxn{s: s, saved: make(map[string]*txnSave)}
+	// not batched, fn must not run again
+	err := s.writeOnce(func(tx *bolt.Tx) error {
+		txn.tx = tx
+		return fn(txn)
+	})
+	txn.tx = nil
+	for id := range txn.saved {
+		s.forget(id)
+	}
+	for _, id := range txn.deleted {
+		s.forget(id)
+	}
+	if err != nil {
+		return s.traced(ctx, err)
+	}
+	s.evicted(ctx, txn.evicted)
+	for id, saved := range txn.saved {
+		setMeta(saved.session, metaVersion, saved.version)
+		s.invalidate(ctx, id, EventSave)
+	}
+	for _, id := range txn.deleted {
+		s.invalidate(ctx, id, EventDelete)
+	}
+	return nil
+}
+
+// New returns a new session of name with a generated ID, stored by Save.
+func (t *StoreTxn) New(name string) *sessions.Session {
+	session := t.s.newSession(name, newSessionID())
+	session.IsNew = true
+	return session
+}
+
+// Get loads the active session with given id, returns ErrNotFound if there
+// is none.
+func (t *StoreTxn) Get(id string) (*sessions.Session, error) {
+	_, spec := t.s.findTx(t.tx, []byte(id), t.s.buckets[0])
+	if spec == nil {
+		return nil, ErrNotFound
+	}
+	session := t.s.newSession(t.s.sessionName(spec), id)
+	ok, err := t.s.loadTx(t.tx, session)
+	if err != nil {
+		return nil, fmt.Errorf("load session error: %w", err)
+	}
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return session, nil
+}
+
+// Save stores the session, it is visible to later reads of the transaction.
+func (t *StoreTxn) Save(session *sessions.Session) error {
+	if session.ID == "" {
+		session.ID = newSessionID()
+	}
+	expireKeys(session, time.Now())
+	rec, err := t.s.saveTx(t.tx, session)
+	if err != nil {
+		return err
+	}
+	t.evicted = append(t.evicted, rec.evicted...)
+	t.saved[session.ID] = &txnSave{session: session, version: rec.version}
+	return nil
+}
+
+// Delete removes the session with given id, returns ErrNotFound if there
+// is none.
+func (t *StoreTxn) Delete(id string) error {
+	b, spec := t.s.findTx(t.tx, []byte(id), t.s.buckets[0])
+	if b == nil || b.Get(keyDeletedUntil) != nil {
+		return ErrNotFound
+	}
+	if err := t.s.deleteTx(t.tx, b, spec, id); err != nil {
+		return err
+	}
+	delete(t.saved, id)
+	t.deleted = append(t.deleted, id)
+	return nil
+}
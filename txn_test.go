@@ -0,0 +1,75 @@
+package boltstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gorilla/sessions"
+)
+
+func TestTxn(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t, Options{})
+	var ids []string
+	for _, cart := range []int{1, 2} {
+		_, session := loadCookie(t, store, "session-key", saveNew(t, store, "session-key", map[interface{}]interface{}{"cart": cart}))
+		ids = append(ids, session.ID)
+	}
+
+	// merge the carts into a new session
+	var merged *sessions.Session
+	err := store.Txn(ctx, func(txn *StoreTxn) error {
+		merged = txn.New("session-key")
+		total := 0
+		for _, id := range ids {
+			session, err := txn.Get(id)
+			if err != nil {
+				return err
+			}
+			total += session.Values["cart"].(int)
+			if err := txn.Delete(id); err != nil {
+				return err
+			}
+		}
+		merged.Values["cart"] = total
+		return txn.Save(merged)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.Exists(ids[0]) || store.Exists(ids[1]) {
+		t.Error("Expected merged sessions deleted")
+	}
+	if Version(merged) == 0 {
+		t.Error("Expected version of the saved session")
+	}
+
+	// nothing is changed by a failed transaction
+	failed := errors.New("failed")
+	err = store.Txn(ctx, func(txn *StoreTxn) error {
+		if err := txn.Delete(merged.ID); err != nil {
+			return err
+		}
+		if _, err := txn.Get(merged.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected the deleted session not found; Got %v", err)
+		}
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Errorf("Expected the function error; Got %v", err)
+	}
+	err = store.Txn(ctx, func(txn *StoreTxn) error {
+		session, err := txn.Get(merged.ID)
+		if err != nil {
+			return err
+		}
+		if session.Values["cart"] != 3 {
+			t.Errorf("Expected the merged cart; Got %v", session.Values["cart"])
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,97 @@
+package boltstore
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/sessions"
+)
+
+// typedKey is the session values key holding encoded typed session data.
+const typedKey = "_typed"
+
+// TypedStore wraps a store to keep session data as a user-defined struct T.
+// T is encoded as a single value, gob if the serializer of the session name
+// keeps []byte values and JSON otherwise, so its field types need no gob
+// registration.
+type TypedStore[T any] struct {
+	store  *BoltStore
+	binary sync.Map // session name -> bool, see binaryValues
+}
+
+// TypedSession is a session with typed data.
+type TypedSession[T any] struct {
+	Data    T
+	Session *sessions.Session
+}
+
+// Typed returns a typed wrapper of the store.
+func Typed[T any](store *BoltStore) *TypedStore[T] {
+	return &TypedStore[T]{store: store}
+}
+
+// Get returns the typed session for the given name, Data is the zero value
+// of T for new sessions.
+func (ts *TypedStore[T]) Get(r *http.Request, name string) (*TypedSession[T], error) {
+	session, err := ts.store.Get(r, name)
+	if err != nil {
+		return nil, err
+	}
+	t := &TypedSession[T]{Session: session}
+	switch v := session.Values[typedKey].(type) {
+	case nil:
+	case string:
+		err = json.Unmarshal([]byte(v), &t.Data)
+	case []byte:
+		err = gob.NewDecoder(bytes.NewReader(v)).Decode(&t.Data)
+	default:
+		err = fmt.Errorf("unexpected typed session value %T", v)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decode typed session error: %w", err)
+	}
+	return t, nil
+}
+
+// Save encodes Data into the session and saves it.
+func (ts *TypedStore[T]) Save(r *http.Request, w http.ResponseWriter, t *TypedSession[T]) error {
+	if !ts.binaryValues(t.Session.Name()) {
+		b, err := json.Marshal(t.Data)
+		if err != nil {
+			return fmt.Errorf("encode typed session error: %w", err)
+		}
+		t.Session.Values[typedKey] = string(b)
+	} else {
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(t.Data); err != nil {
+			return fmt.Errorf("encode typed session error: %w", err)
+		}
+		t.Session.Values[typedKey] = buf.Bytes()
+	}
+	return ts.store.Save(r, w, t.Session)
+}
+
+// binaryValues reports whether the serializer of session name keeps []byte
+// values, probed once with a round trip so wrapping and custom serializers
+// are detected as well as JSONSerializer.
+func (ts *TypedStore[T]) binaryValues(name string) bool {
+	if ok, found := ts.binary.Load(name); found {
+		return ok.(bool)
+	}
+	serial := ts.store.bucketOf(name).serial
+	probe := sessions.NewSession(ts.store, name)
+	probe.Values[typedKey] = []byte{0}
+	ok := false
+	if b, err := serial.Serialize(probe); err == nil {
+		out := sessions.NewSession(ts.store, name)
+		if serial.Deserialize(b, out) == nil {
+			_, ok = out.Values[typedKey].([]byte)
+		}
+	}
+	ts.binary.Store(name, ok)
+	return ok
+}
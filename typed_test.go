@@ -0,0 +1,75 @@
+package boltstore
+
+import (
+	"net/http"
+	"testing"
+)
+
+type typedCart struct {
+	UserID int
+	Items  []string
+}
+
+// wrappedSerializer hides the type of the serializer it wraps, like
+// compressing or encrypting serializers.
+type wrappedSerializer struct {
+	SessionSerializer
+}
+
+func TestTyped(t *testing.T) {
+	sers := []SessionSerializer{
+		GobSerializer{},
+		JSONSerializer{},
+		&JSONSerializer{},
+		wrappedSerializer{JSONSerializer{}},
+		wrappedSerializer{GobSerializer{}},
+	}
+	for _, ser := range sers {
+		store := newTestStore(t, Options{Serializer: ser})
+		carts := Typed[typedCart](store)
+
+		req, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+		rsp := NewRecorder()
+		cart, err := carts.Get(req, "cart")
+		if err != nil {
+			t.Fatal(err)
+		}
+		cart.Data.UserID = 42
+		cart.Data.Items = append(cart.Data.Items, "book")
+		if err := carts.Save(req, rsp, cart); err != nil {
+			t.Fatal(err)
+		}
+
+		req, _ = http.NewRequest("GET", "http://localhost:8080/", nil)
+		req.Header.Add("Cookie", rsp.Header()["Set-Cookie"][0])
+		cart, err = carts.Get(req, "cart")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cart.Session.IsNew || cart.Data.UserID != 42 || len(cart.Data.Items) != 1 {
+			t.Errorf("%T: Expected stored cart; Got %+v", ser, cart.Data)
+		}
+	}
+}
+
+func TestTypedFlashes(t *testing.T) {
+	store := newTestStore(t, Options{})
+	req, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	rsp := NewRecorder()
+	session, _ := store.New(req, "session-key")
+	if err := AddFlash(session, Flash{SeverityWarning, "low balance"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(req, rsp, session); err != nil {
+		t.Fatal(err)
+	}
+
+	_, session = loadCookie(t, store, "session-key", rsp.Header()["Set-Cookie"][0])
+	flashes := Flashes[Flash](session)
+	if len(flashes) != 1 || flashes[0].Severity != SeverityWarning || flashes[0].Message != "low balance" {
+		t.Errorf("Expected warning flash; Got %+v", flashes)
+	}
+	if flashes = Flashes[Flash](session); len(flashes) != 0 {
+		t.Errorf("Expected dumped flashes; Got %+v", flashes)
+	}
+}
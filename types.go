@@ -0,0 +1,86 @@
+package boltstore
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// typeRegistry records the value types registered with gob by a store.
+type typeRegistry struct {
+	mu    sync.RWMutex
+	names map[string]struct{}
+}
+
+// RegisterTypes registers the types of values with gob, so they can be
+// stored in session.Values with GobSerializer, and records them on the store.
+// Values of types not registered yield an *UnregisteredTypeError on load and
+// save. Like gob.Register, it panics if a type name is already used by a
+// different type.
+func (s *BoltStore) RegisterTypes(values ...interface{}) {
+	s.types.mu.Lock()
+	defer s.types.mu.Unlock()
+	if s.types.names == nil {
+		s.types.names = make(map[string]struct{})
+	}
+	for _, v := range values {
+		gob.Register(v)
+		s.types.names[fmt.Sprintf("%T", v)] = struct{}{}
+	}
+}
+
+// RegisteredTypes returns sorted names of the types registered with
+// RegisterTypes.
+func (s *BoltStore) RegisteredTypes() []string {
+	s.types.mu.RLock()
+	defer s.types.mu.RUnlock()
+	names := make([]string, 0, len(s.types.names))
+	for name := range s.types.names {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// UnregisteredTypeError is returned on load or save of a session holding a
+// value of a type which was not registered with gob.
+type UnregisteredTypeError struct {
+	Type string // gob name of the type
+	Err  error  // original gob error
+}
+
+func (e *UnregisteredTypeError) Error() string {
+	return fmt.Sprintf("session value type %s is not registered, see BoltStore.RegisterTypes", e.Type)
+}
+
+func (e *UnregisteredTypeError) Unwrap() error {
+	return e.Err
+}
+
+// gob errors are plain strings, these are messages of unknown interface types.
+var gobUnregistered = []string{
+	"gob: name not registered for interface: ", // decoding
+	"gob: type not registered for interface: ", // encoding
+}
+
+// typeError converts gob errors about unregistered types to
+// *UnregisteredTypeError and returns other errors as is.
+func typeError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var te *UnregisteredTypeError
+	if errors.As(err, &te) {
+		return err
+	}
+	msg := err.Error()
+	for _, prefix := range gobUnregistered {
+		if i := strings.Index(msg, prefix); i >= 0 {
+			return &UnregisteredTypeError{Type: strings.Trim(msg[i+len(prefix):], `"`), Err: err}
+		}
+	}
+	return err
+}
@@ -0,0 +1,45 @@
+package boltstore
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+type unregisteredValue struct{ N int }
+
+type registeredValue struct{ N int }
+
+func TestRegisterTypes(t *testing.T) {
+	store := newTestStore(t, Options{})
+	store.RegisterTypes(registeredValue{})
+	if names := store.RegisteredTypes(); len(names) != 1 || names[0] != "boltstore.registeredValue" {
+		t.Errorf("Unexpected registered types %v", names)
+	}
+
+	cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"v": registeredValue{N: 7}})
+	_, session := loadCookie(t, store, "session-key", cookie)
+	if v, ok := session.Values["v"].(registeredValue); !ok || v.N != 7 {
+		t.Errorf("Expected registered value; Got %#v", session.Values["v"])
+	}
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	session, _ = store.New(req, "session-key")
+	session.Values["v"] = unregisteredValue{N: 1}
+	err := store.Save(req, NewRecorder(), session)
+	var te *UnregisteredTypeError
+	if !errors.As(err, &te) || te.Type != "boltstore.unregisteredValue" {
+		t.Errorf("Expected UnregisteredTypeError; Got %v", err)
+	}
+}
+
+func TestTypeError(t *testing.T) {
+	err := typeError(errors.New(`gob: name not registered for interface: "main.Profile"`))
+	var te *UnregisteredTypeError
+	if !errors.As(err, &te) || te.Type != "main.Profile" {
+		t.Errorf("Expected UnregisteredTypeError; Got %v", err)
+	}
+	if err := errors.New("other"); typeError(err) != err {
+		t.Error("Expected other errors unchanged")
+	}
+}
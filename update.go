@@ -0,0 +1,68 @@
+package boltstore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gorilla/sessions"
+	bolt "go.etcd.io/bbolt"
+)
+
+// newSession returns an empty session with store defaults for server-side
+// operations done by ID, without a request.
+func (s *BoltStore) newSession(name, id string) *sessions.Session {
+	session := sessions.NewSession(s, name)
+	options := *s.Options
+	if spec, ok := s.named[name]; ok {
+		options.MaxAge = int(spec.expire / time.Second)
+	}
+	session.Options = &options
+	session.ID = id
+	return session
+}
+
+// UpdateByID loads the session with given id, calls fn to mutate it and saves
+// the result within a single write transaction, so the update is atomic.
+// If fn returns an error, nothing is saved and the error is returned as is.
+func (s *BoltStore) UpdateByID(ctx context.Context, id string, fn func(*sessions.Session) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := s.enter(); err != nil {
+		return err
+	}
+	defer s.leave()
+	var (
+		session *sessions.Session
+		rec     *record
+	)
+	// not batched, fn must not run again
+	err := s.writeOnce(func(tx *bolt.Tx) error {
+		_, spec := s.findTx(tx, []byte(id), s.buckets[0])
+		if spec == nil {
+			return ErrNotFound
+		}
+		session = s.newSession(s.sessionName(spec), id)
+		ok, err := s.loadTx(tx, session)
+		if err != nil {
+			return fmt.Errorf("load session error: %w", err)
+		}
+		if !ok {
+			return ErrNotFound
+		}
+		if err := fn(session); err != nil {
+			return err
+		}
+		rec, err = s.saveTx(tx, session)
+		return err
+	})
+	if err != nil {
+		s.forget(id)
+		return s.traced(ctx, err)
+	}
+	s.evicted(ctx, rec.evicted)
+	s.invalidate(ctx, id, EventSave)
+	setMeta(session, metaVersion, rec.version)
+	return nil
+}
@@ -0,0 +1,45 @@
+package boltstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gorilla/sessions"
+)
+
+func TestUpdateByID(t *testing.T) {
+	store := newTestStore(t, Options{})
+	cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"credits": 3})
+	_, session := loadCookie(t, store, "session-key", cookie)
+
+	decrement := func(s *sessions.Session) error {
+		s.Values["credits"] = s.Values["credits"].(int) - 1
+		return nil
+	}
+	ctx := context.Background()
+	if err := store.UpdateByID(ctx, session.ID, decrement); err != nil {
+		t.Fatalf("Error updating session: %v", err)
+	}
+	_, session = loadCookie(t, store, "session-key", cookie)
+	if session.Values["credits"] != 2 {
+		t.Errorf("Expected 2 credits; Got %v", session.Values["credits"])
+	}
+
+	errAbort := errors.New("abort")
+	err := store.UpdateByID(ctx, session.ID, func(s *sessions.Session) error {
+		s.Values["credits"] = 100
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("Expected abort error; Got %v", err)
+	}
+	_, session = loadCookie(t, store, "session-key", cookie)
+	if session.Values["credits"] != 2 {
+		t.Errorf("Expected 2 credits after abort; Got %v", session.Values["credits"])
+	}
+
+	if err := store.UpdateByID(ctx, "missing", decrement); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound; Got %v", err)
+	}
+}
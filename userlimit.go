@@ -0,0 +1,165 @@
+package boltstore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// keyUserSessions is the control bucket key of the index of session IDs by
+// user, maintained with Options.UserKey. Entries of sessions which are gone
+// or changed user are pruned when a session is saved for the user.
+var keyUserSessions = []byte("user_sessions")
+
+// LimitPolicy is what saving a session of a user at Options.MaxUserSessions
+// does.
+type LimitPolicy int
+
+const (
+	// LimitEvict deletes the oldest session of the user which is not
+	// pinned, see Pin, or fails Save like LimitReject if all are pinned.
+	// Evicted sessions are soft deleted with Options.SoftDelete.
+	LimitEvict LimitPolicy = iota
+	// LimitReject fails Save with a *SessionLimitError, so the login
+	// handler can ask the user to log out another device first.
+	LimitReject
+)
+
+// limitUserTx indexes session id saved for user within transaction tx,
+// checking the active sessions of the user first and applying
+// Options.UserLimit at Options.MaxUserSessions. returns IDs of evicted
+// sessions.
+func (s *BoltStore) limitUserTx(tx *bolt.Tx, user []byte, id string) ([]string, error) {
+	index, err := tx.Bucket(controlBucketName(s.options.BucketName)).CreateBucketIfNotExists(keyUserSessions)
+	if err != nil {
+		return nil, fmt.Errorf("create user sessions bucket error: %w", err)
+	}
+	ids, err := index.CreateBucketIfNotExists(user)
+	if err != nil {
+		return nil, fmt.Errorf("create user sessions bucket error: %w", err)
+	}
+
+	type active struct {
+		id     []byte
+		seq    uint64 // order the session was saved for the user in
+		pinned bool
+	}
+	var (
+		sessions []active
+		stale    [][]byte
+	)
+	now := time.Now()
+	err = ids.ForEach(func(k, v []byte) error {
+		if string(k) == id {
+			return nil
+		}
+		b := s.activeBucket(tx, k, now)
+		if b == nil || !bytes.Equal(b.Get(keyUser), user) {
+			stale = append(stale, append([]byte{}, k...))
+			return nil
+		}
+		sessions = append(sessions, active{id: append([]byte{}, k...), seq: decodeUint(v), pinned: b.Get(keyPinned) != nil})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read user sessions error: %w", err)
+	}
+	for _, k := range stale {
+		if err := ids.Delete(k); err != nil {
+			return nil, err
+		}
+	}
+
+	var evicted []string
+	for s.options.MaxUserSessions > 0 && len(sessions) >= s.options.MaxUserSessions {
+		if s.options.UserLimit == LimitReject {
+			return nil, &SessionLimitError{User: string(user), Limit: s.options.MaxUserSessions}
+		}
+		oldest := -1
+		for i := range sessions {
+			if !sessions[i].pinned && (oldest < 0 || sessions[i].seq < sessions[oldest].seq) {
+				oldest = i
+			}
+		}
+		if oldest < 0 {
+			// pinned sessions are never evicted
+			return nil, &SessionLimitError{User: string(user), Limit: s.options.MaxUserSessions}
+		}
+		k := sessions[oldest].id
+		if b, spec := s.findTx(tx, k, s.buckets[0]); b != nil {
+			if err := s.deleteTx(tx, b, spec, string(k)); err != nil {
+				return nil, fmt.Errorf("evict session error: %w", err)
+			}
+		}
+		if err := ids.Delete(k); err != nil {
+			return nil, err
+		}
+		evicted = append(evicted, string(k))
+		sessions = append(sessions[:oldest], sessions[oldest+1:]...)
+	}
+	seq, err := ids.NextSequence()
+	if err != nil {
+		return nil, err
+	}
+	return evicted, ids.Put([]byte(id), encodeUint(seq))
+}
+
+// ListUserSessions returns metadata of the active sessions of user, see
+// Options.UserKey, in the order they were saved for the user. Sessions
+// saved by versions without the user index are listed once they are saved
+// for the user again.
+func (s *BoltStore) ListUserSessions(ctx context.Context, user string) ([]SessionInfo, error) {
+	if err := s.enter(); err != nil {
+		return nil, err
+	}
+	defer s.leave()
+	type indexed struct {
+		info SessionInfo
+		seq  uint64
+	}
+	var list []indexed
+	err := s.db.View(func(tx *bolt.Tx) error {
+		ids := controlNested(tx, s.options.BucketName, keyUserSessions)
+		if ids != nil {
+			ids = ids.Bucket([]byte(user))
+		}
+		if ids == nil {
+			return nil
+		}
+		now := time.Now()
+		return ids.ForEach(func(k, v []byte) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			b := s.activeBucket(tx, k, now)
+			if b == nil || string(b.Get(keyUser)) != user {
+				return nil
+			}
+			_, spec := s.findTx(tx, k, s.buckets[0])
+			list = append(list, indexed{info: s.infoTx(b, spec, string(k)), seq: decodeUint(v)})
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].seq < list[j].seq })
+	infos := make([]SessionInfo, len(list))
+	for i, l := range list {
+		infos[i] = l.info
+	}
+	return infos, nil
+}
+
+// evicted drops data of sessions evicted by a save kept in memory and
+// notifies hooks of their deletion.
+func (s *BoltStore) evicted(ctx context.Context, ids []string) {
+	for _, id := range ids {
+		s.forget(id)
+		s.invalidate(ctx, id, EventDelete)
+	}
+}
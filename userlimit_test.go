@@ -0,0 +1,69 @@
+package boltstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMaxUserSessions(t *testing.T) {
+	store := newTestStore(t, Options{UserKey: "user", MaxUserSessions: 2})
+	first := saveNew(t, store, "session-key", map[interface{}]interface{}{"user": "alice"})
+	_, oldest := loadCookie(t, store, "session-key", first)
+	saveNew(t, store, "session-key", map[interface{}]interface{}{"user": "alice"})
+	saveNew(t, store, "session-key", map[interface{}]interface{}{"user": "bob"})
+	third := saveNew(t, store, "session-key", map[interface{}]interface{}{"user": "alice"})
+	if store.Exists(oldest.ID) {
+		t.Error("Expected the oldest session of alice evicted")
+	}
+	if _, session := loadCookie(t, store, "session-key", third); session.IsNew {
+		t.Error("Expected the new session of alice")
+	}
+
+	store = newTestStore(t, Options{UserKey: "user", MaxUserSessions: 1, UserLimit: LimitReject})
+	cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"user": "alice"})
+	req, session := loadCookie(t, store, "session-key", "")
+	session.Values["user"] = "alice"
+	var limitErr *SessionLimitError
+	if err := store.Save(req, NewRecorder(), session); !errors.As(err, &limitErr) || limitErr.Limit != 1 {
+		t.Fatalf("Expected session limit error; Got %v", err)
+	}
+
+	// the limit frees up when a session is logged out
+	req, session = loadCookie(t, store, "session-key", cookie)
+	session.Options.MaxAge = -1
+	if err := store.Save(req, NewRecorder(), session); err != nil {
+		t.Fatal(err)
+	}
+	saveNew(t, store, "session-key", map[interface{}]interface{}{"user": "alice"})
+}
+
+func TestMaxUserSessionsPinned(t *testing.T) {
+	store := newTestStore(t, Options{UserKey: "user", MaxUserSessions: 2, SoftDelete: time.Hour})
+	first := saveNew(t, store, "session-key", map[interface{}]interface{}{"user": "alice"})
+	_, pinned := loadCookie(t, store, "session-key", first)
+	if err := store.Pin(pinned.ID); err != nil {
+		t.Fatal(err)
+	}
+	second := saveNew(t, store, "session-key", map[interface{}]interface{}{"user": "alice"})
+	_, oldest := loadCookie(t, store, "session-key", second)
+	saveNew(t, store, "session-key", map[interface{}]interface{}{"user": "alice"})
+	if !store.Exists(pinned.ID) || store.Exists(oldest.ID) {
+		t.Fatal("Expected the oldest unpinned session evicted")
+	}
+	if err := store.Undelete(context.Background(), oldest.ID); err != nil {
+		t.Errorf("Expected the evicted session soft deleted; Got %v", err)
+	}
+
+	store = newTestStore(t, Options{UserKey: "user", MaxUserSessions: 1})
+	cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"user": "alice"})
+	_, session := loadCookie(t, store, "session-key", cookie)
+	store.Pin(session.ID)
+	req, session := loadCookie(t, store, "session-key", "")
+	session.Values["user"] = "alice"
+	var limitErr *SessionLimitError
+	if err := store.Save(req, NewRecorder(), session); !errors.As(err, &limitErr) {
+		t.Errorf("Expected session limit error with only pinned sessions; Got %v", err)
+	}
+}
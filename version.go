@@ -0,0 +1,32 @@
+package boltstore
+
+import (
+	"strconv"
+
+	"github.com/gorilla/sessions"
+)
+
+// Version returns the stored record version the session was loaded or last
+// saved with. Zero means the session was never persisted.
+func Version(session *sessions.Session) uint64 {
+	v, _ := getMeta(session, metaVersion)
+	version, _ := v.(uint64)
+	return version
+}
+
+// decodeUint parses a stored version or counter value, nil is treated as zero.
+func decodeUint(b []byte) uint64 {
+	if b == nil {
+		return 0
+	}
+	v, err := strconv.ParseUint(string(b), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+// encodeUint formats a version or counter value for storing.
+func encodeUint(v uint64) []byte {
+	return []byte(strconv.FormatUint(v, 10))
+}
@@ -0,0 +1,35 @@
+package boltstore
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestOptimisticLocking(t *testing.T) {
+	store := newTestStore(t, Options{OptimisticLocking: true})
+	cookie := saveNew(t, store, "session-key", map[interface{}]interface{}{"n": 1})
+
+	reqA, a := loadCookie(t, store, "session-key", cookie)
+	reqB, b := loadCookie(t, store, "session-key", cookie)
+	if Version(a) != 1 || Version(b) != 1 {
+		t.Fatalf("Expected version 1; Got %d, %d", Version(a), Version(b))
+	}
+
+	a.Values["n"] = 2
+	if err := store.Save(reqA, NewRecorder(), a); err != nil {
+		t.Fatalf("Error saving session: %v", err)
+	}
+	if Version(a) != 2 {
+		t.Errorf("Expected version 2; Got %d", Version(a))
+	}
+
+	b.Values["n"] = 3
+	if err := store.Save(reqB, NewRecorder(), b); !errors.Is(err, ErrConflict) {
+		t.Fatalf("Expected ErrConflict; Got %v", err)
+	}
+
+	// saving again within the same request is not a conflict
+	if err := store.Save(reqA, NewRecorder(), a); err != nil {
+		t.Fatalf("Error saving session: %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package boltstore
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// warmUp is the progress of reading stored sessions on start.
+type warmUp struct {
+	running atomic.Bool
+	read    atomic.Int64
+	total   atomic.Int64
+}
+
+// warmSink keeps the byte sum of warm up reads, so the compiler can't drop
+// reads of values which are otherwise unused.
+var warmSink atomic.Uint32
+
+// warmUp reads every stored session once, so its pages are faulted into
+// memory before requests need them. it stops when ctx is done.
+func (s *BoltStore) warmUp(ctx context.Context) {
+	defer s.bg.Done()
+	defer s.warm.running.Store(false)
+	if n, err := s.Count(ctx); err == nil {
+		s.warm.total.Store(int64(n))
+	}
+	var sum byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return s.eachTx(ctx, tx, func(_ *bucketSpec, _ []byte, b *bolt.Bucket) error {
+			c := b.Cursor()
+			for k, v := c.First(); k != nil; k, v = c.Next() {
+				for i := 0; i < len(v); i += 512 {
+					sum += v[i]
+				}
+			}
+			s.warm.read.Add(1)
+			return nil
+		})
+	})
+	warmSink.Store(uint32(sum))
+	if err != nil && ctx.Err() == nil {
+		log.Printf("boltstore: warm up error: %v", err)
+	}
+}
+
+// WarmUpProgress returns the count of sessions read by Options.WarmUp and
+// the count of stored sessions when it started, and whether it is still
+// running.
+func (s *BoltStore) WarmUpProgress() (read, total int, running bool) {
+	return int(s.warm.read.Load()), int(s.warm.total.Load()), s.warm.running.Load()
+}
@@ -0,0 +1,36 @@
+package boltstore
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWarmUp(t *testing.T) {
+	store := newTestStore(t, Options{})
+	for i := 0; i < 3; i++ {
+		saveNew(t, store, "session-key", map[interface{}]interface{}{"n": i})
+	}
+	path := store.DB().Path()
+	store.Close()
+
+	store, err := NewStore(context.Background(), path, Options{KeyPairs: [][]byte{[]byte("secret-key")}, WarmUp: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	deadline := time.Now().Add(time.Second)
+	for {
+		read, total, running := store.WarmUpProgress()
+		if !running {
+			if read != 3 || total != 3 {
+				t.Errorf("Expected 3 of 3 sessions read; Got %d of %d", read, total)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Warm up didn't finish")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
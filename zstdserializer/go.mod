@@ -0,0 +1,18 @@
+module github.com/maxim0r/boltstore/zstdserializer
+
+go 1.21
+
+require (
+	github.com/gorilla/sessions v1.2.1
+	github.com/klauspost/compress v1.17.11
+	github.com/maxim0r/boltstore v0.0.0-20261015013630-bf65fc899b15
+)
+
+require (
+	github.com/gorilla/securecookie v1.1.1 // indirect
+	go.etcd.io/bbolt v1.3.7 // indirect
+	golang.org/x/sys v0.4.0 // indirect
+)
+
+// development against the parent module, ignored by dependents
+replace github.com/maxim0r/boltstore => ../
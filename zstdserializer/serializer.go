@@ -0,0 +1,150 @@
+// Package zstdserializer provides a boltstore.SessionSerializer wrapper
+// compressing session data with zstd, optionally using a dictionary trained
+// on existing sessions. Small sessions barely compress on their own, while a
+// dictionary of their common content shrinks them considerably.
+package zstdserializer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gorilla/sessions"
+	"github.com/klauspost/compress/zstd"
+	"github.com/maxim0r/boltstore"
+)
+
+// magic starts every zstd frame, data without it is stored uncompressed.
+var magic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+// maxHistory is the max size of dictionary content built by Train.
+const maxHistory = 64 << 10
+
+// Serializer compresses data of the Inner serializer. Data is stored
+// uncompressed when compression doesn't make it smaller, and uncompressed
+// records written before compression was enabled are still read.
+type Serializer struct {
+	inner boltstore.SessionSerializer
+	enc   *zstd.Encoder
+	dec   *zstd.Decoder
+}
+
+// New returns a Serializer compressing data of inner with dictionary dict,
+// nil dict compresses without a dictionary. Records compressed with a
+// dictionary can only be read with the same dictionary, keep it with
+// the db.
+func New(inner boltstore.SessionSerializer, dict []byte) (*Serializer, error) {
+	var (
+		eopts = []zstd.EOption{zstd.WithEncoderConcurrency(1)}
+		dopts = []zstd.DOption{zstd.WithDecoderConcurrency(0)}
+	)
+	if dict != nil {
+		eopts = append(eopts, zstd.WithEncoderDict(dict))
+		dopts = append(dopts, zstd.WithDecoderDicts(dict))
+	}
+	enc, err := zstd.NewWriter(nil, eopts...)
+	if err != nil {
+		return nil, fmt.Errorf("create zstd encoder error: %w", err)
+	}
+	dec, err := zstd.NewReader(nil, dopts...)
+	if err != nil {
+		return nil, fmt.Errorf("create zstd decoder error: %w", err)
+	}
+	return &Serializer{inner: inner, enc: enc, dec: dec}, nil
+}
+
+// Serialize serializes the session with the inner serializer and compresses the result.
+func (s *Serializer) Serialize(ss *sessions.Session) ([]byte, error) {
+	b, err := s.inner.Serialize(ss)
+	if err != nil {
+		return nil, err
+	}
+	c := s.enc.EncodeAll(b, nil)
+	if len(c) >= len(b) {
+		return b, nil
+	}
+	return c, nil
+}
+
+// Deserialize decompresses data d if it is compressed and deserializes it
+// with the inner serializer.
+func (s *Serializer) Deserialize(d []byte, ss *sessions.Session) error {
+	if bytes.HasPrefix(d, magic) {
+		b, err := s.dec.DecodeAll(d, nil)
+		if err != nil {
+			return fmt.Errorf("zstd decompress session error: %w", err)
+		}
+		d = b
+	}
+	return s.inner.Deserialize(d, ss)
+}
+
+// SerializerID names the format in exported records.
+func (s *Serializer) SerializerID() string {
+	return "zstd"
+}
+
+// Train builds a dictionary with the given id from sample session data,
+// see Samples. Dictionary ids should be unique per db, 0 is not allowed.
+// Newer samples form the dictionary content, older ones tune its tables.
+func Train(id uint32, samples [][]byte) (dict []byte, err error) {
+	if len(samples) < 2 {
+		return nil, errors.New("not enough samples to train dictionary")
+	}
+	defer func() {
+		// BuildDict panics on some degenerate inputs
+		if r := recover(); r != nil {
+			dict, err = nil, fmt.Errorf("build zstd dictionary error: %v", r)
+		}
+	}()
+	half := len(samples) / 2
+	var history []byte
+	for i := len(samples) - 1; i >= half && len(history)+len(samples[i]) <= maxHistory; i-- {
+		history = append(history, samples[i]...)
+	}
+	dict, err = zstd.BuildDict(zstd.BuildDictOptions{
+		ID:       id,
+		Contents: samples[:half],
+		History:  history,
+		Offsets:  [3]int{1, 4, 8},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("build zstd dictionary error: %w", err)
+	}
+	return dict, nil
+}
+
+// errEnough stops the export when enough samples are collected.
+var errEnough = errors.New("enough samples")
+
+// Samples returns serialized data of up to n active sessions of the store,
+// to train a dictionary. Already compressed records are skipped.
+func Samples(ctx context.Context, store *boltstore.BoltStore, n int) ([][]byte, error) {
+	w := &sampler{n: n}
+	if err := store.Export(ctx, w, boltstore.ExportOptions{}); err != nil && !errors.Is(err, errEnough) {
+		return nil, fmt.Errorf("read samples error: %w", err)
+	}
+	return w.samples, nil
+}
+
+// sampler collects data of exported records.
+type sampler struct {
+	n       int
+	samples [][]byte
+}
+
+func (w *sampler) Write(line []byte) (int, error) {
+	if len(w.samples) >= w.n {
+		return 0, errEnough
+	}
+	var rec boltstore.ExportedRecord
+	if err := json.Unmarshal(line, &rec); err != nil {
+		return 0, err
+	}
+	if len(rec.Data) > 0 && !bytes.HasPrefix(rec.Data, magic) {
+		w.samples = append(w.samples, rec.Data)
+	}
+	return len(line), nil
+}
@@ -0,0 +1,72 @@
+package zstdserializer
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gorilla/sessions"
+	"github.com/maxim0r/boltstore"
+)
+
+func newSession(i int) *sessions.Session {
+	ss := sessions.NewSession(nil, "session-key")
+	ss.Values["user_id"] = fmt.Sprintf("user-%08d", i)
+	ss.Values["email"] = fmt.Sprintf("user%d@example.com", i)
+	ss.Values["roles"] = "reader,writer"
+	ss.Values["locale"] = "en-US"
+	ss.Values["csrf_token"] = fmt.Sprintf("%032x", i*7919)
+	return ss
+}
+
+func TestDictionary(t *testing.T) {
+	ctx := context.Background()
+	store, err := boltstore.NewStore(ctx, filepath.Join(t.TempDir(), "test.db"), boltstore.Options{
+		KeyPairs: [][]byte{[]byte("secret-key")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	for i := 0; i < 200; i++ {
+		ss := newSession(i)
+		ss.ID = fmt.Sprintf("id%d", i)
+		ss.Options = &sessions.Options{MaxAge: 60}
+		if err := store.Save(nil, httptest.NewRecorder(), ss); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	samples, err := Samples(ctx, store, 100)
+	if err != nil || len(samples) != 100 {
+		t.Fatalf("Expected 100 samples; Got %d, %v", len(samples), err)
+	}
+	dict, err := Train(1, samples)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, _ := New(boltstore.GobSerializer{}, nil)
+	trained, err := New(boltstore.GobSerializer{}, dict)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, _ := boltstore.GobSerializer{}.Serialize(newSession(1000))
+	p, _ := plain.Serialize(newSession(1000))
+	d, err := trained.Serialize(newSession(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d) >= len(p) || len(d) >= len(raw)/2 {
+		t.Errorf("Expected dictionary to shrink %d byte session (plain %d); Got %d", len(raw), len(p), len(d))
+	}
+
+	for _, b := range [][]byte{d, raw} {
+		ss := sessions.NewSession(nil, "session-key")
+		if err := trained.Deserialize(b, ss); err != nil || ss.Values["user_id"] != "user-00001000" {
+			t.Errorf("Expected decoded session; Got %v, %v", ss.Values, err)
+		}
+	}
+}